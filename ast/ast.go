@@ -23,6 +23,17 @@ type Expression interface {
 	expressionNode()
 }
 
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// NOTE: the type annotations checked by the (optional) type checker - the evaluator ignores them
+type Type interface {
+	Node
+	typeNode()
+}
+
 // INFO: Program - implements Node
 
 type Program struct {
@@ -53,6 +64,9 @@ func (p *Program) String() string {
 type LetStatement struct {
 	Token token.Token // the token.LET token
 	Name  *Identifier
+	// NOTE: only set for destructuring (let [a, b] = ...), Name is nil then
+	Pattern Pattern
+	Type    Type // let x: int = ... - nil without an annotation (always for destructuring)
 	// NOTE: Identifier implements Expression - as Thornsten said "to keep things simple"...
 	// There are Identifiers that do "produce a value" so we treat them as expressions
 	Value Expression
@@ -64,7 +78,14 @@ func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+	}
+	if ls.Type != nil {
+		out.WriteString(": " + ls.Type.String())
+	}
 	out.WriteString(" = ")
 	if ls.Value != nil {
 		out.WriteString(ls.Value.String())
@@ -116,6 +137,7 @@ func (es *ExpressionStatement) String() string {
 type BlockStatement struct {
 	Token      token.Token // the { token
 	Statements []Statement
+	EndToken   token.Token // the } token - only its position is of interest (for the formatter)
 }
 
 func (bs *BlockStatement) statementNode()       {}
@@ -130,6 +152,16 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
+// INFO: BadStatement - placeholder for a statement the parser couldn't make sense of (see parser.Diagnostics)
+
+type BadStatement struct {
+	Token token.Token // the first token of the statement
+}
+
+func (bs *BadStatement) statementNode()       {}
+func (bs *BadStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BadStatement) String() string       { return "<bad statement>" }
+
 // INFO: ==================================== EXPRESSIONS! ====================================
 
 // INFO: Identifier
@@ -137,12 +169,27 @@ func (bs *BlockStatement) String() string {
 type Identifier struct {
 	Token token.Token // the token.IDENT token
 	Value string
+	// NOTE: filled in by the resolver - the value lives Depth environments up from the current one, in its Slot.
+	// Without Resolved (no resolver ran, a builtin, an undefined name) the name is looked up by its Value.
+	Resolved bool
+	Depth    int
+	Slot     int
 }
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 func (i *Identifier) String() string       { return i.Value }
 
+// INFO: BadExpression - placeholder for an expression the parser couldn't make sense of
+
+type BadExpression struct {
+	Token token.Token // the token where parsing failed
+}
+
+func (be *BadExpression) expressionNode()      {}
+func (be *BadExpression) TokenLiteral() string { return be.Token.Literal }
+func (be *BadExpression) String() string       { return "<bad expression>" }
+
 // INFO: IntegerLiteral
 
 type IntegerLiteral struct {
@@ -154,6 +201,17 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+// INFO: FloatLiteral
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 // INFO: Boolean
 
 type Boolean struct {
@@ -251,7 +309,9 @@ func (ie *IfExpression) String() string {
 
 type FunctionLiteral struct {
 	Token      token.Token // The 'fn' token
-	Parameters []*Identifier
+	Parameters []Pattern   // IdentifierPattern for plain parameters, but destructuring is allowed too
+	Rest       *Identifier // fn(a, ...rest) - nil when there is no rest parameter
+	ReturnType Type        // fn(a): int { ... } - nil without an annotation
 	Body       *BlockStatement
 }
 
@@ -264,11 +324,18 @@ func (fl *FunctionLiteral) String() string {
 	for _, p := range fl.Parameters {
 		params = append(params, p.String())
 	}
+	if fl.Rest != nil {
+		params = append(params, "..."+fl.Rest.String())
+	}
 
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") ")
+	out.WriteString(")")
+	if fl.ReturnType != nil {
+		out.WriteString(": " + fl.ReturnType.String())
+	}
+	out.WriteString(" ")
 	out.WriteString(fl.Body.String())
 
 	return out.String()
@@ -277,9 +344,12 @@ func (fl *FunctionLiteral) String() string {
 // INFO: CallExpression
 
 type CallExpression struct {
-	Token     token.Token // The '(' token
+	Token     token.Token // The '(' token (or the '|>' token for pipelines)
 	Function  Expression  // Identifier or FunctionLiteral
 	Arguments []Expression
+	// NOTE: set when the call was written as a pipeline (x |> f(a) is parsed as f(x, a)),
+	// so the piped value is Arguments[0] and String() can give back the pipeline
+	Piped bool
 }
 
 func (ce *CallExpression) expressionNode()      {}
@@ -292,6 +362,22 @@ func (ce *CallExpression) String() string {
 		args = append(args, a.String())
 	}
 
+	if ce.Piped && len(args) > 0 {
+		out.WriteString("(")
+		out.WriteString(args[0])
+		out.WriteString(" |> ")
+		out.WriteString(ce.Function.String())
+		// NOTE: x |> (f(a)) calls what f(a) gives with x - written as x |> f(a)() to not read like f(x, a)
+		function, isCall := ce.Function.(*CallExpression)
+		if len(args) > 1 || (isCall && !function.Piped) {
+			out.WriteString("(")
+			out.WriteString(strings.Join(args[1:], ", "))
+			out.WriteString(")")
+		}
+		out.WriteString(")")
+		return out.String()
+	}
+
 	out.WriteString(ce.Function.String())
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
@@ -324,6 +410,17 @@ func (al *ArrayLiteral) String() string {
 	return out.String()
 }
 
+// INFO: SpreadExpression - ...xs, only allowed in call arguments and array literals
+
+type SpreadExpression struct {
+	Token token.Token // the ... token
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode()      {}
+func (se *SpreadExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SpreadExpression) String() string       { return "..." + se.Value.String() }
+
 // INFO: IndexExpression
 
 type IndexExpression struct {
@@ -347,6 +444,61 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// INFO: SliceExpression - a[start:end:step], every part is optional (nil when omitted)
+
+type SliceExpression struct {
+	Token token.Token // the [ token
+	Left  Expression
+	Start Expression
+	End   Expression
+	Step  Expression
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	if se.Step != nil {
+		out.WriteString(":")
+		out.WriteString(se.Step.String())
+	}
+	out.WriteString("]")
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// INFO: AssignExpression - only index expressions can be assigned to (arr[0] = 1, hash["key"] = 2)
+
+type AssignExpression struct {
+	Token  token.Token // the = token
+	Target Expression
+	Value  Expression
+}
+
+func (ae *AssignExpression) expressionNode()      {}
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ae.Target.String())
+	out.WriteString(" = ")
+	out.WriteString(ae.Value.String())
+
+	return out.String()
+}
+
 // INFO: HashLiteral
 
 type HashLiteral struct {
@@ -370,3 +522,233 @@ func (hl *HashLiteral) String() string {
 
 	return out.String()
 }
+
+// INFO: ==================================== PATTERNS! ====================================
+
+// INFO: WildcardPattern - `_`, matches anything and binds nothing
+
+type WildcardPattern struct {
+	Token token.Token // the _ token
+}
+
+func (wp *WildcardPattern) patternNode()         {}
+func (wp *WildcardPattern) TokenLiteral() string { return wp.Token.Literal }
+func (wp *WildcardPattern) String() string       { return "_" }
+
+// INFO: LiteralPattern - matches values equal to the literal (1, -2.5, "foo", true)
+
+type LiteralPattern struct {
+	Token token.Token
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode()         {}
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Token.Literal }
+func (lp *LiteralPattern) String() string       { return lp.Value.String() }
+
+// INFO: IdentifierPattern - matches anything and binds it to the name
+
+type IdentifierPattern struct {
+	Token token.Token // the token.IDENT token
+	Name  *Identifier
+	Type  Type // fn(a: int) - only parameters can have an annotation, nil without one
+}
+
+func (ip *IdentifierPattern) patternNode()         {}
+func (ip *IdentifierPattern) TokenLiteral() string { return ip.Token.Literal }
+func (ip *IdentifierPattern) String() string {
+	if ip.Type != nil {
+		return ip.Name.String() + ": " + ip.Type.String()
+	}
+	return ip.Name.String()
+}
+
+// INFO: DefaultPattern - `b = 2`, used when the value is missing (array too short, no such key, no argument)
+
+type DefaultPattern struct {
+	Token   token.Token // the = token
+	Pattern Pattern
+	Default Expression
+}
+
+func (dp *DefaultPattern) patternNode()         {}
+func (dp *DefaultPattern) TokenLiteral() string { return dp.Token.Literal }
+func (dp *DefaultPattern) String() string {
+	return dp.Pattern.String() + " = " + dp.Default.String()
+}
+
+// INFO: ArrayPattern - [a, [b, _], ...rest]
+
+type ArrayPattern struct {
+	Token    token.Token // the [ token
+	Elements []Pattern
+	Rest     *Identifier // nil when there is no ...rest
+}
+
+func (ap *ArrayPattern) patternNode()         {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// INFO: HashPattern - {"kind": "user", "name": name, ...others}, matches hashes having (at least) the given keys
+// NOTE: pairs are kept in a slice (not a map like HashLiteral), so they are matched and printed in source order
+
+type HashPattern struct {
+	Token token.Token // the { token
+	Pairs []*HashPatternPair
+	Rest  *Identifier // nil when there is no ...rest, otherwise gets a hash of the remaining pairs
+}
+
+type HashPatternPair struct {
+	Key   Expression
+	Value Pattern
+}
+
+func (hp *HashPattern) patternNode()         {}
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
+	}
+	if hp.Rest != nil {
+		pairs = append(pairs, "..."+hp.Rest.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// INFO: MatchExpression
+
+type MatchExpression struct {
+	Token    token.Token // the 'match' token
+	Subject  Expression
+	Arms     []*MatchArm
+	EndToken token.Token // the } token - only its position is of interest (for the formatter)
+}
+
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression // nil when the arm has no `if` guard
+	Body    Expression
+}
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	out.WriteString("match (")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// INFO: ==================================== TYPES! ====================================
+
+// INFO: NamedType - int, float, string, bool, null and any (the checker tells which names are types)
+
+type NamedType struct {
+	Token token.Token // the token.IDENT token
+	Name  string
+}
+
+func (nt *NamedType) typeNode()            {}
+func (nt *NamedType) TokenLiteral() string { return nt.Token.Literal }
+func (nt *NamedType) String() string       { return nt.Name }
+
+// INFO: ArrayType - [int]
+
+type ArrayType struct {
+	Token   token.Token // the [ token
+	Element Type
+}
+
+func (at *ArrayType) typeNode()            {}
+func (at *ArrayType) TokenLiteral() string { return at.Token.Literal }
+func (at *ArrayType) String() string       { return "[" + at.Element.String() + "]" }
+
+// INFO: HashType - {string: int}
+
+type HashType struct {
+	Token token.Token // the { token
+	Key   Type
+	Value Type
+}
+
+func (ht *HashType) typeNode()            {}
+func (ht *HashType) TokenLiteral() string { return ht.Token.Literal }
+func (ht *HashType) String() string {
+	return "{" + ht.Key.String() + ": " + ht.Value.String() + "}"
+}
+
+// INFO: FunctionType - fn(int, string): bool
+
+type FunctionType struct {
+	Token      token.Token // The 'fn' token
+	Parameters []Type
+	Return     Type // nil when not annotated - the function can return anything then
+}
+
+func (ft *FunctionType) typeNode()            {}
+func (ft *FunctionType) TokenLiteral() string { return ft.Token.Literal }
+func (ft *FunctionType) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range ft.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+	if ft.Return != nil {
+		out.WriteString(": " + ft.Return.String())
+	}
+
+	return out.String()
+}
@@ -1,7 +1,9 @@
 package ast
 
 import (
+	"encoding/json"
 	"mfiorek/waiig/token"
+	"reflect"
 	"testing"
 )
 
@@ -26,3 +28,410 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+func TestJSON(t *testing.T) {
+	program := &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Token: token.Token{Type: token.LET, Literal: "let", Line: 1, Column: 1},
+				Name: &Identifier{
+					Token: token.Token{Type: token.IDENT, Literal: "x", Line: 1, Column: 5},
+					Value: "x",
+				},
+				Value: &IntegerLiteral{
+					Token: token.Token{Type: token.INT, Literal: "1", Line: 1, Column: 9},
+					Value: 1,
+				},
+			},
+		},
+	}
+
+	expected := `{"statements":[{"annotation":null,"name":{"token":{"type":"IDENT","literal":"x","line":1,"column":5},"type":"Identifier","value":"x"},` +
+		`"pattern":null,"token":{"type":"LET","literal":"let","line":1,"column":1},"type":"LetStatement",` +
+		`"value":{"token":{"type":"INT","literal":"1","line":1,"column":9},"type":"IntegerLiteral","value":1}}],"type":"Program"}`
+
+	encoded, err := EncodeJSON(program)
+	if err != nil {
+		t.Fatalf("EncodeJSON returned error: %s", err)
+	}
+	if string(encoded) != expected {
+		t.Fatalf("EncodeJSON wrong.\nexpected=%s\ngot=     %s", expected, encoded)
+	}
+
+	decoded, err := DecodeJSON(encoded)
+	if err != nil {
+		t.Fatalf("DecodeJSON returned error: %s", err)
+	}
+	if !reflect.DeepEqual(decoded, program) {
+		t.Errorf("DecodeJSON wrong. expected=%#v, got=%#v", program, decoded)
+	}
+
+	var unmarshaled Program
+	if err := json.Unmarshal(encoded, &unmarshaled); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %s", err)
+	}
+	if !reflect.DeepEqual(&unmarshaled, program) {
+		t.Errorf("json.Unmarshal wrong. expected=%#v, got=%#v", program, &unmarshaled)
+	}
+
+	// NOTE: every node type survives the round trip - compared by encoding it again, the keys of a HashLiteral are
+	// pointers (so not DeepEqual) and String() prints its pairs in map order
+	encoded, err = EncodeJSON(everyNodeProgram())
+	if err != nil {
+		t.Fatalf("EncodeJSON returned error: %s", err)
+	}
+	decoded, err = DecodeJSON(encoded)
+	if err != nil {
+		t.Fatalf("DecodeJSON returned error: %s", err)
+	}
+	if reencoded, _ := EncodeJSON(decoded); string(reencoded) != string(encoded) {
+		t.Errorf("DecodeJSON wrong.\nexpected=%s\ngot=     %s", encoded, reencoded)
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`[]`, "invalid node, expected an object: []"},
+		{`{"value": 1}`, `node without a type: {"value": 1}`},
+		{`{"type": "Nope"}`, `unknown node type "Nope"`},
+		{`{"type": "Identifier", "value": "x"}`, `Identifier: missing "token"`},
+		{`{"type": "Program", "statements": [{"type": "WildcardPattern", "token": {"type": "IDENT", "literal": "_"}}]}`,
+			`Program: statements[0]: unexpected WildcardPattern`},
+		{`{"type": "PrefixExpression", "token": {"type": "-", "literal": "-"}, "operator": "-"}`,
+			`PrefixExpression: missing "right"`},
+		{`{"type": "ExpressionStatement", "token": {"type": "TRUE", "literal": "true"}, "expression": {"type": "Boolean", "token": {"type": "TRUE", "literal": "true"}}}`,
+			`ExpressionStatement: "expression": Boolean: missing "value"`},
+		{`{"type": "HashLiteral", "token": {"type": "{", "literal": "{"}, "pairs": [{"key": null, "value": null}]}`,
+			`HashLiteral: pairs[0]: missing "key"`},
+		{`{"type": "LetStatement", "token": {"type": "LET", "literal": "let"}, "value": {"type": "Boolean", "token": {"type": "TRUE", "literal": "true"}, "value": true}}`,
+			`LetStatement: exactly one of name and pattern must be set`},
+	}
+
+	for _, tt := range tests {
+		_, err := DecodeJSON([]byte(tt.input))
+		if err == nil {
+			t.Errorf("DecodeJSON(%s) - expected an error", tt.input)
+			continue
+		}
+		if err.Error() != tt.expectedError {
+			t.Errorf("DecodeJSON(%s) - wrong error.\nexpected=%q\ngot=     %q", tt.input, tt.expectedError, err.Error())
+		}
+	}
+
+	var program Program
+	err := json.Unmarshal([]byte(`{"type": "Identifier", "token": {"type": "IDENT", "literal": "x"}, "value": "x"}`), &program)
+	if err == nil || err.Error() != "expected Program, got Identifier" {
+		t.Errorf("json.Unmarshal into Program - wrong error. got=%v", err)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	program := everyNodeProgram()
+
+	expected := []string{
+		"Program",
+		"LetStatement", "ArrayPattern", "IdentifierPattern", "Identifier", "DefaultPattern", "IdentifierPattern", "Identifier",
+		"IntegerLiteral", "Identifier", "ArrayLiteral", "IntegerLiteral", "SpreadExpression", "Identifier",
+		"LetStatement", "Identifier", "FunctionLiteral", "IdentifierPattern", "Identifier", "HashPattern", "StringLiteral",
+		"WildcardPattern", "Identifier", "BlockStatement", "ReturnStatement", "InfixExpression", "PrefixExpression",
+		"Identifier", "FloatLiteral",
+		"ExpressionStatement", "IfExpression", "Boolean", "BlockStatement", "ExpressionStatement", "AssignExpression",
+		"IndexExpression", "Identifier", "IntegerLiteral", "CallExpression", "Identifier", "IntegerLiteral",
+		"BlockStatement", "ExpressionStatement", "SliceExpression", "Identifier", "IntegerLiteral",
+		"ExpressionStatement", "CallExpression", "Identifier", "Identifier", "IntegerLiteral",
+		"ExpressionStatement", "MatchExpression", "HashLiteral", "IntegerLiteral", "IntegerLiteral", "StringLiteral",
+		"IntegerLiteral", "LiteralPattern", "IntegerLiteral", "Identifier", "IntegerLiteral", "WildcardPattern",
+		"IntegerLiteral",
+		"LetStatement", "Identifier", "FunctionType", "ArrayType", "NamedType", "HashType", "NamedType", "NamedType",
+		"IntegerLiteral",
+	}
+
+	visited := []string{}
+	depth := 0
+	Inspect(program, func(node Node) bool {
+		if node == nil {
+			depth--
+			return false
+		}
+		depth++
+		visited = append(visited, nodeType(node))
+		return true
+	})
+
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("wrong nodes visited.\nexpected=%v\ngot=     %v", expected, visited)
+	}
+	if depth != 0 {
+		t.Errorf("Visit(nil) not called after every node. depth=%d", depth)
+	}
+
+	// NOTE: giving back false skips the children
+	visited = []string{}
+	Inspect(program.Statements[1], func(node Node) bool {
+		if node != nil {
+			visited = append(visited, nodeType(node))
+		}
+		_, isFunction := node.(*FunctionLiteral)
+		return !isFunction
+	})
+	expected = []string{"LetStatement", "Identifier", "FunctionLiteral"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("wrong nodes visited.\nexpected=%v\ngot=     %v", expected, visited)
+	}
+}
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return &IntegerLiteral{Value: 1} }
+	two := func() Expression { return &IntegerLiteral{Value: 2} }
+	x := func() *Identifier { return &Identifier{Value: "x"} }
+	y := func() *Identifier { return &Identifier{Value: "y"} }
+
+	turnOneIntoTwo := func(node Node) Node {
+		if integer, ok := node.(*IntegerLiteral); ok && integer.Value == 1 {
+			integer.Value = 2
+		}
+		if ident, ok := node.(*Identifier); ok && ident.Value == "x" {
+			return y()
+		}
+		return node
+	}
+
+	tests := []struct {
+		input    Node
+		expected Node
+	}{
+		{one(), two()},
+		{x(), y()},
+		{&Program{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: two()}}}},
+		{&LetStatement{Name: x(), Value: one()}, &LetStatement{Name: y(), Value: two()}},
+		{&LetStatement{Pattern: &IdentifierPattern{Name: x()}, Value: one()},
+			&LetStatement{Pattern: &IdentifierPattern{Name: y()}, Value: two()}},
+		{&ReturnStatement{ReturnValue: one()}, &ReturnStatement{ReturnValue: two()}},
+		{&BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			&BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}}},
+		{&PrefixExpression{Operator: "-", Right: one()}, &PrefixExpression{Operator: "-", Right: two()}},
+		{&InfixExpression{Left: one(), Operator: "+", Right: one()}, &InfixExpression{Left: two(), Operator: "+", Right: two()}},
+		{
+			&IfExpression{
+				Condition:   one(),
+				Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+				Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			},
+			&IfExpression{
+				Condition:   two(),
+				Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+				Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+			},
+		},
+		{&IfExpression{Condition: one(), Consequence: &BlockStatement{}}, &IfExpression{Condition: two(), Consequence: &BlockStatement{}}},
+		{
+			&FunctionLiteral{Parameters: []Pattern{&IdentifierPattern{Name: x()}}, Rest: x(),
+				Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}}},
+			&FunctionLiteral{Parameters: []Pattern{&IdentifierPattern{Name: y()}}, Rest: y(),
+				Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}}},
+		},
+		{&CallExpression{Function: x(), Arguments: []Expression{one(), one()}, Piped: true},
+			&CallExpression{Function: y(), Arguments: []Expression{two(), two()}, Piped: true}},
+		{&ArrayLiteral{Elements: []Expression{one(), &SpreadExpression{Value: one()}}},
+			&ArrayLiteral{Elements: []Expression{two(), &SpreadExpression{Value: two()}}}},
+		{&IndexExpression{Left: one(), Index: one()}, &IndexExpression{Left: two(), Index: two()}},
+		{&SliceExpression{Left: one(), Start: one(), Step: one()}, &SliceExpression{Left: two(), Start: two(), Step: two()}},
+		{&AssignExpression{Target: &IndexExpression{Left: x(), Index: one()}, Value: one()},
+			&AssignExpression{Target: &IndexExpression{Left: y(), Index: two()}, Value: two()}},
+		{&WildcardPattern{}, &WildcardPattern{}},
+		{&LiteralPattern{Value: one()}, &LiteralPattern{Value: two()}},
+		{&DefaultPattern{Pattern: &IdentifierPattern{Name: x()}, Default: one()},
+			&DefaultPattern{Pattern: &IdentifierPattern{Name: y()}, Default: two()}},
+		{&ArrayPattern{Elements: []Pattern{&LiteralPattern{Value: one()}}, Rest: x()},
+			&ArrayPattern{Elements: []Pattern{&LiteralPattern{Value: two()}}, Rest: y()}},
+		{&HashPattern{Pairs: []*HashPatternPair{{Key: one(), Value: &IdentifierPattern{Name: x()}}}, Rest: x()},
+			&HashPattern{Pairs: []*HashPatternPair{{Key: two(), Value: &IdentifierPattern{Name: y()}}}, Rest: y()}},
+		{
+			&MatchExpression{Subject: one(), Arms: []*MatchArm{
+				{Pattern: &LiteralPattern{Value: one()}, Guard: one(), Body: one()},
+				{Pattern: &WildcardPattern{}, Body: one()},
+			}},
+			&MatchExpression{Subject: two(), Arms: []*MatchArm{
+				{Pattern: &LiteralPattern{Value: two()}, Guard: two(), Body: two()},
+				{Pattern: &WildcardPattern{}, Body: two()},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		modified := Modify(tt.input, turnOneIntoTwo)
+		if !reflect.DeepEqual(modified, tt.expected) {
+			t.Errorf("not modified. expected=%#v, got=%#v", tt.expected, modified)
+		}
+	}
+
+	// NOTE: HashLiteral keys are pointers, so the pairs are compared one by one
+	hash := &HashLiteral{Pairs: map[Expression]Expression{one(): one(), x(): one()}}
+	Modify(hash, turnOneIntoTwo)
+	if len(hash.Pairs) != 2 {
+		t.Fatalf("wrong number of pairs. got=%d", len(hash.Pairs))
+	}
+	for key, value := range hash.Pairs {
+		if !reflect.DeepEqual(key, two()) && !reflect.DeepEqual(key, y()) {
+			t.Errorf("key not modified. got=%#v", key)
+		}
+		if !reflect.DeepEqual(value, two()) {
+			t.Errorf("value not modified. got=%#v", value)
+		}
+	}
+
+	// NOTE: and on the whole tree, no 1 is left anywhere
+	program := Modify(everyNodeProgram(), turnOneIntoTwo)
+	Inspect(program, func(node Node) bool {
+		if integer, ok := node.(*IntegerLiteral); ok && integer.Value == 1 {
+			t.Errorf("IntegerLiteral 1 not modified")
+		}
+		return true
+	})
+}
+
+func TestModifyWrongReplacement(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r != "ast.Modify: cannot replace *ast.BlockStatement with *ast.IntegerLiteral" {
+			t.Errorf("wrong panic. got=%v", r)
+		}
+	}()
+
+	node := &IfExpression{Condition: &Boolean{Value: true}, Consequence: &BlockStatement{}}
+	Modify(node, func(node Node) Node {
+		if _, ok := node.(*BlockStatement); ok {
+			return &IntegerLiteral{Value: 1}
+		}
+		return node
+	})
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+// NOTE: an AST with every node type (and the optional children set), as the parser would give it for:
+//
+//	let [a, b = 1, ...r] = [1, ...xs];
+//	let f = fn(x, {"k": _, ...o}) { return -x + 1.5; };
+//	if (true) { xs[1] = f(1) } else { xs[1:] }
+//	xs |> g(1);
+//	match ({1: 1, "s": 1}) { 1 if a => 1, _ => 1 }
+//	let h: fn([int]): {string: bool} = 1;
+func everyNodeProgram() *Program {
+	at := func(tokenType token.TokenType, literal string, line, column int) token.Token {
+		return token.Token{Type: tokenType, Literal: literal, Line: line, Column: column}
+	}
+	ident := func(name string, line, column int) *Identifier {
+		return &Identifier{Token: at(token.IDENT, name, line, column), Value: name}
+	}
+	one := func(line, column int) *IntegerLiteral {
+		return &IntegerLiteral{Token: at(token.INT, "1", line, column), Value: 1}
+	}
+
+	return &Program{Statements: []Statement{
+		&LetStatement{
+			Token: at(token.LET, "let", 1, 1),
+			Pattern: &ArrayPattern{
+				Token: at(token.LBRACKET, "[", 1, 5),
+				Elements: []Pattern{
+					&IdentifierPattern{Token: at(token.IDENT, "a", 1, 6), Name: ident("a", 1, 6)},
+					&DefaultPattern{
+						Token:   at(token.ASSIGN, "=", 1, 11),
+						Pattern: &IdentifierPattern{Token: at(token.IDENT, "b", 1, 9), Name: ident("b", 1, 9)},
+						Default: one(1, 13),
+					},
+				},
+				Rest: ident("r", 1, 19),
+			},
+			Value: &ArrayLiteral{Token: at(token.LBRACKET, "[", 1, 24), Elements: []Expression{
+				one(1, 25),
+				&SpreadExpression{Token: at(token.ELLIPSIS, "...", 1, 28), Value: ident("xs", 1, 31)},
+			}},
+		},
+		&LetStatement{
+			Token: at(token.LET, "let", 2, 1),
+			Name:  ident("f", 2, 5),
+			Value: &FunctionLiteral{
+				Token: at(token.FUNCTION, "fn", 2, 9),
+				Parameters: []Pattern{
+					&IdentifierPattern{Token: at(token.IDENT, "x", 2, 12), Name: ident("x", 2, 12)},
+					&HashPattern{
+						Token: at(token.LBRACE, "{", 2, 15),
+						Pairs: []*HashPatternPair{{
+							Key:   &StringLiteral{Token: at(token.STRING, "k", 2, 16), Value: "k"},
+							Value: &WildcardPattern{Token: at(token.IDENT, "_", 2, 21)},
+						}},
+						Rest: ident("o", 2, 27),
+					},
+				},
+				Body: &BlockStatement{Token: at(token.LBRACE, "{", 2, 31), Statements: []Statement{
+					&ReturnStatement{Token: at(token.RETURN, "return", 2, 33), ReturnValue: &InfixExpression{
+						Token:    at(token.PLUS, "+", 2, 43),
+						Left:     &PrefixExpression{Token: at(token.MINUS, "-", 2, 40), Operator: "-", Right: ident("x", 2, 41)},
+						Operator: "+",
+						Right:    &FloatLiteral{Token: at(token.FLOAT, "1.5", 2, 45), Value: 1.5},
+					}},
+				}},
+			},
+		},
+		&ExpressionStatement{Token: at(token.IF, "if", 3, 1), Expression: &IfExpression{
+			Token:     at(token.IF, "if", 3, 1),
+			Condition: &Boolean{Token: at(token.TRUE, "true", 3, 5), Value: true},
+			Consequence: &BlockStatement{Token: at(token.LBRACE, "{", 3, 11), Statements: []Statement{
+				&ExpressionStatement{Token: at(token.IDENT, "xs", 3, 13), Expression: &AssignExpression{
+					Token:  at(token.ASSIGN, "=", 3, 19),
+					Target: &IndexExpression{Token: at(token.LBRACKET, "[", 3, 15), Left: ident("xs", 3, 13), Index: one(3, 16)},
+					Value: &CallExpression{
+						Token:     at(token.LPAREN, "(", 3, 22),
+						Function:  ident("f", 3, 21),
+						Arguments: []Expression{one(3, 23)},
+					},
+				}},
+			}},
+			Alternative: &BlockStatement{Token: at(token.LBRACE, "{", 3, 33), Statements: []Statement{
+				&ExpressionStatement{Token: at(token.IDENT, "xs", 3, 35), Expression: &SliceExpression{
+					Token: at(token.LBRACKET, "[", 3, 37),
+					Left:  ident("xs", 3, 35),
+					Start: one(3, 38),
+				}},
+			}},
+		}},
+		&ExpressionStatement{Token: at(token.IDENT, "xs", 4, 1), Expression: &CallExpression{
+			Token:     at(token.LPAREN, "(", 4, 8),
+			Function:  ident("g", 4, 7),
+			Arguments: []Expression{ident("xs", 4, 1), one(4, 9)},
+			Piped:     true,
+		}},
+		&ExpressionStatement{Token: at(token.MATCH, "match", 5, 1), Expression: &MatchExpression{
+			Token: at(token.MATCH, "match", 5, 1),
+			Subject: &HashLiteral{Token: at(token.LBRACE, "{", 5, 8), Pairs: map[Expression]Expression{
+				one(5, 9): one(5, 12),
+				&StringLiteral{Token: at(token.STRING, "s", 5, 15), Value: "s"}: one(5, 20),
+			}},
+			Arms: []*MatchArm{
+				{Pattern: &LiteralPattern{Token: at(token.INT, "1", 5, 26), Value: one(5, 26)}, Guard: ident("a", 5, 31), Body: one(5, 36)},
+				{Pattern: &WildcardPattern{Token: at(token.IDENT, "_", 5, 39)}, Body: one(5, 44)},
+			},
+		}},
+		&LetStatement{
+			Token: at(token.LET, "let", 6, 1),
+			Name:  ident("h", 6, 5),
+			Type: &FunctionType{
+				Token: at(token.FUNCTION, "fn", 6, 8),
+				Parameters: []Type{
+					&ArrayType{Token: at(token.LBRACKET, "[", 6, 11), Element: &NamedType{Token: at(token.IDENT, "int", 6, 12), Name: "int"}},
+				},
+				Return: &HashType{
+					Token: at(token.LBRACE, "{", 6, 19),
+					Key:   &NamedType{Token: at(token.IDENT, "string", 6, 20), Name: "string"},
+					Value: &NamedType{Token: at(token.IDENT, "bool", 6, 28), Name: "bool"},
+				},
+			},
+			Value: one(6, 36),
+		},
+	}}
+}
@@ -0,0 +1,719 @@
+package ast
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"mfiorek/waiig/token"
+	"slices"
+)
+
+// INFO: JSON encoding of the AST - for external analysis tools and for caching parse results.
+//
+// The schema:
+//   - every Node is an object with a "type" (the Go type name: "Program", "LetStatement", "InfixExpression", ...)
+//     and a "token" - the node's token.Token as {"type", "literal", "line", "column"} (positions are left out when unknown).
+//     Program is the only node without a token.
+//   - the other members are the fields of the Go type, in lowerCamelCase ("returnValue", "consequence", ...),
+//     child nodes are nested objects, lists of nodes arrays and missing optional children (an `else`, a ...rest) null.
+//   - IntegerLiteral, FloatLiteral, Boolean and StringLiteral have their "value" as a JSON number, bool or string.
+//   - HashLiteral "pairs" and HashPattern "pairs" are arrays of {"key", "value"} objects, MatchExpression "arms"
+//     an array of {"pattern", "guard", "body"} objects - these aren't nodes, so they have no "type".
+//     The pairs of a HashLiteral are ordered by the position of their keys, so the encoding is deterministic.
+//   - BlockStatement and MatchExpression have an "endToken" too - their closing }.
+//   - BadStatement and BadExpression (left by the parser where the source didn't parse) have only their "token".
+//   - type annotations are nodes too (NamedType, ArrayType, HashType, FunctionType) - "type" being taken, the one of
+//     a LetStatement or an IdentifierPattern is its "annotation", the one of a FunctionLiteral its "returnType".
+//
+// For example `let x = 1;` is:
+//
+//	{"type": "Program", "statements": [{"type": "LetStatement",
+//	  "token": {"type": "LET", "literal": "let", "line": 1, "column": 1},
+//	  "name": {"type": "Identifier", "token": {"type": "IDENT", "literal": "x", "line": 1, "column": 5}, "value": "x"},
+//	  "pattern": null, "annotation": null,
+//	  "value": {"type": "IntegerLiteral", "token": {"type": "INT", "literal": "1", "line": 1, "column": 9}, "value": 1}}]}
+
+func EncodeJSON(node Node) ([]byte, error) {
+	encoded, err := encodeNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// NOTE: gives back the node the JSON describes - with the right types for every child, so the result can be
+// evaluated just like a parsed one
+func DecodeJSON(data []byte) (Node, error) {
+	return decodeNode(json.RawMessage(data))
+}
+
+func (p *Program) MarshalJSON() ([]byte, error) {
+	return EncodeJSON(p)
+}
+func (p *Program) UnmarshalJSON(data []byte) error {
+	node, err := DecodeJSON(data)
+	if err != nil {
+		return err
+	}
+	program, ok := node.(*Program)
+	if !ok {
+		return fmt.Errorf("expected Program, got %s", nodeType(node))
+	}
+	*p = *program
+	return nil
+}
+
+// INFO: ==================================== Encoding ====================================
+
+type jsonObject map[string]any
+
+type jsonToken struct {
+	Type    token.TokenType `json:"type"`
+	Literal string          `json:"literal"`
+	Line    int             `json:"line,omitempty"`
+	Column  int             `json:"column,omitempty"`
+}
+
+func encodeToken(tok token.Token) jsonToken {
+	return jsonToken{Type: tok.Type, Literal: tok.Literal, Line: tok.Line, Column: tok.Column}
+}
+
+func encodeNode(node Node) (any, error) {
+	var obj jsonObject
+	var err error
+
+	switch node := node.(type) {
+	case nil:
+		return nil, nil
+	case *Program:
+		obj = jsonObject{"statements": encodeList(node.Statements, &err)}
+	case *LetStatement:
+		obj = jsonObject{
+			"name":       encodeChild(node.Name, &err),
+			"pattern":    encodeChild(node.Pattern, &err),
+			"annotation": encodeChild(node.Type, &err),
+			"value":      encodeChild(node.Value, &err),
+		}
+	case *ReturnStatement:
+		obj = jsonObject{"returnValue": encodeChild(node.ReturnValue, &err)}
+	case *ExpressionStatement:
+		obj = jsonObject{"expression": encodeChild(node.Expression, &err)}
+	case *BlockStatement:
+		// NOTE: a missing Alternative (or Rest, or let Name) comes in as a typed nil - a non-nil Node
+		if node == nil {
+			return nil, nil
+		}
+		obj = jsonObject{
+			"statements": encodeList(node.Statements, &err),
+			"endToken":   encodeToken(node.EndToken),
+		}
+	case *BadStatement, *BadExpression:
+		obj = jsonObject{}
+	case *Identifier:
+		if node == nil {
+			return nil, nil
+		}
+		obj = jsonObject{"value": node.Value}
+	case *IntegerLiteral:
+		obj = jsonObject{"value": node.Value}
+	case *FloatLiteral:
+		obj = jsonObject{"value": node.Value}
+	case *Boolean:
+		obj = jsonObject{"value": node.Value}
+	case *StringLiteral:
+		obj = jsonObject{"value": node.Value}
+	case *PrefixExpression:
+		obj = jsonObject{
+			"operator": node.Operator,
+			"right":    encodeChild(node.Right, &err),
+		}
+	case *InfixExpression:
+		obj = jsonObject{
+			"left":     encodeChild(node.Left, &err),
+			"operator": node.Operator,
+			"right":    encodeChild(node.Right, &err),
+		}
+	case *IfExpression:
+		obj = jsonObject{
+			"condition":   encodeChild(node.Condition, &err),
+			"consequence": encodeChild(node.Consequence, &err),
+			"alternative": encodeChild(node.Alternative, &err),
+		}
+	case *FunctionLiteral:
+		obj = jsonObject{
+			"parameters": encodeList(node.Parameters, &err),
+			"rest":       encodeChild(node.Rest, &err),
+			"returnType": encodeChild(node.ReturnType, &err),
+			"body":       encodeChild(node.Body, &err),
+		}
+	case *CallExpression:
+		obj = jsonObject{
+			"function":  encodeChild(node.Function, &err),
+			"arguments": encodeList(node.Arguments, &err),
+			"piped":     node.Piped,
+		}
+	case *ArrayLiteral:
+		obj = jsonObject{"elements": encodeList(node.Elements, &err)}
+	case *SpreadExpression:
+		obj = jsonObject{"value": encodeChild(node.Value, &err)}
+	case *IndexExpression:
+		obj = jsonObject{
+			"left":  encodeChild(node.Left, &err),
+			"index": encodeChild(node.Index, &err),
+		}
+	case *SliceExpression:
+		obj = jsonObject{
+			"left":  encodeChild(node.Left, &err),
+			"start": encodeChild(node.Start, &err),
+			"end":   encodeChild(node.End, &err),
+			"step":  encodeChild(node.Step, &err),
+		}
+	case *AssignExpression:
+		obj = jsonObject{
+			"target": encodeChild(node.Target, &err),
+			"value":  encodeChild(node.Value, &err),
+		}
+	case *HashLiteral:
+		pairs := []jsonObject{}
+		for _, key := range sortedHashKeys(node) {
+			pairs = append(pairs, jsonObject{
+				"key":   encodeChild(key, &err),
+				"value": encodeChild(node.Pairs[key], &err),
+			})
+		}
+		obj = jsonObject{"pairs": pairs}
+	case *WildcardPattern:
+		obj = jsonObject{}
+	case *LiteralPattern:
+		obj = jsonObject{"value": encodeChild(node.Value, &err)}
+	case *IdentifierPattern:
+		obj = jsonObject{
+			"name":       encodeChild(node.Name, &err),
+			"annotation": encodeChild(node.Type, &err),
+		}
+	case *DefaultPattern:
+		obj = jsonObject{
+			"pattern": encodeChild(node.Pattern, &err),
+			"default": encodeChild(node.Default, &err),
+		}
+	case *ArrayPattern:
+		obj = jsonObject{
+			"elements": encodeList(node.Elements, &err),
+			"rest":     encodeChild(node.Rest, &err),
+		}
+	case *HashPattern:
+		pairs := []jsonObject{}
+		for _, pair := range node.Pairs {
+			pairs = append(pairs, jsonObject{
+				"key":   encodeChild(pair.Key, &err),
+				"value": encodeChild(pair.Value, &err),
+			})
+		}
+		obj = jsonObject{
+			"pairs": pairs,
+			"rest":  encodeChild(node.Rest, &err),
+		}
+	case *MatchExpression:
+		arms := []jsonObject{}
+		for _, arm := range node.Arms {
+			arms = append(arms, jsonObject{
+				"pattern": encodeChild(arm.Pattern, &err),
+				"guard":   encodeChild(arm.Guard, &err),
+				"body":    encodeChild(arm.Body, &err),
+			})
+		}
+		obj = jsonObject{
+			"subject":  encodeChild(node.Subject, &err),
+			"arms":     arms,
+			"endToken": encodeToken(node.EndToken),
+		}
+	case *NamedType:
+		obj = jsonObject{"name": node.Name}
+	case *ArrayType:
+		obj = jsonObject{"element": encodeChild(node.Element, &err)}
+	case *HashType:
+		obj = jsonObject{
+			"key":   encodeChild(node.Key, &err),
+			"value": encodeChild(node.Value, &err),
+		}
+	case *FunctionType:
+		obj = jsonObject{
+			"parameters": encodeList(node.Parameters, &err),
+			"return":     encodeChild(node.Return, &err),
+		}
+	default:
+		return nil, fmt.Errorf("cannot encode node of type %T", node)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	obj["type"] = nodeType(node)
+	if tok, ok := TokenOf(node); ok {
+		obj["token"] = encodeToken(tok)
+	}
+	return obj, nil
+}
+
+// WARN: Helper method used only in encodeNode - keeps the first error, so the fields can be listed in one literal
+func encodeChild[T Node](child T, err *error) any {
+	if *err != nil {
+		return nil
+	}
+	encoded, childErr := encodeNode(child)
+	if childErr != nil {
+		*err = childErr
+	}
+	return encoded
+}
+
+// WARN: Helper method used only in encodeNode - always gives an array, even for a nil slice
+func encodeList[T Node](children []T, err *error) []any {
+	list := []any{}
+	for _, child := range children {
+		list = append(list, encodeChild(child, err))
+	}
+	return list
+}
+
+func nodeType(node Node) string {
+	if node == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%T", node)[len("*ast."):]
+}
+
+// NOTE: the token a node was created from (the operator of an infix, the ( of a call, ...) - false for a Program,
+// which has none
+func TokenOf(node Node) (token.Token, bool) {
+	switch node := node.(type) {
+	case *LetStatement:
+		return node.Token, true
+	case *ReturnStatement:
+		return node.Token, true
+	case *ExpressionStatement:
+		return node.Token, true
+	case *BadStatement:
+		return node.Token, true
+	case *BadExpression:
+		return node.Token, true
+	case *BlockStatement:
+		return node.Token, true
+	case *Identifier:
+		return node.Token, true
+	case *IntegerLiteral:
+		return node.Token, true
+	case *FloatLiteral:
+		return node.Token, true
+	case *Boolean:
+		return node.Token, true
+	case *StringLiteral:
+		return node.Token, true
+	case *PrefixExpression:
+		return node.Token, true
+	case *InfixExpression:
+		return node.Token, true
+	case *IfExpression:
+		return node.Token, true
+	case *FunctionLiteral:
+		return node.Token, true
+	case *CallExpression:
+		return node.Token, true
+	case *ArrayLiteral:
+		return node.Token, true
+	case *SpreadExpression:
+		return node.Token, true
+	case *IndexExpression:
+		return node.Token, true
+	case *SliceExpression:
+		return node.Token, true
+	case *AssignExpression:
+		return node.Token, true
+	case *HashLiteral:
+		return node.Token, true
+	case *WildcardPattern:
+		return node.Token, true
+	case *LiteralPattern:
+		return node.Token, true
+	case *IdentifierPattern:
+		return node.Token, true
+	case *DefaultPattern:
+		return node.Token, true
+	case *ArrayPattern:
+		return node.Token, true
+	case *HashPattern:
+		return node.Token, true
+	case *MatchExpression:
+		return node.Token, true
+	case *NamedType:
+		return node.Token, true
+	case *ArrayType:
+		return node.Token, true
+	case *HashType:
+		return node.Token, true
+	case *FunctionType:
+		return node.Token, true
+	}
+	return token.Token{}, false
+}
+
+// NOTE: source order when the keys have positions, their String() otherwise (and to break ties)
+func sortedHashKeys(hl *HashLiteral) []Expression {
+	keys := make([]Expression, 0, len(hl.Pairs))
+	for key := range hl.Pairs {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b Expression) int {
+		aTok, _ := TokenOf(a)
+		bTok, _ := TokenOf(b)
+		return cmp.Or(
+			cmp.Compare(aTok.Line, bTok.Line),
+			cmp.Compare(aTok.Column, bTok.Column),
+			cmp.Compare(a.String(), b.String()),
+		)
+	})
+	return keys
+}
+
+// INFO: ==================================== Decoding ====================================
+
+// NOTE: the fields of one node being decoded - the first error sticks, so a node can be built in one literal
+// and the error checked once at the end
+type nodeDecoder struct {
+	nodeType string
+	fields   map[string]json.RawMessage
+	err      error
+}
+
+func decodeNode(raw json.RawMessage) (Node, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+
+	d := &nodeDecoder{}
+	if err := json.Unmarshal(raw, &d.fields); err != nil {
+		return nil, fmt.Errorf("invalid node, expected an object: %.40s", raw)
+	}
+	if err := json.Unmarshal(d.fields["type"], &d.nodeType); err != nil || d.nodeType == "" {
+		return nil, fmt.Errorf("node without a type: %s", raw)
+	}
+
+	var node Node
+	switch d.nodeType {
+	case "Program":
+		node = &Program{Statements: decodeList(d, "statements", asStatement)}
+	case "LetStatement":
+		node = &LetStatement{
+			Token:   d.token("token"),
+			Name:    optional(d, "name", asIdentifier),
+			Pattern: optional(d, "pattern", asPattern),
+			Type:    optional(d, "annotation", asType),
+			Value:   required(d, "value", asExpression),
+		}
+	case "ReturnStatement":
+		node = &ReturnStatement{Token: d.token("token"), ReturnValue: optional(d, "returnValue", asExpression)}
+	case "ExpressionStatement":
+		node = &ExpressionStatement{Token: d.token("token"), Expression: optional(d, "expression", asExpression)}
+	case "BlockStatement":
+		node = d.block()
+	case "BadStatement":
+		node = &BadStatement{Token: d.token("token")}
+	case "BadExpression":
+		node = &BadExpression{Token: d.token("token")}
+	case "Identifier":
+		node = d.identifier()
+	case "IntegerLiteral":
+		lit := &IntegerLiteral{Token: d.token("token")}
+		d.value("value", &lit.Value)
+		node = lit
+	case "FloatLiteral":
+		lit := &FloatLiteral{Token: d.token("token")}
+		d.value("value", &lit.Value)
+		node = lit
+	case "Boolean":
+		lit := &Boolean{Token: d.token("token")}
+		d.value("value", &lit.Value)
+		node = lit
+	case "StringLiteral":
+		lit := &StringLiteral{Token: d.token("token")}
+		d.value("value", &lit.Value)
+		node = lit
+	case "PrefixExpression":
+		expr := &PrefixExpression{Token: d.token("token"), Right: required(d, "right", asExpression)}
+		d.value("operator", &expr.Operator)
+		node = expr
+	case "InfixExpression":
+		expr := &InfixExpression{
+			Token: d.token("token"),
+			Left:  required(d, "left", asExpression),
+			Right: required(d, "right", asExpression),
+		}
+		d.value("operator", &expr.Operator)
+		node = expr
+	case "IfExpression":
+		node = &IfExpression{
+			Token:       d.token("token"),
+			Condition:   required(d, "condition", asExpression),
+			Consequence: required(d, "consequence", asBlock),
+			Alternative: optional(d, "alternative", asBlock),
+		}
+	case "FunctionLiteral":
+		node = &FunctionLiteral{
+			Token:      d.token("token"),
+			Parameters: decodeList(d, "parameters", asPattern),
+			Rest:       optional(d, "rest", asIdentifier),
+			ReturnType: optional(d, "returnType", asType),
+			Body:       required(d, "body", asBlock),
+		}
+	case "CallExpression":
+		expr := &CallExpression{
+			Token:     d.token("token"),
+			Function:  required(d, "function", asExpression),
+			Arguments: decodeList(d, "arguments", asExpression),
+		}
+		d.optionalValue("piped", &expr.Piped)
+		node = expr
+	case "ArrayLiteral":
+		node = &ArrayLiteral{Token: d.token("token"), Elements: decodeList(d, "elements", asExpression)}
+	case "SpreadExpression":
+		node = &SpreadExpression{Token: d.token("token"), Value: required(d, "value", asExpression)}
+	case "IndexExpression":
+		node = &IndexExpression{
+			Token: d.token("token"),
+			Left:  required(d, "left", asExpression),
+			Index: required(d, "index", asExpression),
+		}
+	case "SliceExpression":
+		node = &SliceExpression{
+			Token: d.token("token"),
+			Left:  required(d, "left", asExpression),
+			Start: optional(d, "start", asExpression),
+			End:   optional(d, "end", asExpression),
+			Step:  optional(d, "step", asExpression),
+		}
+	case "AssignExpression":
+		node = &AssignExpression{
+			Token:  d.token("token"),
+			Target: required(d, "target", asExpression),
+			Value:  required(d, "value", asExpression),
+		}
+	case "HashLiteral":
+		hash := &HashLiteral{Token: d.token("token"), Pairs: make(map[Expression]Expression)}
+		for _, pair := range d.objects("pairs") {
+			hash.Pairs[required(pair, "key", asExpression)] = required(pair, "value", asExpression)
+			d.adopt(pair)
+		}
+		node = hash
+	case "WildcardPattern":
+		node = &WildcardPattern{Token: d.token("token")}
+	case "LiteralPattern":
+		node = &LiteralPattern{Token: d.token("token"), Value: required(d, "value", asExpression)}
+	case "IdentifierPattern":
+		node = &IdentifierPattern{
+			Token: d.token("token"),
+			Name:  required(d, "name", asIdentifier),
+			Type:  optional(d, "annotation", asType),
+		}
+	case "DefaultPattern":
+		node = &DefaultPattern{
+			Token:   d.token("token"),
+			Pattern: required(d, "pattern", asPattern),
+			Default: required(d, "default", asExpression),
+		}
+	case "ArrayPattern":
+		node = &ArrayPattern{
+			Token:    d.token("token"),
+			Elements: decodeList(d, "elements", asPattern),
+			Rest:     optional(d, "rest", asIdentifier),
+		}
+	case "HashPattern":
+		pattern := &HashPattern{Token: d.token("token"), Rest: optional(d, "rest", asIdentifier)}
+		for _, pair := range d.objects("pairs") {
+			pattern.Pairs = append(pattern.Pairs, &HashPatternPair{
+				Key:   required(pair, "key", asExpression),
+				Value: required(pair, "value", asPattern),
+			})
+			d.adopt(pair)
+		}
+		node = pattern
+	case "MatchExpression":
+		match := &MatchExpression{
+			Token:    d.token("token"),
+			Subject:  required(d, "subject", asExpression),
+			EndToken: d.token("endToken"),
+		}
+		for _, arm := range d.objects("arms") {
+			match.Arms = append(match.Arms, &MatchArm{
+				Pattern: required(arm, "pattern", asPattern),
+				Guard:   optional(arm, "guard", asExpression),
+				Body:    required(arm, "body", asExpression),
+			})
+			d.adopt(arm)
+		}
+		node = match
+	case "NamedType":
+		named := &NamedType{Token: d.token("token")}
+		d.value("name", &named.Name)
+		node = named
+	case "ArrayType":
+		node = &ArrayType{Token: d.token("token"), Element: required(d, "element", asType)}
+	case "HashType":
+		node = &HashType{
+			Token: d.token("token"),
+			Key:   required(d, "key", asType),
+			Value: required(d, "value", asType),
+		}
+	case "FunctionType":
+		node = &FunctionType{
+			Token:      d.token("token"),
+			Parameters: decodeList(d, "parameters", asType),
+			Return:     optional(d, "return", asType),
+		}
+	default:
+		return nil, fmt.Errorf("unknown node type %q", d.nodeType)
+	}
+
+	if d.err != nil {
+		return nil, fmt.Errorf("%s: %w", d.nodeType, d.err)
+	}
+	if let, ok := node.(*LetStatement); ok && (let.Name == nil) == (let.Pattern == nil) {
+		return nil, fmt.Errorf("LetStatement: exactly one of name and pattern must be set")
+	}
+	return node, nil
+}
+
+// INFO: Helper methods of nodeDecoder
+
+func (d *nodeDecoder) fail(format string, a ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, a...)
+	}
+}
+
+func (d *nodeDecoder) token(name string) token.Token {
+	var tok jsonToken
+	d.value(name, &tok)
+	return token.Token{Type: tok.Type, Literal: tok.Literal, Line: tok.Line, Column: tok.Column}
+}
+
+func (d *nodeDecoder) value(name string, target any) {
+	if d.err != nil {
+		return
+	}
+	raw, ok := d.fields[name]
+	if !ok || isNull(raw) {
+		d.fail("missing %q", name)
+		return
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		d.fail("invalid %q: %s", name, err)
+	}
+}
+
+func (d *nodeDecoder) optionalValue(name string, target any) {
+	if raw, ok := d.fields[name]; ok && !isNull(raw) {
+		d.value(name, target)
+	}
+}
+
+// NOTE: the not-node objects of a list (hash pairs, match arms) - decoded with decoders of their own,
+// whose errors are taken over with adopt
+func (d *nodeDecoder) objects(name string) []*nodeDecoder {
+	var raws []json.RawMessage
+	d.optionalValue(name, &raws)
+
+	decoders := []*nodeDecoder{}
+	for idx, raw := range raws {
+		sub := &nodeDecoder{nodeType: fmt.Sprintf("%s[%d]", name, idx)}
+		if err := json.Unmarshal(raw, &sub.fields); err != nil {
+			d.fail("invalid %s: %s", sub.nodeType, err)
+			continue
+		}
+		decoders = append(decoders, sub)
+	}
+	return decoders
+}
+
+func (d *nodeDecoder) adopt(sub *nodeDecoder) {
+	if sub.err != nil {
+		d.fail("%s: %w", sub.nodeType, sub.err)
+	}
+}
+
+func (d *nodeDecoder) block() *BlockStatement {
+	return &BlockStatement{
+		Token:      d.token("token"),
+		Statements: decodeList(d, "statements", asStatement),
+		EndToken:   d.token("endToken"),
+	}
+}
+
+func (d *nodeDecoder) identifier() *Identifier {
+	ident := &Identifier{Token: d.token("token")}
+	d.value("value", &ident.Value)
+	return ident
+}
+
+// INFO: Conversions of decoded nodes to the type a field needs
+
+func asStatement(node Node) (Statement, bool)    { s, ok := node.(Statement); return s, ok }
+func asExpression(node Node) (Expression, bool)  { e, ok := node.(Expression); return e, ok }
+func asPattern(node Node) (Pattern, bool)        { p, ok := node.(Pattern); return p, ok }
+func asBlock(node Node) (*BlockStatement, bool)  { b, ok := node.(*BlockStatement); return b, ok }
+func asIdentifier(node Node) (*Identifier, bool) { i, ok := node.(*Identifier); return i, ok }
+func asType(node Node) (Type, bool)              { t, ok := node.(Type); return t, ok }
+
+func optional[T any](d *nodeDecoder, name string, as func(Node) (T, bool)) T {
+	var zero T
+	if d.err != nil {
+		return zero
+	}
+	raw, ok := d.fields[name]
+	if !ok || isNull(raw) {
+		return zero
+	}
+	node, err := decodeNode(raw)
+	if err != nil {
+		d.fail("%q: %w", name, err)
+		return zero
+	}
+	converted, ok := as(node)
+	if !ok {
+		d.fail("%q: unexpected %s", name, nodeType(node))
+		return zero
+	}
+	return converted
+}
+
+func required[T any](d *nodeDecoder, name string, as func(Node) (T, bool)) T {
+	if raw, ok := d.fields[name]; (!ok || isNull(raw)) && d.err == nil {
+		d.fail("missing %q", name)
+	}
+	return optional(d, name, as)
+}
+
+func decodeList[T any](d *nodeDecoder, name string, as func(Node) (T, bool)) []T {
+	var raws []json.RawMessage
+	d.optionalValue(name, &raws)
+
+	list := []T{}
+	for idx, raw := range raws {
+		node, err := decodeNode(raw)
+		if err != nil {
+			d.fail("%s[%d]: %w", name, idx, err)
+			return nil
+		}
+		element, ok := as(node)
+		if !ok {
+			d.fail("%s[%d]: unexpected %s", name, idx, nodeType(node))
+			return nil
+		}
+		list = append(list, element)
+	}
+	return list
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
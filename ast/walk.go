@@ -0,0 +1,280 @@
+package ast
+
+import "fmt"
+
+// INFO: Traversal of the AST - Walk & Inspect work like the ones of go/ast, Modify rewrites the tree bottom-up
+// (like the macro expansion of "The Lost Chapter" of the book). The children are visited in source order,
+// the pairs of a HashLiteral in the order of their keys' positions (see sortedHashKeys).
+// NOTE: MatchArm and HashPatternPair aren't nodes - their parts are visited as children of the match / the pattern.
+
+// NOTE: Visit is called with every node - if it gives back a non-nil visitor w, the children of the node are walked
+// with w, and w.Visit(nil) is called after them
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		walkList(v, node.Statements)
+	case *LetStatement:
+		walkOptional(v, node.Name)
+		walkOptional(v, node.Pattern)
+		walkOptional(v, node.Type)
+		walkOptional(v, node.Value)
+	case *ReturnStatement:
+		walkOptional(v, node.ReturnValue)
+	case *ExpressionStatement:
+		walkOptional(v, node.Expression)
+	case *BlockStatement:
+		walkList(v, node.Statements)
+	case *BadStatement, *BadExpression, *Identifier, *IntegerLiteral, *FloatLiteral, *Boolean, *StringLiteral, *WildcardPattern,
+		*NamedType:
+		// NOTE: no children
+	case *PrefixExpression:
+		Walk(v, node.Right)
+	case *InfixExpression:
+		Walk(v, node.Left)
+		Walk(v, node.Right)
+	case *IfExpression:
+		Walk(v, node.Condition)
+		Walk(v, node.Consequence)
+		walkOptional(v, node.Alternative)
+	case *FunctionLiteral:
+		walkList(v, node.Parameters)
+		walkOptional(v, node.Rest)
+		walkOptional(v, node.ReturnType)
+		Walk(v, node.Body)
+	case *CallExpression:
+		// NOTE: a pipeline is walked in source order too - the piped value first
+		if node.Piped && len(node.Arguments) > 0 {
+			Walk(v, node.Arguments[0])
+			Walk(v, node.Function)
+			walkList(v, node.Arguments[1:])
+		} else {
+			Walk(v, node.Function)
+			walkList(v, node.Arguments)
+		}
+	case *ArrayLiteral:
+		walkList(v, node.Elements)
+	case *SpreadExpression:
+		Walk(v, node.Value)
+	case *IndexExpression:
+		Walk(v, node.Left)
+		Walk(v, node.Index)
+	case *SliceExpression:
+		Walk(v, node.Left)
+		walkOptional(v, node.Start)
+		walkOptional(v, node.End)
+		walkOptional(v, node.Step)
+	case *AssignExpression:
+		Walk(v, node.Target)
+		Walk(v, node.Value)
+	case *HashLiteral:
+		for _, key := range sortedHashKeys(node) {
+			Walk(v, key)
+			Walk(v, node.Pairs[key])
+		}
+	case *LiteralPattern:
+		Walk(v, node.Value)
+	case *IdentifierPattern:
+		Walk(v, node.Name)
+		walkOptional(v, node.Type)
+	case *DefaultPattern:
+		Walk(v, node.Pattern)
+		Walk(v, node.Default)
+	case *ArrayPattern:
+		walkList(v, node.Elements)
+		walkOptional(v, node.Rest)
+	case *HashPattern:
+		for _, pair := range node.Pairs {
+			Walk(v, pair.Key)
+			Walk(v, pair.Value)
+		}
+		walkOptional(v, node.Rest)
+	case *MatchExpression:
+		Walk(v, node.Subject)
+		for _, arm := range node.Arms {
+			Walk(v, arm.Pattern)
+			walkOptional(v, arm.Guard)
+			Walk(v, arm.Body)
+		}
+	case *ArrayType:
+		Walk(v, node.Element)
+	case *HashType:
+		Walk(v, node.Key)
+		Walk(v, node.Value)
+	case *FunctionType:
+		walkList(v, node.Parameters)
+		walkOptional(v, node.Return)
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", node))
+	}
+
+	v.Visit(nil)
+}
+
+// WARN: Helper method used only in Walk - skips missing children (nil interfaces and typed nil pointers alike)
+func walkOptional[T Node](v Visitor, node T) {
+	if !isNilNode(node) {
+		Walk(v, node)
+	}
+}
+
+// WARN: Helper method used only in Walk
+func walkList[T Node](v Visitor, nodes []T) {
+	for _, node := range nodes {
+		Walk(v, node)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// NOTE: calls f with every node (and f(nil) after the children of a node) - when f gives back false,
+// the children of that node are skipped
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// INFO: ==================================== Modify ====================================
+
+// NOTE: gives back the node to put in place of the given one - the node itself to keep it
+type ModifierFunc func(Node) Node
+
+// NOTE: the children are modified before their parent, so the modifier sees a node with its children already replaced.
+// A replacement has to fit where the node was (an Expression for an Expression, a *BlockStatement for a block, ...),
+// otherwise Modify panics. The pairs of a HashLiteral are rebuilt, so both keys and values can be replaced.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch node := node.(type) {
+	case *Program:
+		modifyList(node.Statements, modifier)
+	case *LetStatement:
+		node.Name = modifyOptional(node.Name, modifier)
+		node.Pattern = modifyOptional(node.Pattern, modifier)
+		node.Type = modifyOptional(node.Type, modifier)
+		node.Value = modifyOptional(node.Value, modifier)
+	case *ReturnStatement:
+		node.ReturnValue = modifyOptional(node.ReturnValue, modifier)
+	case *ExpressionStatement:
+		node.Expression = modifyOptional(node.Expression, modifier)
+	case *BlockStatement:
+		modifyList(node.Statements, modifier)
+	case *PrefixExpression:
+		node.Right = modifyOptional(node.Right, modifier)
+	case *InfixExpression:
+		node.Left = modifyOptional(node.Left, modifier)
+		node.Right = modifyOptional(node.Right, modifier)
+	case *IfExpression:
+		node.Condition = modifyOptional(node.Condition, modifier)
+		node.Consequence = modifyOptional(node.Consequence, modifier)
+		node.Alternative = modifyOptional(node.Alternative, modifier)
+	case *FunctionLiteral:
+		modifyList(node.Parameters, modifier)
+		node.Rest = modifyOptional(node.Rest, modifier)
+		node.ReturnType = modifyOptional(node.ReturnType, modifier)
+		node.Body = modifyOptional(node.Body, modifier)
+	case *CallExpression:
+		node.Function = modifyOptional(node.Function, modifier)
+		modifyList(node.Arguments, modifier)
+	case *ArrayLiteral:
+		modifyList(node.Elements, modifier)
+	case *SpreadExpression:
+		node.Value = modifyOptional(node.Value, modifier)
+	case *IndexExpression:
+		node.Left = modifyOptional(node.Left, modifier)
+		node.Index = modifyOptional(node.Index, modifier)
+	case *SliceExpression:
+		node.Left = modifyOptional(node.Left, modifier)
+		node.Start = modifyOptional(node.Start, modifier)
+		node.End = modifyOptional(node.End, modifier)
+		node.Step = modifyOptional(node.Step, modifier)
+	case *AssignExpression:
+		node.Target = modifyOptional(node.Target, modifier)
+		node.Value = modifyOptional(node.Value, modifier)
+	case *HashLiteral:
+		pairs := make(map[Expression]Expression, len(node.Pairs))
+		for _, key := range sortedHashKeys(node) {
+			value := node.Pairs[key]
+			pairs[modifyOptional(key, modifier)] = modifyOptional(value, modifier)
+		}
+		node.Pairs = pairs
+	case *LiteralPattern:
+		node.Value = modifyOptional(node.Value, modifier)
+	case *IdentifierPattern:
+		node.Name = modifyOptional(node.Name, modifier)
+		node.Type = modifyOptional(node.Type, modifier)
+	case *DefaultPattern:
+		node.Pattern = modifyOptional(node.Pattern, modifier)
+		node.Default = modifyOptional(node.Default, modifier)
+	case *ArrayPattern:
+		modifyList(node.Elements, modifier)
+		node.Rest = modifyOptional(node.Rest, modifier)
+	case *HashPattern:
+		for _, pair := range node.Pairs {
+			pair.Key = modifyOptional(pair.Key, modifier)
+			pair.Value = modifyOptional(pair.Value, modifier)
+		}
+		node.Rest = modifyOptional(node.Rest, modifier)
+	case *MatchExpression:
+		node.Subject = modifyOptional(node.Subject, modifier)
+		for _, arm := range node.Arms {
+			arm.Pattern = modifyOptional(arm.Pattern, modifier)
+			arm.Guard = modifyOptional(arm.Guard, modifier)
+			arm.Body = modifyOptional(arm.Body, modifier)
+		}
+	case *ArrayType:
+		node.Element = modifyOptional(node.Element, modifier)
+	case *HashType:
+		node.Key = modifyOptional(node.Key, modifier)
+		node.Value = modifyOptional(node.Value, modifier)
+	case *FunctionType:
+		modifyList(node.Parameters, modifier)
+		node.Return = modifyOptional(node.Return, modifier)
+	}
+
+	return modifier(node)
+}
+
+// WARN: Helper method used only in Modify - missing children stay missing, the modifier isn't called with them
+func modifyOptional[T Node](node T, modifier ModifierFunc) T {
+	if isNilNode(node) {
+		return node
+	}
+	modified := Modify(node, modifier)
+	replacement, ok := modified.(T)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: cannot replace %T with %T", node, modified))
+	}
+	return replacement
+}
+
+// WARN: Helper method used only in Modify - the elements are replaced in place
+func modifyList[T Node](nodes []T, modifier ModifierFunc) {
+	for idx, node := range nodes {
+		nodes[idx] = modifyOptional(node, modifier)
+	}
+}
+
+// NOTE: true for nil interfaces and for the typed nil pointers of missing children (an Alternative, a Rest, ...)
+func isNilNode(node Node) bool {
+	switch node := node.(type) {
+	case nil:
+		return true
+	case *BlockStatement:
+		return node == nil
+	case *Identifier:
+		return node == nil
+	}
+	return false
+}
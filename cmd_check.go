@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"mfiorek/waiig/diagnostic"
+	"mfiorek/waiig/lexer"
+	"mfiorek/waiig/parser"
+	"mfiorek/waiig/types"
+	"os"
+)
+
+// INFO: `check` subcommand - type checks the given files (or stdin without files) and prints the errors rustc-style.
+// Running a program never needs it, the evaluator ignores the annotations. The exit code is 1 when there were
+// errors (syntax errors included), 2 when a file couldn't be read.
+
+func runCheck(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: waiig check [files...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	exitCode := 0
+	process := func(name string, src []byte) {
+		p := parser.New(lexer.New(string(src)))
+		program := p.ParseProgram()
+
+		diagnostics := p.Diagnostics()
+		if len(p.Errors()) == 0 {
+			diagnostics = types.Check(program)
+		}
+		if len(diagnostics) == 0 {
+			return
+		}
+		exitCode = max(exitCode, 1)
+
+		renderer := &diagnostic.Renderer{Filename: name, Source: string(src), Color: diagnostic.ColorEnabled(stdout)}
+		renderer.Render(stdout, diagnostics)
+		io.WriteString(stdout, "\n")
+	}
+
+	if flags.NArg() == 0 {
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "<stdin>: %s\n", err)
+			return 2
+		}
+		process("<stdin>", src)
+	}
+	for _, name := range flags.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", name, err)
+			exitCode = 2
+			continue
+		}
+		process(name, src)
+	}
+	return exitCode
+}
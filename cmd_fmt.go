@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"mfiorek/waiig/format"
+	"os"
+)
+
+// INFO: `fmt` subcommand - formats the given files (or stdin without files):
+// by default the formatted source is printed, -check lists the files that aren't formatted, -d prints a diff
+// and -w rewrites the files in place. The exit code is 1 when -check found unformatted files, 2 on errors.
+
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	check := flags.Bool("check", false, "list the files whose formatting differs, exit with 1 if there are any")
+	diff := flags.Bool("d", false, "print a diff instead of the formatted source")
+	write := flags.Bool("w", false, "write the result back to the files instead of printing it")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: waiig fmt [-check | -d | -w] [files...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *write && flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "fmt: -w needs files to write to")
+		return 2
+	}
+
+	exitCode := 0
+	process := func(name string, src []byte) {
+		formatted, err := format.Source(string(src))
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", name, err)
+			exitCode = 2
+			return
+		}
+
+		switch {
+		case *check:
+			if formatted != string(src) {
+				fmt.Fprintln(stdout, name)
+				exitCode = max(exitCode, 1)
+			}
+		case *diff:
+			io.WriteString(stdout, unifiedDiff(name, string(src), formatted))
+		case *write:
+			if formatted == string(src) {
+				return
+			}
+			if err := os.WriteFile(name, []byte(formatted), 0o644); err != nil {
+				fmt.Fprintf(stderr, "%s: %s\n", name, err)
+				exitCode = 2
+			}
+		default:
+			io.WriteString(stdout, formatted)
+		}
+	}
+
+	if flags.NArg() == 0 {
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "<stdin>: %s\n", err)
+			return 2
+		}
+		process("<stdin>", src)
+		return exitCode
+	}
+
+	for _, name := range flags.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", name, err)
+			exitCode = 2
+			continue
+		}
+		process(name, src)
+	}
+
+	return exitCode
+}
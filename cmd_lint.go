@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"mfiorek/waiig/diagnostic"
+	"mfiorek/waiig/lint"
+	"mfiorek/waiig/parser"
+	"os"
+	"strings"
+)
+
+// INFO: `lint` subcommand - reports the issues the lint rules find in the given files (or stdin without files).
+// The rules come from -config (by default .monkeylint.json when there is one), -enable and -disable change them
+// on top of it. The issues are printed rustc-style, or as a JSON array with -json. The exit code is 1 when there
+// were issues, 2 on errors.
+
+const defaultLintConfig = ".monkeylint.json"
+
+// NOTE: an issue in the -json output - lines and columns are 1-based, columns count bytes
+type lintIssue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", "", "the config file (default "+defaultLintConfig+" when there is one)")
+	enable := flags.String("enable", "", "comma-separated rules to enable on top of the config")
+	disable := flags.String("disable", "", "comma-separated rules to disable on top of the config")
+	asJSON := flags.Bool("json", false, "print the issues as a JSON array")
+	list := flags.Bool("rules", false, "list the rules and exit")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: waiig lint [-config file] [-enable rules] [-disable rules] [-json] [files...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *list {
+		for _, rule := range lint.Rules() {
+			fmt.Fprintf(stdout, "%-20s %s\n", rule.Name, rule.Description)
+		}
+		return 0
+	}
+
+	config, err := lintConfig(*configPath, *enable, *disable)
+	if err != nil {
+		fmt.Fprintf(stderr, "lint: %s\n", err)
+		return 2
+	}
+
+	exitCode := 0
+	found := []lintIssue{}
+	process := func(name string, src []byte) {
+		issues := lint.Source(string(src), config)
+		if len(issues) == 0 {
+			return
+		}
+		exitCode = max(exitCode, 1)
+
+		if *asJSON {
+			for _, issue := range issues {
+				found = append(found, lintIssue{
+					File:     name,
+					Line:     issue.Line,
+					Column:   issue.Column,
+					Severity: issue.Severity.String(),
+					Rule:     issue.Rule,
+					Message:  issue.Message,
+					Hint:     issue.Hint,
+				})
+			}
+			return
+		}
+
+		diagnostics := []parser.Diagnostic{}
+		for _, issue := range issues {
+			d := issue.Diagnostic
+			d.Message += " [" + issue.Rule + "]"
+			diagnostics = append(diagnostics, d)
+		}
+		renderer := &diagnostic.Renderer{Filename: name, Source: string(src), Color: diagnostic.ColorEnabled(stdout)}
+		renderer.Render(stdout, diagnostics)
+		io.WriteString(stdout, "\n")
+	}
+
+	if flags.NArg() == 0 {
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "<stdin>: %s\n", err)
+			return 2
+		}
+		process("<stdin>", src)
+	}
+	for _, name := range flags.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", name, err)
+			exitCode = 2
+			continue
+		}
+		process(name, src)
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(found, "", "  ")
+		fmt.Fprintln(stdout, string(out))
+	}
+	return exitCode
+}
+
+// WARN: Helper method used only in runLint - a missing default config is fine, a missing -config isn't
+func lintConfig(path, enable, disable string) (*lint.Config, error) {
+	config := &lint.Config{}
+	if path != "" {
+		loaded, err := lint.LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		config = loaded
+	} else if loaded, err := lint.LoadConfig(defaultLintConfig); err == nil {
+		config = loaded
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, change := range []struct {
+		rules   string
+		enabled bool
+	}{{enable, true}, {disable, false}} {
+		for _, rule := range strings.Split(change.rules, ",") {
+			if rule = strings.TrimSpace(rule); rule == "" {
+				continue
+			}
+			if err := config.Set(rule, change.enabled); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mfiorek/waiig/lsp"
+)
+
+// INFO: `lsp` subcommand - a language server for editors, talking LSP over stdin/stdout.
+// The exit code is 0 when the client asked for a shutdown before exiting, 1 otherwise (as the protocol wants it).
+
+func runLsp(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		fmt.Fprintln(stderr, "usage: waiig lsp")
+		return 2
+	}
+
+	if err := lsp.NewServer(stdin, stdout).Run(); err != nil {
+		if err != lsp.ErrExitWithoutShutdown {
+			fmt.Fprintf(stderr, "lsp: %s\n", err)
+		}
+		return 1
+	}
+	return 0
+}
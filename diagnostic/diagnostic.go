@@ -0,0 +1,174 @@
+package diagnostic
+
+import (
+	"fmt"
+	"io"
+	"mfiorek/waiig/object"
+	"mfiorek/waiig/parser"
+	"mfiorek/waiig/token"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// INFO: Rendering of diagnostics the way rustc does it - a header, where it is, the source line and the span underlined:
+//
+//	error: identifier not found: lenn
+//	 --> <repl>:1:1
+//	  |
+//	1 | lenn([1, 2])
+//	  | ^^^^
+//	  |
+//	  = help: did you mean `len`?
+
+type Renderer struct {
+	Filename string // shown in the --> line
+	Source   string // the source the positions refer to - without it only the header and the position are shown
+	Color    bool
+}
+
+// NOTE: ANSI escapes used when Color is set
+const (
+	reset  = "\x1b[0m"
+	bold   = "\x1b[1m"
+	red    = "\x1b[1;31m"
+	yellow = "\x1b[1;33m"
+	blue   = "\x1b[1;34m"
+	cyan   = "\x1b[1;36m"
+)
+
+func (r *Renderer) Render(out io.Writer, diagnostics []parser.Diagnostic) {
+	for idx, d := range diagnostics {
+		if idx > 0 {
+			io.WriteString(out, "\n")
+		}
+		r.render(out, d)
+	}
+}
+
+func (r *Renderer) render(out io.Writer, d parser.Diagnostic) {
+	severityColor := red
+	if d.Severity == parser.SeverityWarning {
+		severityColor = yellow
+	}
+
+	fmt.Fprintf(out, "%s%s\n", r.paint(severityColor, d.Severity.String()), r.paint(bold, ": "+d.Message))
+
+	line, ok := r.sourceLine(d.Line)
+	gutter := strings.Repeat(" ", len(fmt.Sprint(d.Line)))
+	fmt.Fprintf(out, "%s%s %s:%d:%d\n", gutter, r.paint(blue, "-->"), r.Filename, d.Line, d.Column)
+
+	if ok {
+		fmt.Fprintf(out, "%s %s\n", gutter, r.paint(blue, "|"))
+		fmt.Fprintf(out, "%s %s\n", r.paint(blue, fmt.Sprintf("%d |", d.Line)), line)
+
+		padding, width := underline(line, d.Column, spanWidth(d.Found))
+		marker := strings.Repeat("^", width)
+		if label := expectedLabel(d.Expected); label != "" {
+			marker += " " + label
+		}
+		fmt.Fprintf(out, "%s %s %s%s\n", gutter, r.paint(blue, "|"), padding, r.paint(severityColor, marker))
+	}
+
+	if d.Hint != "" {
+		if ok {
+			fmt.Fprintf(out, "%s %s\n", gutter, r.paint(blue, "|"))
+		}
+		fmt.Fprintf(out, "%s %s %s\n", gutter, r.paint(blue, "="), r.paint(bold, "help:")+" "+d.Hint)
+	}
+}
+
+func (r *Renderer) paint(color, str string) string {
+	if !r.Color {
+		return str
+	}
+	return color + str + reset
+}
+
+// NOTE: the line without its \n (and \r) - false when the source doesn't have it
+func (r *Renderer) sourceLine(number int) (string, bool) {
+	if r.Source == "" || number < 1 {
+		return "", false
+	}
+	lines := strings.Split(r.Source, "\n")
+	if number > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[number-1], "\r"), true
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+// NOTE: how many characters of the source the token takes - the quotes of a string included, 1 for EOF
+// (the caret goes just past the end then)
+func spanWidth(tok token.Token) int {
+	switch tok.Type {
+	case token.EOF:
+		return 1
+	case token.STRING:
+		return utf8.RuneCountInString(tok.Literal) + 2
+	}
+	return max(1, utf8.RuneCountInString(tok.Literal))
+}
+
+// NOTE: the whitespace to put before the carets, so they end up under the column (a tab where the line has one),
+// and how many carets fit - the span is cut at the end of the line (an unclosed string has the rest of the source)
+func underline(line string, column, width int) (string, int) {
+	var padding strings.Builder
+	runes := 0
+	for offset, ch := range line {
+		if offset >= column-1 {
+			break
+		}
+		if ch == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+		runes++
+	}
+
+	remaining := utf8.RuneCountInString(line) - runes
+	return padding.String(), max(1, min(width, remaining))
+}
+
+func expectedLabel(expected []token.TokenType) string {
+	if len(expected) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(expected))
+	for idx, tokenType := range expected {
+		quoted[idx] = "`" + string(tokenType) + "`"
+	}
+	return "expected " + strings.Join(quoted, " or ")
+}
+
+// INFO: ==================================== Runtime errors ====================================
+
+// NOTE: a diagnostic for an evaluation error - only errors which know where they happened can be one
+func FromError(err *object.Error) (parser.Diagnostic, bool) {
+	if err.Token.Line == 0 {
+		return parser.Diagnostic{}, false
+	}
+	return parser.Diagnostic{
+		Severity: parser.SeverityError,
+		Line:     err.Token.Line,
+		Column:   err.Token.Column,
+		Message:  err.Message,
+		Found:    err.Token,
+		Hint:     err.Hint,
+	}, true
+}
+
+// NOTE: color only for terminals, and not when the user asked for none (https://no-color.org)
+func ColorEnabled(out io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,147 @@
+package diagnostic
+
+import (
+	"bytes"
+	"mfiorek/waiig/lexer"
+	"mfiorek/waiig/object"
+	"mfiorek/waiig/parser"
+	"mfiorek/waiig/token"
+	"testing"
+)
+
+// INFO: ==================================== Tests ====================================
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{
+			"let x = (1 + 2;",
+			"error: expected next token to be ), got ; instead\n" +
+				" --> test.mk:1:15\n" +
+				"  |\n" +
+				"1 | let x = (1 + 2;\n" +
+				"  |               ^ expected `)`\n",
+		},
+		{
+			"let a = 1;\nlet b = 2;\nlet c = [1, 2",
+			"error: expected next token to be ], got EOF instead\n" +
+				" --> test.mk:3:14\n" +
+				"  |\n" +
+				"3 | let c = [1, 2\n" +
+				"  |              ^ expected `]`\n",
+		},
+		{
+			"if (x) {\n\tlet = \"abc\"\n}",
+			"error: expected next token to be IDENT, got = instead\n" +
+				" --> test.mk:2:6\n" +
+				"  |\n" +
+				"2 | \tlet = \"abc\"\n" +
+				"  | \t    ^ expected `IDENT`\n",
+		},
+		{
+			"match (x) { \"é\" => 1, { => 2 }",
+			"error: hash pattern keys must be literals, got =>\n" +
+				" --> test.mk:1:26\n" +
+				"  |\n" +
+				"1 | match (x) { \"é\" => 1, { => 2 }\n" +
+				"  |                         ^^\n",
+		},
+		{
+			"let x = 1 +;\n\n\n\n\n\n\n\n\nlet y = ;",
+			"error: no prefix parse function for ; found\n" +
+				" --> test.mk:1:12\n" +
+				"  |\n" +
+				"1 | let x = 1 +;\n" +
+				"  |            ^\n" +
+				"\n" +
+				"error: no prefix parse function for ; found\n" +
+				"  --> test.mk:10:9\n" +
+				"   |\n" +
+				"10 | let y = ;\n" +
+				"   |         ^\n",
+		},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.source))
+		p.ParseProgram()
+
+		var out bytes.Buffer
+		renderer := &Renderer{Filename: "test.mk", Source: tt.source}
+		renderer.Render(&out, p.Diagnostics())
+
+		if out.String() != tt.expected {
+			t.Errorf("wrong rendering of %q.\nexpected:\n%s\ngot:\n%s", tt.source, tt.expected, out.String())
+		}
+	}
+}
+
+func TestRenderHintsAndColor(t *testing.T) {
+	d := parser.Diagnostic{
+		Severity: parser.SeverityWarning,
+		Line:     1,
+		Column:   5,
+		Message:  "unused variable",
+		Found:    token.Token{Type: token.STRING, Literal: "ab", Line: 1, Column: 5},
+		Hint:     "remove it",
+	}
+
+	tests := []struct {
+		renderer *Renderer
+		expected string
+	}{
+		{
+			&Renderer{Filename: "f", Source: `x = "ab" + y`},
+			"warning: unused variable\n" +
+				" --> f:1:5\n" +
+				"  |\n" +
+				"1 | x = \"ab\" + y\n" +
+				"  |     ^^^^\n" +
+				"  |\n" +
+				"  = help: remove it\n",
+		},
+		// NOTE: without the source there is no snippet
+		{
+			&Renderer{Filename: "f"},
+			"warning: unused variable\n" +
+				" --> f:1:5\n" +
+				"  = help: remove it\n",
+		},
+		{
+			&Renderer{Filename: "f", Color: true},
+			"\x1b[1;33mwarning\x1b[0m\x1b[1m: unused variable\x1b[0m\n" +
+				" \x1b[1;34m-->\x1b[0m f:1:5\n" +
+				"  \x1b[1;34m=\x1b[0m \x1b[1mhelp:\x1b[0m remove it\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		tt.renderer.Render(&out, []parser.Diagnostic{d})
+
+		if out.String() != tt.expected {
+			t.Errorf("wrong rendering.\nexpected=%q\ngot=     %q", tt.expected, out.String())
+		}
+	}
+}
+
+func TestFromError(t *testing.T) {
+	if _, ok := FromError(&object.Error{Message: "type mismatch: INTEGER + BOOLEAN"}); ok {
+		t.Errorf("an error without a position must not become a diagnostic")
+	}
+
+	err := &object.Error{
+		Message: "identifier not found: lenn",
+		Token:   token.Token{Type: token.IDENT, Literal: "lenn", Line: 2, Column: 3},
+		Hint:    "did you mean `len`?",
+	}
+	d, ok := FromError(err)
+	if !ok {
+		t.Fatalf("expected a diagnostic")
+	}
+	if d.Severity != parser.SeverityError || d.Line != 2 || d.Column != 3 || d.Message != err.Message || d.Hint != err.Hint {
+		t.Errorf("wrong diagnostic. got=%+v", d)
+	}
+}
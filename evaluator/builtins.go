@@ -1,13 +1,13 @@
 package evaluator
 
 import (
-	"fmt"
 	"mfiorek/waiig/object"
+	"unicode/utf8"
 )
 
 var builtins = map[string]*object.Builtin{
 	"len": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments. got=%d, want=1", len(args))
 			}
@@ -16,7 +16,8 @@ var builtins = map[string]*object.Builtin{
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				// NOTE: counting runes, to stay consistent with string indexing and slicing
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			default:
 				return newError("argument to `len` not supported, got %s", args[0].Type())
 			}
@@ -24,7 +25,7 @@ var builtins = map[string]*object.Builtin{
 	},
 
 	"first": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments. got=%d, want=1", len(args))
 			}
@@ -42,7 +43,7 @@ var builtins = map[string]*object.Builtin{
 	},
 
 	"last": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments. got=%d, want=1", len(args))
 			}
@@ -61,7 +62,7 @@ var builtins = map[string]*object.Builtin{
 	},
 
 	"rest": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments. got=%d, want=1", len(args))
 			}
@@ -82,7 +83,7 @@ var builtins = map[string]*object.Builtin{
 	},
 
 	"push": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return newError("wrong number of arguments. got=%d, want=2", len(args))
 			}
@@ -101,10 +102,174 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 
+	// INFO: In-place builtins - unlike push and rest, these mutate the given ARRAY/HASH instead of copying it
+
+	"append": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) < 2 {
+				return newError("wrong number of arguments. got=%d, want>=2", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `append` must be ARRAY, got %s", args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			arr.Elements = append(arr.Elements, args[1:]...)
+
+			return arr
+		},
+	},
+
+	"pop": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `pop` must be ARRAY, got %s", args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+			if length == 0 {
+				return NULL
+			}
+
+			last := arr.Elements[length-1]
+			arr.Elements = arr.Elements[:length-1]
+
+			return last
+		},
+	},
+
+	"shift": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `shift` must be ARRAY, got %s", args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			if len(arr.Elements) == 0 {
+				return NULL
+			}
+
+			first := arr.Elements[0]
+			arr.Elements = arr.Elements[1:]
+
+			return first
+		},
+	},
+
+	"unshift": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `unshift` must be ARRAY, got %s", args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			arr.Elements = append([]object.Object{args[1]}, arr.Elements...)
+
+			return arr
+		},
+	},
+
+	"insert": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=3", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("first argument to `insert` must be ARRAY, got %s", args[0].Type())
+			}
+			if args[1].Type() != object.INTEGER_OBJ {
+				return newError("second argument to `insert` must be INTEGER, got %s", args[1].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			length := int64(len(arr.Elements))
+			idx := args[1].(*object.Integer).Value
+			if idx < 0 {
+				idx += length
+			}
+			// NOTE: inserting at len(arr) is allowed - it's the same as append
+			if idx < 0 || idx > length {
+				return newError("index out of range: %d (length %d)", args[1].(*object.Integer).Value, length)
+			}
+
+			arr.Elements = append(arr.Elements, nil)
+			copy(arr.Elements[idx+1:], arr.Elements[idx:])
+			arr.Elements[idx] = args[2]
+
+			return arr
+		},
+	},
+
+	"delete": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			switch coll := args[0].(type) {
+			case *object.Hash:
+				hashableKey, ok := args[1].(object.Hashable)
+				if !ok {
+					return newError("unusable as hash key: %s", args[1].Type())
+				}
+				pair, ok := coll.Pairs[hashableKey.HashKey()]
+				if !ok {
+					return NULL
+				}
+				delete(coll.Pairs, hashableKey.HashKey())
+				return pair.Value
+			case *object.Array:
+				if args[1].Type() != object.INTEGER_OBJ {
+					return newError("second argument to `delete` must be INTEGER, got %s", args[1].Type())
+				}
+				idx, ok := normalizeIndex(args[1].(*object.Integer).Value, len(coll.Elements))
+				if !ok {
+					return NULL
+				}
+				removed := coll.Elements[idx]
+				coll.Elements = append(coll.Elements[:idx], coll.Elements[idx+1:]...)
+				return removed
+			default:
+				return newError("argument to `delete` must be HASH or ARRAY, got %s", args[0].Type())
+			}
+		},
+	},
+
+	"clear": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch coll := args[0].(type) {
+			case *object.Hash:
+				clear(coll.Pairs)
+			case *object.Array:
+				coll.Elements = []object.Object{}
+			default:
+				return newError("argument to `clear` must be HASH or ARRAY, got %s", args[0].Type())
+			}
+
+			return args[0]
+		},
+	},
+
 	"puts": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
 			for _, arg := range args {
-				fmt.Println(arg.Inspect())
+				if err := writeOutput("puts", config.Stdout, arg.Inspect()+"\n"); err != nil {
+					return err
+				}
 			}
 
 			return NULL
@@ -0,0 +1,562 @@
+package evaluator
+
+import (
+	"cmp"
+	"mfiorek/waiig/object"
+	"sort"
+)
+
+// INFO: Collection builtins - map, filter, reduce & co. implemented in Go, calling back into Monkey through applyFunction.
+// NOTE: they live in their own map (merged into builtins in init) because builtins -> applyFunction -> Eval -> builtins
+// would be an initialization cycle otherwise
+
+func init() {
+	for name, builtin := range collectionBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+var collectionBuiltins = map[string]*object.Builtin{
+	"map": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("map", args)
+			if err != nil {
+				return err
+			}
+
+			result := make([]object.Object, 0, len(arr.Elements))
+			for _, el := range arr.Elements {
+				mapped := applyFunction(fn, []object.Object{el}, config)
+				if isError(mapped) {
+					return mapped
+				}
+				result = append(result, mapped)
+			}
+
+			return &object.Array{Elements: result}
+		},
+	},
+
+	"filter": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("filter", args)
+			if err != nil {
+				return err
+			}
+
+			result := []object.Object{}
+			for _, el := range arr.Elements {
+				keep := applyFunction(fn, []object.Object{el}, config)
+				if isError(keep) {
+					return keep
+				}
+				if isTruthy(keep) {
+					result = append(result, el)
+				}
+			}
+
+			return &object.Array{Elements: result}
+		},
+	},
+
+	"reduce": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=2..3", len(args))
+			}
+			arr, fn, err := arrayAndFunctionArgs("reduce", args[:2])
+			if err != nil {
+				return err
+			}
+
+			// NOTE: without an initial value the first element is the initial accumulator
+			elements := arr.Elements
+			var acc object.Object
+			if len(args) == 3 {
+				acc = args[2]
+			} else {
+				if len(elements) == 0 {
+					return newError("reduce of empty ARRAY with no initial value")
+				}
+				acc, elements = elements[0], elements[1:]
+			}
+
+			for _, el := range elements {
+				acc = applyFunction(fn, []object.Object{acc, el}, config)
+				if isError(acc) {
+					return acc
+				}
+			}
+
+			return acc
+		},
+	},
+
+	"each": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("each", args)
+			if err != nil {
+				return err
+			}
+
+			for _, el := range arr.Elements {
+				result := applyFunction(fn, []object.Object{el}, config)
+				if isError(result) {
+					return result
+				}
+			}
+
+			return NULL
+		},
+	},
+
+	"find": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("find", args)
+			if err != nil {
+				return err
+			}
+
+			for _, el := range arr.Elements {
+				found := applyFunction(fn, []object.Object{el}, config)
+				if isError(found) {
+					return found
+				}
+				if isTruthy(found) {
+					return el
+				}
+			}
+
+			return NULL
+		},
+	},
+
+	"any": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("any", args)
+			if err != nil {
+				return err
+			}
+
+			for _, el := range arr.Elements {
+				result := applyFunction(fn, []object.Object{el}, config)
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					return TRUE
+				}
+			}
+
+			return FALSE
+		},
+	},
+
+	"all": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("all", args)
+			if err != nil {
+				return err
+			}
+
+			for _, el := range arr.Elements {
+				result := applyFunction(fn, []object.Object{el}, config)
+				if isError(result) {
+					return result
+				}
+				if !isTruthy(result) {
+					return FALSE
+				}
+			}
+
+			return TRUE
+		},
+	},
+
+	// NOTE: the comparator works like in JS - fn(a, b) gives a negative INTEGER when a goes first, positive when b does
+	"sort": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("first argument to `sort` must be ARRAY, got %s", args[0].Type())
+			}
+
+			var compare func(a, b object.Object) (int64, *object.Error)
+			if len(args) == 2 {
+				if !isCallable(args[1]) {
+					return newError("second argument to `sort` must be FUNCTION, got %s", args[1].Type())
+				}
+				compare = func(a, b object.Object) (int64, *object.Error) {
+					result := applyFunction(args[1], []object.Object{a, b}, config)
+					if err, ok := result.(*object.Error); ok {
+						return 0, err
+					}
+					integer, ok := result.(*object.Integer)
+					if !ok {
+						return 0, newError("comparator passed to `sort` must return INTEGER, got %s", result.Type())
+					}
+					return integer.Value, nil
+				}
+			} else {
+				compare = func(a, b object.Object) (int64, *object.Error) {
+					result, err := compareObjects(a, b)
+					return int64(result), err
+				}
+			}
+
+			sorted := make([]object.Object, len(args[0].(*object.Array).Elements))
+			copy(sorted, args[0].(*object.Array).Elements)
+
+			var sortErr *object.Error
+			sort.SliceStable(sorted, func(i, j int) bool {
+				if sortErr != nil {
+					return false
+				}
+				result, err := compare(sorted[i], sorted[j])
+				if err != nil {
+					sortErr = err
+					return false
+				}
+				return result < 0
+			})
+			if sortErr != nil {
+				return sortErr
+			}
+
+			return &object.Array{Elements: sorted}
+		},
+	},
+
+	"reverse": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Array:
+				length := len(arg.Elements)
+				reversed := make([]object.Object, length)
+				for i, el := range arg.Elements {
+					reversed[length-1-i] = el
+				}
+				return &object.Array{Elements: reversed}
+			case *object.String:
+				runes := []rune(arg.Value)
+				for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+					runes[i], runes[j] = runes[j], runes[i]
+				}
+				return &object.String{Value: string(runes)}
+			default:
+				return newError("argument to `reverse` must be ARRAY or STRING, got %s", args[0].Type())
+			}
+		},
+	},
+
+	// NOTE: the result is as long as the shortest array
+	"zip": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) < 2 {
+				return newError("wrong number of arguments. got=%d, want>=2", len(args))
+			}
+
+			length := -1
+			for _, arg := range args {
+				arr, ok := arg.(*object.Array)
+				if !ok {
+					return newError("argument to `zip` must be ARRAY, got %s", arg.Type())
+				}
+				if length == -1 || len(arr.Elements) < length {
+					length = len(arr.Elements)
+				}
+			}
+
+			result := make([]object.Object, 0, length)
+			for i := 0; i < length; i++ {
+				tuple := make([]object.Object, 0, len(args))
+				for _, arg := range args {
+					tuple = append(tuple, arg.(*object.Array).Elements[i])
+				}
+				result = append(result, &object.Array{Elements: tuple})
+			}
+
+			return &object.Array{Elements: result}
+		},
+	},
+
+	// NOTE: flattens one level by default, flatten(arr, depth) for more
+	"flatten": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `flatten` must be ARRAY, got %s", args[0].Type())
+			}
+
+			depth := int64(1)
+			if len(args) == 2 {
+				integer, ok := args[1].(*object.Integer)
+				if !ok {
+					return newError("second argument to `flatten` must be INTEGER, got %s", args[1].Type())
+				}
+				depth = integer.Value
+			}
+
+			elements, err := flattenElements(arr, depth, map[*object.Array]bool{})
+			if err != nil {
+				return err
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+
+	// NOTE: range(end), range(start, end) or range(start, end, step) - end is exclusive, like in Python
+	"range": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments. got=%d, want=1..3", len(args))
+			}
+
+			bounds := []int64{}
+			for _, arg := range args {
+				integer, ok := arg.(*object.Integer)
+				if !ok {
+					return newError("argument to `range` must be INTEGER, got %s", arg.Type())
+				}
+				bounds = append(bounds, integer.Value)
+			}
+
+			start, end, step := int64(0), bounds[0], int64(1)
+			if len(bounds) > 1 {
+				start, end = bounds[0], bounds[1]
+			}
+			if len(bounds) > 2 {
+				step = bounds[2]
+			}
+			if step == 0 {
+				return newError("`range` step cannot be zero")
+			}
+
+			count := rangeLength(start, end, step)
+			if count > maxRangeLength {
+				return newError("`range` would have %d elements, the most it can have is %d", count, maxRangeLength)
+			}
+
+			// NOTE: the elements are computed from the count in uint64, so the last step can't overflow past end
+			result := make([]object.Object, 0, count)
+			for i := range count {
+				result = append(result, &object.Integer{Value: int64(uint64(start) + i*uint64(step))})
+			}
+
+			return &object.Array{Elements: result}
+		},
+	},
+
+	"sum": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `sum` must be ARRAY, got %s", args[0].Type())
+			}
+
+			var total object.Object = &object.Integer{Value: 0}
+			for _, el := range arr.Elements {
+				if !isNumber(el) {
+					return newError("`sum` can only add INTEGER and FLOAT, got %s", el.Type())
+				}
+				total = evalInfixExpression("+", total, el)
+			}
+
+			return total
+		},
+	},
+
+	"min": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			return extremum("min", args, func(result int) bool { return result < 0 })
+		},
+	},
+
+	"max": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			return extremum("max", args, func(result int) bool { return result > 0 })
+		},
+	},
+
+	"keys": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			hash, err := hashArg("keys", args)
+			if err != nil {
+				return err
+			}
+
+			result := []object.Object{}
+			for _, pair := range hash.SortedPairs() {
+				result = append(result, pair.Key)
+			}
+
+			return &object.Array{Elements: result}
+		},
+	},
+
+	"values": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			hash, err := hashArg("values", args)
+			if err != nil {
+				return err
+			}
+
+			result := []object.Object{}
+			for _, pair := range hash.SortedPairs() {
+				result = append(result, pair.Value)
+			}
+
+			return &object.Array{Elements: result}
+		},
+	},
+
+	"entries": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			hash, err := hashArg("entries", args)
+			if err != nil {
+				return err
+			}
+
+			result := []object.Object{}
+			for _, pair := range hash.SortedPairs() {
+				result = append(result, &object.Array{Elements: []object.Object{pair.Key, pair.Value}})
+			}
+
+			return &object.Array{Elements: result}
+		},
+	},
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+// NOTE: the common (ARRAY, FUNCTION) signature of map, filter, each, ...
+func arrayAndFunctionArgs(name string, args []object.Object) (*object.Array, object.Object, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, nil, newError("first argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return nil, nil, newError("second argument to `%s` must be FUNCTION, got %s", name, args[1].Type())
+	}
+
+	return arr, args[1], nil
+}
+
+func hashArg(name string, args []object.Object) (*object.Hash, *object.Error) {
+	if len(args) != 1 {
+		return nil, newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return nil, newError("argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+
+	return hash, nil
+}
+
+func isCallable(obj object.Object) bool {
+	return obj.Type() == object.FUNCTION_OBJ || obj.Type() == object.BUILTIN_OBJ
+}
+
+// WARN: Helper method used only in flatten - flattening holds the arrays being flattened, an array holding itself
+// would be flattened forever (the depth can be anything)
+func flattenElements(arr *object.Array, depth int64, flattening map[*object.Array]bool) ([]object.Object, *object.Error) {
+	flattening[arr] = true
+	defer delete(flattening, arr)
+
+	result := []object.Object{}
+	for _, el := range arr.Elements {
+		inner, ok := el.(*object.Array)
+		if !ok || depth <= 0 {
+			result = append(result, el)
+			continue
+		}
+		if flattening[inner] {
+			return nil, newError("cannot flatten a cyclic array")
+		}
+		elements, err := flattenElements(inner, depth-1, flattening)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, elements...)
+	}
+	return result, nil
+}
+
+// NOTE: min/max take either one ARRAY or the values themselves - min([1, 2]) and min(1, 2) are the same
+func extremum(name string, args []object.Object, better func(result int) bool) object.Object {
+	if len(args) == 0 {
+		return newError("wrong number of arguments. got=0, want>=1")
+	}
+
+	candidates := args
+	if len(args) == 1 {
+		arr, ok := args[0].(*object.Array)
+		if !ok {
+			return newError("argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+		}
+		candidates = arr.Elements
+	}
+	if len(candidates) == 0 {
+		return NULL
+	}
+
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		result, err := compareObjects(candidate, best)
+		if err != nil {
+			return err
+		}
+		if better(result) {
+			best = candidate
+		}
+	}
+
+	return best
+}
+
+// NOTE: the natural order - numbers by value (INTEGER and FLOAT mixed), strings lexicographically
+func compareObjects(left, right object.Object) (int, *object.Error) {
+	switch {
+	case isNumber(left) && isNumber(right):
+		if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
+			return cmp.Compare(left.(*object.Integer).Value, right.(*object.Integer).Value), nil
+		}
+		return cmp.Compare(toFloat(left), toFloat(right)), nil
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return cmp.Compare(left.(*object.String).Value, right.(*object.String).Value), nil
+	default:
+		return 0, newError("cannot compare %s and %s", left.Type(), right.Type())
+	}
+}
+
+// NOTE: the most elements `range` gives - anything larger is most likely a mistake, and would take all the memory
+const maxRangeLength = 1 << 24
+
+// WARN: Helper method used only in range - the number of elements, in uint64 as end - start doesn't always fit an int64
+func rangeLength(start, end, step int64) uint64 {
+	switch {
+	case step > 0 && start < end:
+		return (uint64(end)-uint64(start)-1)/uint64(step) + 1
+	case step < 0 && start > end:
+		// NOTE: -step overflows for MinInt64, -(step + 1) + 1 doesn't
+		return (uint64(start)-uint64(end)-1)/(uint64(-(step+1))+1) + 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,158 @@
+package evaluator
+
+import "slices"
+
+// INFO: Documentation of the builtins (and constants) - the signature and what it does, shown by tools like the language server.
+// NOTE: every builtin must have an entry here, TestBuiltinDocs makes sure none is forgotten
+
+var builtinDocs = map[string]string{
+	// NOTE: builtins.go
+	"len":     "len(x) - the number of elements of an ARRAY or the number of characters of a STRING",
+	"first":   "first(arr) - the first element of the array, null when it's empty",
+	"last":    "last(arr) - the last element of the array, null when it's empty",
+	"rest":    "rest(arr) - a new array without the first element, null when it's empty",
+	"push":    "push(arr, x) - a new array with x added at the end, the array itself is left alone",
+	"append":  "append(arr, x, ...) - adds the values at the end of the array (in place) and gives it back",
+	"pop":     "pop(arr) - removes the last element of the array and gives it back, null when it's empty",
+	"shift":   "shift(arr) - removes the first element of the array and gives it back, null when it's empty",
+	"unshift": "unshift(arr, x) - adds x at the beginning of the array (in place) and gives it back",
+	"insert":  "insert(arr, index, x) - inserts x at the index (in place, len(arr) appends) and gives the array back",
+	"delete":  "delete(hash, key) / delete(arr, index) - removes the pair or the element and gives back the removed value, null when there was none",
+	"clear":   "clear(x) - removes all the elements of an ARRAY or the pairs of a HASH and gives it back",
+	"puts":    "puts(x, ...) - prints every argument on its own line",
+
+	// NOTE: builtins_collections.go
+	"map":     "map(arr, fn) - a new array of fn(element) for every element",
+	"filter":  "filter(arr, fn) - a new array of the elements for which fn(element) is truthy",
+	"reduce":  "reduce(arr, fn, initial?) - folds the array with fn(accumulator, element), without an initial value the first element is the initial accumulator",
+	"each":    "each(arr, fn) - calls fn(element) for every element, gives null",
+	"find":    "find(arr, fn) - the first element for which fn(element) is truthy, null when there is none",
+	"any":     "any(arr, fn) - true when fn(element) is truthy for at least one element",
+	"all":     "all(arr, fn) - true when fn(element) is truthy for every element",
+	"sort":    "sort(arr, fn?) - a sorted copy of the array, in the natural order or by the comparator fn(a, b) (negative when a goes first, positive when b does)",
+	"reverse": "reverse(x) - a reversed copy of an ARRAY or a STRING",
+	"zip":     "zip(arr, arr, ...) - an array of [a, b, ...] arrays, as long as the shortest of the arrays",
+	"flatten": "flatten(arr, depth?) - a copy of the array with the nested arrays flattened one level (or depth levels)",
+	"range":   "range(end) / range(start, end) / range(start, end, step) - an array of INTEGERs, the end is exclusive",
+	"sum":     "sum(arr) - the sum of the numbers of the array",
+	"min":     "min(arr) / min(x, y, ...) - the smallest of the values",
+	"max":     "max(arr) / max(x, y, ...) - the largest of the values",
+	"keys":    "keys(hash) - an array of the keys of the hash, sorted",
+	"values":  "values(hash) - an array of the values of the hash, in the order of their keys",
+	"entries": "entries(hash) - an array of [key, value] arrays, in the order of the keys",
+
+	// NOTE: builtins_fs.go
+	"read_file":   "read_file(path) - the content of the file as a STRING",
+	"write_file":  "write_file(path, content) - writes the content to the file, creating it or truncating the existing one",
+	"append_file": "append_file(path, content) - appends the content to the file, creating it when it doesn't exist yet",
+	"list_dir":    "list_dir(path?) - the sorted names in the directory (the root itself without a path)",
+	"exists":      "exists(path) - true when the file or directory exists",
+	"remove":      "remove(path) - removes a file or an empty directory",
+
+	// NOTE: builtins_io.go
+	"print":     "print(x, ...) - prints the arguments separated by a space, without a newline",
+	"eprint":    "eprint(x, ...) - like puts, but to stderr",
+	"read_line": "read_line() - the next line of the input without its line ending, null once the input is exhausted",
+	"read_all":  "read_all() - everything left in the input (\"\" once it's exhausted)",
+
+	// NOTE: builtins_json.go
+	"json_parse":     "json_parse(s) - the value of the JSON document",
+	"json_stringify": "json_stringify(value, indent?) - the value as compact JSON, indented by indent (INTEGER or STRING) when given",
+
+	// NOTE: builtins_math.go
+	"abs":        "abs(x) - the absolute value of the number",
+	"pow":        "pow(x, y) - x to the power of y, the same as x ** y",
+	"sqrt":       "sqrt(x) - the square root, as a FLOAT",
+	"exp":        "exp(x) - e to the power of x, as a FLOAT",
+	"log10":      "log10(x) - the decimal logarithm, as a FLOAT",
+	"log2":       "log2(x) - the binary logarithm, as a FLOAT",
+	"sin":        "sin(x) - the sine of x (in radians), as a FLOAT",
+	"cos":        "cos(x) - the cosine of x (in radians), as a FLOAT",
+	"tan":        "tan(x) - the tangent of x (in radians), as a FLOAT",
+	"asin":       "asin(x) - the arcsine of x in radians, as a FLOAT",
+	"acos":       "acos(x) - the arccosine of x in radians, as a FLOAT",
+	"atan":       "atan(x) - the arctangent of x in radians, as a FLOAT",
+	"atan2":      "atan2(y, x) - the angle of the point (x, y) in radians, as a FLOAT",
+	"log":        "log(x, base?) - the natural logarithm, or the one in the given base, as a FLOAT",
+	"floor":      "floor(x) - the largest INTEGER not greater than x",
+	"ceil":       "ceil(x) - the smallest INTEGER not less than x",
+	"round":      "round(x, digits?) - the nearest INTEGER (halves away from zero), or a FLOAT with that many decimals",
+	"clamp":      "clamp(x, low, high) - x limited to the range low..high",
+	"rand_seed":  "rand_seed(n) - seeds the random number generator, so the random values can be repeated",
+	"rand_int":   "rand_int(n) / rand_int(low, high) - a random INTEGER, 0 <= x < n or low <= x < high",
+	"rand_float": "rand_float() / rand_float(low, high) - a random FLOAT, 0 <= x < 1 or low <= x < high",
+	"choice":     "choice(arr) - a random element of the array",
+	"shuffle":    "shuffle(arr) - a shuffled copy of the array",
+
+	// NOTE: builtins_regex.go
+	"regex":                "regex(pattern) - compiles the pattern (the syntax of Go's regexp) to a REGEX",
+	"regex_match":          "regex_match(re, s) - true when the regex matches somewhere in s",
+	"regex_find":           "regex_find(re, s) - the first match, null when there is none",
+	"regex_find_all":       "regex_find_all(re, s, n?) - an array of all the matches (at most n)",
+	"regex_captures":       "regex_captures(re, s) - [whole match, group 1, ...] for the first match, null when there is none",
+	"regex_captures_all":   "regex_captures_all(re, s, n?) - an array of the regex_captures of every match (at most n)",
+	"regex_named_captures": "regex_named_captures(re, s) - {name: value} for the named groups ((?P<name>...)) of the first match, null when there is none",
+	"regex_replace":        "regex_replace(re, s, replacement) - replaces every match, by a STRING ($1 / ${name} expand to the groups) or by what fn(captures) gives",
+	"regex_split":          "regex_split(re, s, n?) - splits s on every match (into at most n parts)",
+
+	// NOTE: builtins_strings.go
+	"split":       "split(s, separator?) - splits the string on the separator (on whitespace without one, into characters with \"\")",
+	"join":        "join(arr, separator?) - the elements joined into a STRING, elements that aren't STRINGs in their printed form",
+	"trim":        "trim(s, cutset?) - the string without the leading and trailing whitespace (or characters of the cutset)",
+	"trim_left":   "trim_left(s, cutset?) - the string without the leading whitespace (or characters of the cutset)",
+	"trim_right":  "trim_right(s, cutset?) - the string without the trailing whitespace (or characters of the cutset)",
+	"upper":       "upper(s) - the string in upper case",
+	"lower":       "lower(s) - the string in lower case",
+	"contains":    "contains(s, sub) - true when sub is in the string",
+	"starts_with": "starts_with(s, prefix) - true when the string starts with the prefix",
+	"ends_with":   "ends_with(s, suffix) - true when the string ends with the suffix",
+	"index_of":    "index_of(s, sub) - the (character) index of the first sub in the string, -1 when it isn't there",
+	"replace":     "replace(s, old, new, n?) - replaces all the occurrences of old (or only the first n)",
+	"repeat":      "repeat(s, n) - the string repeated n times",
+	"pad_left":    "pad_left(s, width, char?) - the string padded with spaces (or char) on the left up to width characters",
+	"pad_right":   "pad_right(s, width, char?) - the string padded with spaces (or char) on the right up to width characters",
+	"chars":       "chars(s) - an array of the characters of the string",
+	"format":      "format(template, x, ...) - the template with the verbs (%s, %d, %.2f, ...) replaced by the arguments, like Go's fmt",
+	"sprintf":     "sprintf(template, x, ...) - an alias of format",
+	"parse_int":   "parse_int(s, base?) - the INTEGER in the string (0x/0o/0b prefixes are understood without a base)",
+	"parse_float": "parse_float(s) - the FLOAT in the string",
+	"to_string":   "to_string(x) - the printed form of the value",
+
+	// NOTE: builtins_time.go
+	"now":              "now() - the current TIME",
+	"since":            "since(t) - the DURATION since the time",
+	"time_parse":       "time_parse(s, layout?, zone?) - the TIME in the string, RFC3339 without a layout",
+	"time_format":      "time_format(t, layout?) - the time as a STRING, RFC3339 without a layout",
+	"time_date":        "time_date(year, month, day, hour?, minute?, second?, zone?) - the TIME of the date, in UTC without a zone",
+	"time_in":          "time_in(t, zone) - the same moment, seen from the given zone",
+	"time_unix":        "time_unix(t) - the seconds since the Unix epoch",
+	"time_from_unix":   "time_from_unix(seconds) - the TIME the given seconds after the Unix epoch",
+	"time_parts":       "time_parts(t) - {year, month, day, hour, minute, second, nanosecond, weekday, yearday, zone} of the time",
+	"duration":         "duration(s) - the DURATION in the string (\"1h30m\", the units of Go: ns, us, ms, s, m, h)",
+	"duration_seconds": "duration_seconds(d) - the duration in seconds, as a FLOAT",
+
+	// NOTE: constants
+	"PI":      "PI - the ratio of a circle's circumference to its diameter (FLOAT)",
+	"E":       "E - the base of the natural logarithm (FLOAT)",
+	"INF":     "INF - the positive infinity (FLOAT)",
+	"MAX_INT": "MAX_INT - the largest INTEGER",
+	"MIN_INT": "MIN_INT - the smallest INTEGER",
+}
+
+// NOTE: "" when there is no builtin (or constant) of that name
+func BuiltinDoc(name string) string {
+	return builtinDocs[name]
+}
+
+// NOTE: the names of all the builtins and constants, sorted
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins)+len(constants))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range constants {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
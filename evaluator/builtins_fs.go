@@ -0,0 +1,210 @@
+package evaluator
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mfiorek/waiig/object"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// INFO: File system builtins - every path is relative to the allow-listed root of the interpreter's object.Config.
+// Paths that would leave it (absolute ones, "..") are rejected up front, and the files are opened through os.Root,
+// so a symlink can't be used to get out either.
+
+func init() {
+	for name, builtin := range fsBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+var fsBuiltins = map[string]*object.Builtin{
+	"read_file": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			path, err := singleStringArg("read_file", args)
+			if err != nil {
+				return err
+			}
+
+			var content []byte
+			err = withRoot("read_file", config, path, func(root *os.Root, path string) error {
+				file, openErr := root.Open(path)
+				if openErr != nil {
+					return openErr
+				}
+				defer file.Close()
+
+				var readErr error
+				content, readErr = io.ReadAll(file)
+				return readErr
+			})
+			if err != nil {
+				return err
+			}
+
+			return &object.String{Value: string(content)}
+		},
+	},
+
+	// NOTE: creates the file or truncates the existing one
+	"write_file": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			return writeFileBuiltin("write_file", config, args, os.O_TRUNC)
+		},
+	},
+
+	// NOTE: creates the file when it doesn't exist yet
+	"append_file": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			return writeFileBuiltin("append_file", config, args, os.O_APPEND)
+		},
+	},
+
+	// NOTE: list_dir() lists the root itself, the names come back sorted (directories don't get a trailing slash)
+	"list_dir": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0..1", len(args))
+			}
+			path := "."
+			if len(args) == 1 {
+				var err *object.Error
+				if path, err = stringArg("list_dir", args, 0); err != nil {
+					return err
+				}
+			}
+
+			var names []string
+			err := withRoot("list_dir", config, path, func(root *os.Root, path string) error {
+				dir, openErr := root.Open(path)
+				if openErr != nil {
+					return openErr
+				}
+				defer dir.Close()
+
+				var readErr error
+				names, readErr = dir.Readdirnames(-1)
+				return readErr
+			})
+			if err != nil {
+				return err
+			}
+			sort.Strings(names)
+
+			return stringsToArray(names)
+		},
+	},
+
+	"exists": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			path, err := singleStringArg("exists", args)
+			if err != nil {
+				return err
+			}
+
+			found := true
+			err = withRoot("exists", config, path, func(root *os.Root, path string) error {
+				_, statErr := root.Stat(path)
+				if errors.Is(statErr, fs.ErrNotExist) {
+					found = false
+					return nil
+				}
+				return statErr
+			})
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(found)
+		},
+	},
+
+	// NOTE: removes a file or an empty directory
+	"remove": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			path, err := singleStringArg("remove", args)
+			if err != nil {
+				return err
+			}
+
+			err = withRoot("remove", config, path, func(root *os.Root, path string) error {
+				return root.Remove(path)
+			})
+			if err != nil {
+				return err
+			}
+
+			return NULL
+		},
+	},
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+// NOTE: checks that file access is allowed at all and that the path stays inside the root, then runs action
+// on the opened root - any error of action is turned into a monkey error prefixed by the builtin's name
+func withRoot(name string, config *object.Config, path string, action func(root *os.Root, path string) error) *object.Error {
+	if config.Sandbox {
+		return newError("%s: file system access is disabled in sandbox mode", name)
+	}
+	if config.FSRoot == "" {
+		return newError("%s: file system access is disabled", name)
+	}
+
+	cleaned := filepath.Clean(filepath.FromSlash(path))
+	if !filepath.IsLocal(cleaned) {
+		return newError("%s: path escapes the root directory: %s", name, path)
+	}
+
+	root, err := os.OpenRoot(config.FSRoot)
+	if err != nil {
+		return newError("%s: %s", name, err)
+	}
+	defer root.Close()
+
+	if err := action(root, cleaned); err != nil {
+		return newError("%s: %s", name, describeFSError(err))
+	}
+	return nil
+}
+
+// WARN: Helper method used only in write_file and append_file - flag is either os.O_TRUNC or os.O_APPEND
+func writeFileBuiltin(name string, config *object.Config, args []object.Object, flag int) object.Object {
+	path, content, err := twoStringArgs(name, args)
+	if err != nil {
+		return err
+	}
+
+	err = withRoot(name, config, path, func(root *os.Root, path string) error {
+		file, openErr := root.OpenFile(path, os.O_WRONLY|os.O_CREATE|flag, 0o644)
+		if openErr != nil {
+			return openErr
+		}
+
+		_, writeErr := io.WriteString(file, content)
+		return errors.Join(writeErr, file.Close())
+	})
+	if err != nil {
+		return err
+	}
+
+	return NULL
+}
+
+// NOTE: the errors of os.Root mention the syscall (openat, statat, ...) and the path, which says nothing to a script
+// author - the common cases get a plain message, everything else the underlying error
+func describeFSError(err error) string {
+	var pathErr *fs.PathError
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return "no such file or directory"
+	case errors.Is(err, fs.ErrPermission):
+		return "permission denied"
+	case errors.As(err, &pathErr):
+		return pathErr.Err.Error()
+	default:
+		return err.Error()
+	}
+}
@@ -0,0 +1,97 @@
+package evaluator
+
+import (
+	"errors"
+	"io"
+	"mfiorek/waiig/object"
+	"strings"
+)
+
+// INFO: I/O builtins - everything goes through the writers & reader of the interpreter's object.Config (see puts too),
+// never straight to os.Stdout, so the output can be captured, redirected or thrown away by whoever runs the script
+
+func init() {
+	for name, builtin := range ioBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+var ioBuiltins = map[string]*object.Builtin{
+	// NOTE: unlike puts, print doesn't add a newline - the arguments are written separated by a space
+	"print": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if err := writeOutput("print", config.Stdout, joinInspected(args)); err != nil {
+				return err
+			}
+
+			return NULL
+		},
+	},
+
+	// NOTE: puts for stderr - every argument on its own line
+	"eprint": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			for _, arg := range args {
+				if err := writeOutput("eprint", config.Stderr, arg.Inspect()+"\n"); err != nil {
+					return err
+				}
+			}
+
+			return NULL
+		},
+	},
+
+	// NOTE: the next line without its line ending, or null once the input is exhausted
+	"read_line": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			line, err := config.StdinReader().ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return newError("read_line: %s", err)
+			}
+			if err != nil && line == "" {
+				return NULL
+			}
+
+			line = strings.TrimSuffix(line, "\n")
+			line = strings.TrimSuffix(line, "\r")
+			return &object.String{Value: line}
+		},
+	},
+
+	// NOTE: everything left in the input ("" once it's exhausted)
+	"read_all": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			content, err := io.ReadAll(config.StdinReader())
+			if err != nil {
+				return newError("read_all: %s", err)
+			}
+
+			return &object.String{Value: string(content)}
+		},
+	},
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+func writeOutput(name string, out io.Writer, str string) *object.Error {
+	if _, err := io.WriteString(out, str); err != nil {
+		return newError("%s: %s", name, err)
+	}
+	return nil
+}
+
+func joinInspected(args []object.Object) string {
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		parts = append(parts, arg.Inspect())
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,227 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"mfiorek/waiig/object"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// INFO: JSON builtins
+// JSON -> Monkey: object -> HASH, array -> ARRAY, string -> STRING, number -> INTEGER (FLOAT when it has a fraction/exponent
+// or doesn't fit in 64 bits), true/false -> BOOLEAN, null -> NULL.
+// Monkey -> JSON is the other way around, with hash keys written in sorted order so the output is deterministic.
+// INTEGER and BOOLEAN hash keys become strings (JSON only has string keys), functions & co. can't be serialized.
+
+func init() {
+	for name, builtin := range jsonBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+var jsonBuiltins = map[string]*object.Builtin{
+	"json_parse": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			str, err := singleStringArg("json_parse", args)
+			if err != nil {
+				return err
+			}
+
+			decoder := json.NewDecoder(strings.NewReader(str))
+			decoder.UseNumber()
+
+			var value any
+			if decodeErr := decoder.Decode(&value); decodeErr != nil {
+				return newError("json_parse: invalid JSON: %s", decodeErr)
+			}
+			if _, decodeErr := decoder.Token(); !errors.Is(decodeErr, io.EOF) {
+				return newError("json_parse: invalid JSON: unexpected data after the top-level value")
+			}
+
+			return jsonToObject(value)
+		},
+	},
+
+	// NOTE: json_stringify(value) gives compact JSON, json_stringify(value, 2) or json_stringify(value, "  ") an indented one
+	"json_stringify": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+
+			var out bytes.Buffer
+			if err := writeJSON(&out, args[0], map[object.Object]bool{}); err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				return &object.String{Value: out.String()}
+			}
+
+			var indent string
+			switch arg := args[1].(type) {
+			case *object.Integer:
+				if arg.Value < 0 {
+					return newError("json_stringify: negative indent: %d", arg.Value)
+				}
+				// NOTE: the most JSON.stringify indents by, too
+				if arg.Value > maxJSONIndent {
+					return newError("json_stringify: indent above %d: %d", maxJSONIndent, arg.Value)
+				}
+				indent = strings.Repeat(" ", int(arg.Value))
+			case *object.String:
+				indent = arg.Value
+			default:
+				return newError("second argument to `json_stringify` must be INTEGER or STRING, got %s", args[1].Type())
+			}
+
+			var indented bytes.Buffer
+			if indentErr := json.Indent(&indented, out.Bytes(), "", indent); indentErr != nil {
+				return newError("json_stringify: %s", indentErr)
+			}
+
+			return &object.String{Value: indented.String()}
+		},
+	},
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+const maxJSONIndent = 10
+
+// WARN: Helper method used only in json_parse - value is what encoding/json decodes into `any` (with UseNumber)
+func jsonToObject(value any) object.Object {
+	switch value := value.(type) {
+	case nil:
+		return NULL
+	case bool:
+		return nativeBoolToBooleanObject(value)
+	case string:
+		return &object.String{Value: value}
+	case json.Number:
+		if integer, err := strconv.ParseInt(value.String(), 10, 64); err == nil {
+			return &object.Integer{Value: integer}
+		}
+		float, err := value.Float64()
+		if err != nil {
+			return newError("json_parse: invalid number %s", value)
+		}
+		return &object.Float{Value: float}
+	case []any:
+		elements := make([]object.Object, 0, len(value))
+		for _, el := range value {
+			element := jsonToObject(el)
+			if isError(element) {
+				return element
+			}
+			elements = append(elements, element)
+		}
+		return &object.Array{Elements: elements}
+	case map[string]any:
+		hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+		for key, el := range value {
+			keyObject := &object.String{Value: key}
+			valueObject := jsonToObject(el)
+			if isError(valueObject) {
+				return valueObject
+			}
+			hash.Pairs[keyObject.HashKey()] = object.HashPair{Key: keyObject, Value: valueObject}
+		}
+		return hash
+	default:
+		return newError("json_parse: unsupported value %v", value)
+	}
+}
+
+// WARN: Helper method used only in json_stringify - always writes compact JSON, indenting is done afterwards.
+// NOTE: writing holds the arrays and hashes being written - one holding itself has no JSON. The same array twice
+// side by side is fine, it's just written twice
+func writeJSON(out *bytes.Buffer, obj object.Object, writing map[object.Object]bool) *object.Error {
+	switch obj.(type) {
+	case *object.Array, *object.Hash:
+		if writing[obj] {
+			return newError("json_stringify: cannot serialize cyclic value")
+		}
+		writing[obj] = true
+		defer delete(writing, obj)
+	}
+
+	switch obj := obj.(type) {
+	case *object.Null:
+		out.WriteString("null")
+	case *object.Boolean:
+		out.WriteString(strconv.FormatBool(obj.Value))
+	case *object.Integer:
+		out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return newError("json_stringify: cannot serialize %s", obj.Inspect())
+		}
+		// NOTE: Inspect keeps the dot (2.0, not 2), so the value is still a FLOAT after a json_parse round trip
+		out.WriteString(obj.Inspect())
+	case *object.String:
+		writeJSONString(out, obj.Value)
+	case *object.Array:
+		out.WriteByte('[')
+		for i, el := range obj.Elements {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := writeJSON(out, el, writing); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case *object.Hash:
+		keys := make([]string, 0, len(obj.Pairs))
+		values := make(map[string]object.Object, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			var key string
+			switch k := pair.Key.(type) {
+			case *object.String:
+				key = k.Value
+			case *object.Integer, *object.Boolean:
+				key = k.Inspect()
+			default:
+				return newError("json_stringify: cannot use %s as an object key", pair.Key.Type())
+			}
+			if _, ok := values[key]; ok {
+				return newError("json_stringify: duplicate object key %q", key)
+			}
+			keys = append(keys, key)
+			values[key] = pair.Value
+		}
+		sort.Strings(keys)
+
+		out.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			writeJSONString(out, key)
+			out.WriteByte(':')
+			if err := writeJSON(out, values[key], writing); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	default:
+		return newError("json_stringify: cannot serialize %s", obj.Type())
+	}
+
+	return nil
+}
+
+// NOTE: not using json.Marshal, as it escapes <, > and & for HTML
+func writeJSONString(out *bytes.Buffer, str string) {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	// NOTE: encoding a string can't fail, it only escapes
+	encoder.Encode(str)
+	out.Write(bytes.TrimRight(encoded.Bytes(), "\n"))
+}
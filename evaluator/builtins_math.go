@@ -0,0 +1,336 @@
+package evaluator
+
+import (
+	"math"
+	"mfiorek/waiig/object"
+)
+
+// INFO: Math & random builtins - the math ones take INTEGERs and FLOATs alike, the random ones all draw from the PRNG
+// of the interpreter's object.Config (seeded with rand_seed, or by the host through Config.Seed), so runs are reproducible.
+// NOTE: min and max are collection builtins, they work on numbers too
+
+func init() {
+	for name, builtin := range mathBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+// NOTE: constants are looked up after the builtins, and just like them can be shadowed by a let
+var constants = map[string]object.Object{
+	"PI":      &object.Float{Value: math.Pi},
+	"E":       &object.Float{Value: math.E},
+	"INF":     &object.Float{Value: math.Inf(1)},
+	"MAX_INT": &object.Integer{Value: math.MaxInt64},
+	"MIN_INT": &object.Integer{Value: math.MinInt64},
+}
+
+var mathBuiltins = map[string]*object.Builtin{
+	"abs": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				if arg.Value < 0 {
+					return &object.Integer{Value: -arg.Value}
+				}
+				return arg
+			case *object.Float:
+				return &object.Float{Value: math.Abs(arg.Value)}
+			default:
+				return newError("argument to `abs` must be INTEGER or FLOAT, got %s", args[0].Type())
+			}
+		},
+	},
+
+	// NOTE: the same as the ** operator
+	"pow": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			for idx := range args {
+				if _, err := numberArg("pow", args, idx); err != nil {
+					return err
+				}
+			}
+
+			return evalInfixExpression("**", args[0], args[1])
+		},
+	},
+
+	"sqrt":  floatBuiltin("sqrt", math.Sqrt),
+	"exp":   floatBuiltin("exp", math.Exp),
+	"log10": floatBuiltin("log10", math.Log10),
+	"log2":  floatBuiltin("log2", math.Log2),
+	"sin":   floatBuiltin("sin", math.Sin),
+	"cos":   floatBuiltin("cos", math.Cos),
+	"tan":   floatBuiltin("tan", math.Tan),
+	"asin":  floatBuiltin("asin", math.Asin),
+	"acos":  floatBuiltin("acos", math.Acos),
+	"atan":  floatBuiltin("atan", math.Atan),
+
+	"atan2": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			y, err := numberArg("atan2", args, 0)
+			if err != nil {
+				return err
+			}
+			x, err := numberArg("atan2", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return &object.Float{Value: math.Atan2(y, x)}
+		},
+	},
+
+	// NOTE: log(x) is the natural logarithm, log(x, base) the one in the given base
+	"log": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+			x, err := numberArg("log", args, 0)
+			if err != nil {
+				return err
+			}
+			result := math.Log(x)
+			if len(args) == 2 {
+				base, err := numberArg("log", args, 1)
+				if err != nil {
+					return err
+				}
+				result /= math.Log(base)
+			}
+
+			if math.IsNaN(result) {
+				return newError("log: math domain error")
+			}
+			return &object.Float{Value: result}
+		},
+	},
+
+	"floor": roundingBuiltin("floor", math.Floor),
+	"ceil":  roundingBuiltin("ceil", math.Ceil),
+
+	// NOTE: round(x) gives the nearest INTEGER (halves away from zero), round(x, digits) a FLOAT with that many decimals
+	"round": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) == 1 {
+				return roundingBuiltin("round", math.Round).Fn(config, args...)
+			}
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+			x, err := numberArg("round", args, 0)
+			if err != nil {
+				return err
+			}
+			digits, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("second argument to `round` must be INTEGER, got %s", args[1].Type())
+			}
+
+			scale := math.Pow(10, float64(digits.Value))
+			return &object.Float{Value: math.Round(x*scale) / scale}
+		},
+	},
+
+	// NOTE: gives back whichever of x, low and high is the answer, so the type of INTEGERs is kept
+	"clamp": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=3", len(args))
+			}
+			values := make([]float64, 3)
+			for idx := range args {
+				value, err := numberArg("clamp", args, idx)
+				if err != nil {
+					return err
+				}
+				values[idx] = value
+			}
+			if values[1] > values[2] {
+				return newError("clamp: low bound %s is greater than high bound %s", args[1].Inspect(), args[2].Inspect())
+			}
+
+			switch {
+			case values[0] < values[1]:
+				return args[1]
+			case values[0] > values[2]:
+				return args[2]
+			default:
+				return args[0]
+			}
+		},
+	},
+
+	"rand_seed": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			seed, ok := args[0].(*object.Integer)
+			if !ok {
+				return newError("argument to `rand_seed` must be INTEGER, got %s", args[0].Type())
+			}
+
+			config.Seed(uint64(seed.Value))
+			return NULL
+		},
+	},
+
+	// NOTE: rand_int(n) gives 0 <= x < n, rand_int(low, high) low <= x < high - the same bounds as range
+	"rand_int": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+			bounds := []int64{0}
+			for idx, arg := range args {
+				bound, ok := arg.(*object.Integer)
+				if !ok {
+					if len(args) == 1 {
+						return newError("argument to `rand_int` must be INTEGER, got %s", arg.Type())
+					}
+					return newError("%s argument to `rand_int` must be INTEGER, got %s", ordinals[idx], arg.Type())
+				}
+				bounds = append(bounds, bound.Value)
+			}
+			low, high := bounds[len(bounds)-2], bounds[len(bounds)-1]
+			if low >= high {
+				return newError("rand_int: empty range %d..%d", low, high)
+			}
+
+			// NOTE: the span is computed in uint64, as high - low can overflow int64 (e.g. MIN_INT..MAX_INT)
+			span := uint64(high) - uint64(low)
+			return &object.Integer{Value: low + int64(config.Random().Uint64N(span))}
+		},
+	},
+
+	// NOTE: rand_float() gives 0 <= x < 1, rand_float(low, high) low <= x < high
+	"rand_float": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 0 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=0 or 2", len(args))
+			}
+			low, high := 0.0, 1.0
+			if len(args) == 2 {
+				var err *object.Error
+				if low, err = numberArg("rand_float", args, 0); err != nil {
+					return err
+				}
+				if high, err = numberArg("rand_float", args, 1); err != nil {
+					return err
+				}
+				if low >= high {
+					return newError("rand_float: empty range %s..%s", args[0].Inspect(), args[1].Inspect())
+				}
+			}
+
+			return &object.Float{Value: low + config.Random().Float64()*(high-low)}
+		},
+	},
+
+	"choice": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `choice` must be ARRAY, got %s", args[0].Type())
+			}
+			if len(arr.Elements) == 0 {
+				return newError("choice: empty array")
+			}
+
+			return arr.Elements[config.Random().IntN(len(arr.Elements))]
+		},
+	},
+
+	// NOTE: gives a shuffled copy, the array itself is left alone
+	"shuffle": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `shuffle` must be ARRAY, got %s", args[0].Type())
+			}
+
+			elements := make([]object.Object, len(arr.Elements))
+			copy(elements, arr.Elements)
+			config.Random().Shuffle(len(elements), func(i, j int) {
+				elements[i], elements[j] = elements[j], elements[i]
+			})
+
+			return &object.Array{Elements: elements}
+		},
+	},
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+func numberArg(name string, args []object.Object, idx int) (float64, *object.Error) {
+	if !isNumber(args[idx]) {
+		if len(args) == 1 {
+			return 0, newError("argument to `%s` must be INTEGER or FLOAT, got %s", name, args[idx].Type())
+		}
+		return 0, newError("%s argument to `%s` must be INTEGER or FLOAT, got %s", ordinals[idx], name, args[idx].Type())
+	}
+	return toFloat(args[idx]), nil
+}
+
+// NOTE: a builtin of one number giving a FLOAT - a NaN out of a non-NaN argument means the argument was out of the domain (sqrt(-1))
+func floatBuiltin(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			x, err := numberArg(name, args, 0)
+			if err != nil {
+				return err
+			}
+
+			result := fn(x)
+			if math.IsNaN(result) && !math.IsNaN(x) {
+				return newError("%s: math domain error", name)
+			}
+			return &object.Float{Value: result}
+		},
+	}
+}
+
+// NOTE: a builtin turning a number into an INTEGER - INTEGERs are given back as they are
+func roundingBuiltin(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Float:
+				result := fn(arg.Value)
+				// NOTE: float64(math.MaxInt64) rounds up to 2^63, which doesn't fit anymore, hence >=
+				if math.IsNaN(result) || result < math.MinInt64 || result >= math.MaxInt64 {
+					return newError("%s: cannot convert %s to INTEGER", name, arg.Inspect())
+				}
+				return &object.Integer{Value: int64(result)}
+			default:
+				return newError("argument to `%s` must be INTEGER or FLOAT, got %s", name, args[0].Type())
+			}
+		},
+	}
+}
@@ -0,0 +1,243 @@
+package evaluator
+
+import (
+	"mfiorek/waiig/object"
+	"regexp"
+	"strings"
+)
+
+// INFO: Regex builtins - regex("...") compiles the pattern once (Go regexp / RE2 syntax), the rest take the REGEX as first argument.
+// NOTE: monkey strings have no escape sequences, so a backslash is just a backslash: regex("\d+") works as expected.
+// Capture groups come back as arrays ([whole match, group 1, group 2, ...]) or hashes (named groups), with null for a
+// group that didn't take part in the match.
+
+func init() {
+	for name, builtin := range regexBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+var regexBuiltins = map[string]*object.Builtin{
+	"regex": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			pattern, err := singleStringArg("regex", args)
+			if err != nil {
+				return err
+			}
+
+			re, compileErr := regexp.Compile(pattern)
+			if compileErr != nil {
+				return newError("regex: invalid pattern: %s", compileErr)
+			}
+
+			return &object.Regex{Value: re}
+		},
+	},
+
+	"regex_match": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			re, str, err := regexAndStringArgs("regex_match", args)
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(re.MatchString(str))
+		},
+	},
+
+	// NOTE: the first match or null
+	"regex_find": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			re, str, err := regexAndStringArgs("regex_find", args)
+			if err != nil {
+				return err
+			}
+
+			loc := re.FindStringIndex(str)
+			if loc == nil {
+				return NULL
+			}
+
+			return &object.String{Value: str[loc[0]:loc[1]]}
+		},
+	},
+
+	// NOTE: regex_find_all(re, s) gives all matches, regex_find_all(re, s, n) at most n of them
+	"regex_find_all": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			re, str, limit, err := regexArgsWithLimit("regex_find_all", args)
+			if err != nil {
+				return err
+			}
+
+			return stringsToArray(re.FindAllString(str, limit))
+		},
+	},
+
+	// NOTE: [whole match, group 1, ...] for the first match or null
+	"regex_captures": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			re, str, err := regexAndStringArgs("regex_captures", args)
+			if err != nil {
+				return err
+			}
+
+			loc := re.FindStringSubmatchIndex(str)
+			if loc == nil {
+				return NULL
+			}
+
+			return capturesToArray(str, loc)
+		},
+	},
+
+	// NOTE: an array of regex_captures results, one for every match
+	"regex_captures_all": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			re, str, limit, err := regexArgsWithLimit("regex_captures_all", args)
+			if err != nil {
+				return err
+			}
+
+			matches := re.FindAllStringSubmatchIndex(str, limit)
+			elements := make([]object.Object, 0, len(matches))
+			for _, loc := range matches {
+				elements = append(elements, capturesToArray(str, loc))
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+
+	// NOTE: {name: value} for the named groups ((?P<name>...)) of the first match or null
+	"regex_named_captures": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			re, str, err := regexAndStringArgs("regex_named_captures", args)
+			if err != nil {
+				return err
+			}
+
+			loc := re.FindStringSubmatchIndex(str)
+			if loc == nil {
+				return NULL
+			}
+
+			hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+			for i, name := range re.SubexpNames() {
+				if name == "" {
+					continue
+				}
+				key := &object.String{Value: name}
+				hash.Pairs[key.HashKey()] = object.HashPair{Key: key, Value: captureAt(str, loc, i)}
+			}
+
+			return hash
+		},
+	},
+
+	// NOTE: the replacement is either a STRING ($1 / ${name} expand to the groups) or a function getting the
+	// regex_captures array of every match and returning the STRING to put in its place
+	"regex_replace": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=3", len(args))
+			}
+			re, str, err := regexAndStringArgs("regex_replace", args[:2])
+			if err != nil {
+				return err
+			}
+
+			if replacement, ok := args[2].(*object.String); ok {
+				return &object.String{Value: re.ReplaceAllString(str, replacement.Value)}
+			}
+			if !isCallable(args[2]) {
+				return newError("third argument to `regex_replace` must be STRING or FUNCTION, got %s", args[2].Type())
+			}
+
+			var out strings.Builder
+			last := 0
+			for _, loc := range re.FindAllStringSubmatchIndex(str, -1) {
+				result := applyFunction(args[2], []object.Object{capturesToArray(str, loc)}, config)
+				if isError(result) {
+					return result
+				}
+				replaced, ok := result.(*object.String)
+				if !ok {
+					return newError("regex_replace: callback must return STRING, got %s", result.Type())
+				}
+
+				out.WriteString(str[last:loc[0]])
+				out.WriteString(replaced.Value)
+				last = loc[1]
+			}
+			out.WriteString(str[last:])
+
+			return &object.String{Value: out.String()}
+		},
+	},
+
+	// NOTE: regex_split(re, s) splits on every match, regex_split(re, s, n) into at most n parts
+	"regex_split": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			re, str, limit, err := regexArgsWithLimit("regex_split", args)
+			if err != nil {
+				return err
+			}
+
+			return stringsToArray(re.Split(str, limit))
+		},
+	},
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+func regexAndStringArgs(name string, args []object.Object) (*regexp.Regexp, string, *object.Error) {
+	if len(args) != 2 {
+		return nil, "", newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	re, ok := args[0].(*object.Regex)
+	if !ok {
+		return nil, "", newError("first argument to `%s` must be REGEX, got %s", name, args[0].Type())
+	}
+	str, err := stringArg(name, args, 1)
+	if err != nil {
+		return nil, "", err
+	}
+	return re.Value, str, nil
+}
+
+// NOTE: limit is -1 (everything) when the optional third argument is missing, like in Go's regexp
+func regexArgsWithLimit(name string, args []object.Object) (*regexp.Regexp, string, int, *object.Error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, "", 0, newError("wrong number of arguments. got=%d, want=2..3", len(args))
+	}
+	re, str, err := regexAndStringArgs(name, args[:2])
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if len(args) == 2 {
+		return re, str, -1, nil
+	}
+	limit, ok := args[2].(*object.Integer)
+	if !ok {
+		return nil, "", 0, newError("third argument to `%s` must be INTEGER, got %s", name, args[2].Type())
+	}
+	return re, str, int(limit.Value), nil
+}
+
+// WARN: Helper method used only in regex builtins - loc is what the FindStringSubmatchIndex family returns
+func capturesToArray(str string, loc []int) *object.Array {
+	elements := make([]object.Object, 0, len(loc)/2)
+	for i := 0; i < len(loc)/2; i++ {
+		elements = append(elements, captureAt(str, loc, i))
+	}
+	return &object.Array{Elements: elements}
+}
+
+func captureAt(str string, loc []int, group int) object.Object {
+	start, end := loc[2*group], loc[2*group+1]
+	if start < 0 {
+		return NULL
+	}
+	return &object.String{Value: str[start:end]}
+}
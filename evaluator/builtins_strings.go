@@ -0,0 +1,507 @@
+package evaluator
+
+import (
+	"fmt"
+	"mfiorek/waiig/object"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// INFO: String builtins - everything works on runes (not bytes), the same way len and string indexing do
+
+func init() {
+	for name, builtin := range stringBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+var stringBuiltins = map[string]*object.Builtin{
+	// NOTE: split(s) splits on whitespace, split(s, "") into single characters
+	"split": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+			str, err := stringArg("split", args, 0)
+			if err != nil {
+				return err
+			}
+
+			var parts []string
+			if len(args) == 1 {
+				parts = strings.Fields(str)
+			} else {
+				sep, err := stringArg("split", args, 1)
+				if err != nil {
+					return err
+				}
+				parts = strings.Split(str, sep)
+			}
+
+			return stringsToArray(parts)
+		},
+	},
+
+	// NOTE: elements that aren't STRINGs are joined using their printed form (like to_string)
+	"join": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("first argument to `join` must be ARRAY, got %s", args[0].Type())
+			}
+			sep := ""
+			if len(args) == 2 {
+				var err *object.Error
+				if sep, err = stringArg("join", args, 1); err != nil {
+					return err
+				}
+			}
+
+			parts := make([]string, 0, len(arr.Elements))
+			for _, el := range arr.Elements {
+				parts = append(parts, el.Inspect())
+			}
+
+			return &object.String{Value: strings.Join(parts, sep)}
+		},
+	},
+
+	// NOTE: the trim variants take an optional cutset, whitespace is trimmed by default
+	"trim": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			return trimBuiltin("trim", args, strings.TrimSpace, strings.Trim)
+		},
+	},
+
+	"trim_left": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			return trimBuiltin("trim_left", args, func(s string) string {
+				return strings.TrimLeftFunc(s, unicode.IsSpace)
+			}, strings.TrimLeft)
+		},
+	},
+
+	"trim_right": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			return trimBuiltin("trim_right", args, func(s string) string {
+				return strings.TrimRightFunc(s, unicode.IsSpace)
+			}, strings.TrimRight)
+		},
+	},
+
+	"upper": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			str, err := singleStringArg("upper", args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ToUpper(str)}
+		},
+	},
+
+	"lower": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			str, err := singleStringArg("lower", args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ToLower(str)}
+		},
+	},
+
+	"contains": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			str, substr, err := twoStringArgs("contains", args)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.Contains(str, substr))
+		},
+	},
+
+	"starts_with": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			str, prefix, err := twoStringArgs("starts_with", args)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.HasPrefix(str, prefix))
+		},
+	},
+
+	"ends_with": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			str, suffix, err := twoStringArgs("ends_with", args)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.HasSuffix(str, suffix))
+		},
+	},
+
+	// NOTE: gives the rune index (so it can be used for indexing/slicing the string), -1 when not found
+	"index_of": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			str, substr, err := twoStringArgs("index_of", args)
+			if err != nil {
+				return err
+			}
+
+			byteIdx := strings.Index(str, substr)
+			if byteIdx < 0 {
+				return &object.Integer{Value: -1}
+			}
+
+			return &object.Integer{Value: int64(utf8.RuneCountInString(str[:byteIdx]))}
+		},
+	},
+
+	// NOTE: replaces all occurrences, replace(s, old, new, n) only the first n
+	"replace": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 3 && len(args) != 4 {
+				return newError("wrong number of arguments. got=%d, want=3..4", len(args))
+			}
+			strs := make([]string, 3)
+			for i := range strs {
+				var err *object.Error
+				if strs[i], err = stringArg("replace", args, i); err != nil {
+					return err
+				}
+			}
+			count := int64(-1)
+			if len(args) == 4 {
+				integer, ok := args[3].(*object.Integer)
+				if !ok {
+					return newError("fourth argument to `replace` must be INTEGER, got %s", args[3].Type())
+				}
+				count = integer.Value
+			}
+
+			return &object.String{Value: strings.Replace(strs[0], strs[1], strs[2], int(count))}
+		},
+	},
+
+	"repeat": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			str, err := stringArg("repeat", args, 0)
+			if err != nil {
+				return err
+			}
+			count, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("second argument to `repeat` must be INTEGER, got %s", args[1].Type())
+			}
+			if count.Value < 0 {
+				return newError("negative count passed to `repeat`: %d", count.Value)
+			}
+			if len(str) > 0 && count.Value > maxStringLength/int64(len(str)) {
+				return newError("`repeat` would make a string of more than %d bytes", maxStringLength)
+			}
+
+			return &object.String{Value: strings.Repeat(str, int(count.Value))}
+		},
+	},
+
+	// NOTE: pad_left/pad_right(s, width, [char]) pad with spaces (or char) up to width runes
+	"pad_left": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			return padBuiltin("pad_left", args, func(s, padding string) string { return padding + s })
+		},
+	},
+
+	"pad_right": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			return padBuiltin("pad_right", args, func(s, padding string) string { return s + padding })
+		},
+	},
+
+	"chars": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			str, err := singleStringArg("chars", args)
+			if err != nil {
+				return err
+			}
+
+			parts := []string{}
+			for _, r := range str {
+				parts = append(parts, string(r))
+			}
+
+			return stringsToArray(parts)
+		},
+	},
+
+	"format": {
+		Fn: formatBuiltin,
+	},
+
+	// NOTE: just an alias of format, for the people used to C and Go
+	"sprintf": {
+		Fn: formatBuiltin,
+	},
+
+	// NOTE: parse_int(s) understands 0x/0o/0b prefixes, parse_int(s, base) uses the given base
+	"parse_int": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+			str, err := stringArg("parse_int", args, 0)
+			if err != nil {
+				return err
+			}
+			base := int64(0)
+			if len(args) == 2 {
+				integer, ok := args[1].(*object.Integer)
+				if !ok {
+					return newError("second argument to `parse_int` must be INTEGER, got %s", args[1].Type())
+				}
+				base = integer.Value
+			}
+
+			value, parseErr := strconv.ParseInt(strings.TrimSpace(str), int(base), 64)
+			if parseErr != nil {
+				return newError("could not parse %q as integer", str)
+			}
+
+			return &object.Integer{Value: value}
+		},
+	},
+
+	"parse_float": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			str, err := singleStringArg("parse_float", args)
+			if err != nil {
+				return err
+			}
+
+			value, parseErr := strconv.ParseFloat(strings.TrimSpace(str), 64)
+			if parseErr != nil {
+				return newError("could not parse %q as float", str)
+			}
+
+			return &object.Float{Value: value}
+		},
+	},
+
+	"to_string": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if str, ok := args[0].(*object.String); ok {
+				return str
+			}
+			return &object.String{Value: args[0].Inspect()}
+		},
+	},
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+var ordinals = []string{"first", "second", "third", "fourth", "fifth", "sixth", "seventh"}
+
+// NOTE: gives the idx-th argument as a Go string, with the same error messages the other builtins use
+func stringArg(name string, args []object.Object, idx int) (string, *object.Error) {
+	str, ok := args[idx].(*object.String)
+	if !ok {
+		if len(args) == 1 {
+			return "", newError("argument to `%s` must be STRING, got %s", name, args[idx].Type())
+		}
+		return "", newError("%s argument to `%s` must be STRING, got %s", ordinals[idx], name, args[idx].Type())
+	}
+	return str.Value, nil
+}
+
+func singleStringArg(name string, args []object.Object) (string, *object.Error) {
+	if len(args) != 1 {
+		return "", newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	return stringArg(name, args, 0)
+}
+
+func twoStringArgs(name string, args []object.Object) (string, string, *object.Error) {
+	if len(args) != 2 {
+		return "", "", newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	first, err := stringArg(name, args, 0)
+	if err != nil {
+		return "", "", err
+	}
+	second, err := stringArg(name, args, 1)
+	if err != nil {
+		return "", "", err
+	}
+	return first, second, nil
+}
+
+func stringsToArray(parts []string) *object.Array {
+	elements := make([]object.Object, 0, len(parts))
+	for _, part := range parts {
+		elements = append(elements, &object.String{Value: part})
+	}
+	return &object.Array{Elements: elements}
+}
+
+func trimBuiltin(name string, args []object.Object, trimSpace func(string) string, trimCutset func(string, string) string) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+	}
+	str, err := stringArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		return &object.String{Value: trimSpace(str)}
+	}
+
+	cutset, err := stringArg(name, args, 1)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: trimCutset(str, cutset)}
+}
+
+// NOTE: the longest string repeat and pad_left/pad_right make - anything longer is most likely a mistake
+const maxStringLength = 1 << 28
+
+func padBuiltin(name string, args []object.Object, pad func(s, padding string) string) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=2..3", len(args))
+	}
+	str, err := stringArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+	width, ok := args[1].(*object.Integer)
+	if !ok {
+		return newError("second argument to `%s` must be INTEGER, got %s", name, args[1].Type())
+	}
+	padChar := " "
+	if len(args) == 3 {
+		if padChar, err = stringArg(name, args, 2); err != nil {
+			return err
+		}
+		if utf8.RuneCountInString(padChar) != 1 {
+			return newError("third argument to `%s` must be a single character, got %q", name, padChar)
+		}
+	}
+
+	missing := width.Value - int64(utf8.RuneCountInString(str))
+	if missing <= 0 {
+		return &object.String{Value: str}
+	}
+	if missing > maxStringLength/int64(len(padChar)) {
+		return newError("`%s` would make a string of more than %d bytes", name, maxStringLength)
+	}
+
+	return &object.String{Value: pad(str, strings.Repeat(padChar, int(missing)))}
+}
+
+// NOTE: format("%s is %d years old", name, age) - the verbs (and flags like %-5s, %.2f, %05d) are the ones of Go's fmt,
+// but every argument is checked against its verb, so format("%d", "a") is an error instead of "%!d(string=a)"
+func formatBuiltin(config *object.Config, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError("wrong number of arguments. got=%d, want>=1", len(args))
+	}
+	format, err := stringArg("format", args[:1], 0)
+	if err != nil {
+		return err
+	}
+
+	var out strings.Builder
+	values := args[1:]
+	next := 0
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("+- #0123456789.", format[i]) >= 0 {
+			i++
+		}
+		if i >= len(format) {
+			return newError("format: unfinished verb %q", format[start:])
+		}
+		verb := format[i]
+		spec := format[start : i+1]
+
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		if next >= len(values) {
+			return newError("format: missing argument for %s", spec)
+		}
+
+		value, err := formatValue(spec, verb, values[next])
+		if err != nil {
+			return err
+		}
+		out.WriteString(value)
+		next++
+	}
+
+	if next < len(values) {
+		return newError("format: too many arguments. got=%d, used=%d", len(values), next)
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+// NOTE: the widths and precisions Go's fmt takes - above it prints %!(BADWIDTH) or %!(BADPREC) instead
+const maxFormatWidth = 1_000_000
+
+// WARN: Helper method used only in formatBuiltin
+func formatValue(spec string, verb byte, value object.Object) (string, *object.Error) {
+	for _, number := range strings.FieldsFunc(spec, func(r rune) bool { return r < '0' || r > '9' }) {
+		if n, err := strconv.Atoi(number); err != nil || n > maxFormatWidth {
+			return "", newError("format: width or precision of %s is above %d", spec, maxFormatWidth)
+		}
+	}
+
+	switch verb {
+	case 'd', 'x', 'X', 'o', 'b', 'c':
+		integer, ok := value.(*object.Integer)
+		if !ok {
+			return "", newError("format: %s expects INTEGER, got %s", spec, value.Type())
+		}
+		return fmt.Sprintf(spec, integer.Value), nil
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		if !isNumber(value) {
+			return "", newError("format: %s expects FLOAT, got %s", spec, value.Type())
+		}
+		return fmt.Sprintf(spec, toFloat(value)), nil
+	case 't':
+		boolean, ok := value.(*object.Boolean)
+		if !ok {
+			return "", newError("format: %s expects BOOLEAN, got %s", spec, value.Type())
+		}
+		return fmt.Sprintf(spec, boolean.Value), nil
+	case 'q':
+		str, ok := value.(*object.String)
+		if !ok {
+			return "", newError("format: %s expects STRING, got %s", spec, value.Type())
+		}
+		return fmt.Sprintf(spec, str.Value), nil
+	case 's', 'v':
+		// NOTE: any object can be printed with %s/%v, it's the same output as puts gives
+		return fmt.Sprintf(spec[:len(spec)-1]+"s", value.Inspect()), nil
+	default:
+		return "", newError("format: unknown verb %s", spec)
+	}
+}
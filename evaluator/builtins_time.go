@@ -0,0 +1,290 @@
+package evaluator
+
+import (
+	"mfiorek/waiig/object"
+	"time"
+	_ "time/tzdata" // NOTE: the time zone database is embedded, so time_in & co. work on hosts without one
+)
+
+// INFO: Time builtins - TIME and DURATION are Go's time.Time and time.Duration, the layouts are Go's reference layouts
+// ("2006-01-02 15:04:05") or one of the names in timeLayouts. The current time comes from the interpreter's object.Config.
+
+func init() {
+	for name, builtin := range timeBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RFC822":      time.RFC822,
+	"RFC1123":     time.RFC1123,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+var timeBuiltins = map[string]*object.Builtin{
+	"now": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return &object.Time{Value: config.Now()}
+		},
+	},
+
+	// NOTE: how long ago the given time was
+	"since": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			t, err := timeArg("since", args, 0)
+			if err != nil {
+				return err
+			}
+
+			return &object.Duration{Value: config.Now().Sub(t)}
+		},
+	},
+
+	// NOTE: time_parse(s) expects RFC3339, time_parse(s, layout) the given layout and time_parse(s, layout, zone) reads
+	// a time without zone information as one in the given zone (UTC otherwise)
+	"time_parse": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments. got=%d, want=1..3", len(args))
+			}
+			str, err := stringArg("time_parse", args, 0)
+			if err != nil {
+				return err
+			}
+			layout := time.RFC3339
+			if len(args) >= 2 {
+				if layout, err = layoutArg("time_parse", args, 1); err != nil {
+					return err
+				}
+			}
+			location := time.UTC
+			if len(args) == 3 {
+				if location, err = locationArg("time_parse", args, 2); err != nil {
+					return err
+				}
+			}
+
+			t, parseErr := time.ParseInLocation(layout, str, location)
+			if parseErr != nil {
+				return newError("time_parse: %s", parseErr)
+			}
+
+			return &object.Time{Value: t}
+		},
+	},
+
+	// NOTE: time_format(t) gives RFC3339, time_format(t, layout) the given layout
+	"time_format": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+			t, err := timeArg("time_format", args, 0)
+			if err != nil {
+				return err
+			}
+			layout := time.RFC3339
+			if len(args) == 2 {
+				if layout, err = layoutArg("time_format", args, 1); err != nil {
+					return err
+				}
+			}
+
+			return &object.String{Value: t.Format(layout)}
+		},
+	},
+
+	// NOTE: time_date(year, month, day, hour?, minute?, second?, zone?) - without a zone the time is in UTC
+	"time_date": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) < 3 || len(args) > 7 {
+				return newError("wrong number of arguments. got=%d, want=3..7", len(args))
+			}
+			parts := make([]int, 6)
+			for i := 0; i < len(args) && i < 6; i++ {
+				part, ok := args[i].(*object.Integer)
+				if !ok {
+					return newError("%s argument to `time_date` must be INTEGER, got %s", ordinals[i], args[i].Type())
+				}
+				parts[i] = int(part.Value)
+			}
+			location := time.UTC
+			if len(args) == 7 {
+				var err *object.Error
+				if location, err = locationArg("time_date", args, 6); err != nil {
+					return err
+				}
+			}
+
+			t := time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, location)
+			return &object.Time{Value: t}
+		},
+	},
+
+	// NOTE: the same moment, seen from the given zone
+	"time_in": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			t, err := timeArg("time_in", args, 0)
+			if err != nil {
+				return err
+			}
+			location, err := locationArg("time_in", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return &object.Time{Value: t.In(location)}
+		},
+	},
+
+	// NOTE: seconds since the Unix epoch
+	"time_unix": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			t, err := timeArg("time_unix", args, 0)
+			if err != nil {
+				return err
+			}
+
+			return &object.Integer{Value: t.Unix()}
+		},
+	},
+
+	"time_from_unix": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			seconds, ok := args[0].(*object.Integer)
+			if !ok {
+				return newError("argument to `time_from_unix` must be INTEGER, got %s", args[0].Type())
+			}
+
+			return &object.Time{Value: time.Unix(seconds.Value, 0).UTC()}
+		},
+	},
+
+	// NOTE: {year, month, day, hour, minute, second, nanosecond, weekday, yearday, zone} of the time
+	"time_parts": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			t, err := timeArg("time_parts", args, 0)
+			if err != nil {
+				return err
+			}
+
+			zone, _ := t.Zone()
+			hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+			for _, part := range []struct {
+				name  string
+				value object.Object
+			}{
+				{"year", &object.Integer{Value: int64(t.Year())}},
+				{"month", &object.Integer{Value: int64(t.Month())}},
+				{"day", &object.Integer{Value: int64(t.Day())}},
+				{"hour", &object.Integer{Value: int64(t.Hour())}},
+				{"minute", &object.Integer{Value: int64(t.Minute())}},
+				{"second", &object.Integer{Value: int64(t.Second())}},
+				{"nanosecond", &object.Integer{Value: int64(t.Nanosecond())}},
+				{"weekday", &object.String{Value: t.Weekday().String()}},
+				{"yearday", &object.Integer{Value: int64(t.YearDay())}},
+				{"zone", &object.String{Value: zone}},
+			} {
+				key := &object.String{Value: part.name}
+				hash.Pairs[key.HashKey()] = object.HashPair{Key: key, Value: part.value}
+			}
+
+			return hash
+		},
+	},
+
+	// NOTE: duration("1h30m") - the units are the ones of Go: ns, us, ms, s, m, h
+	"duration": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			str, err := singleStringArg("duration", args)
+			if err != nil {
+				return err
+			}
+
+			d, parseErr := time.ParseDuration(str)
+			if parseErr != nil {
+				return newError("duration: %s", parseErr)
+			}
+
+			return &object.Duration{Value: d}
+		},
+	},
+
+	"duration_seconds": {
+		Fn: func(config *object.Config, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			d, ok := args[0].(*object.Duration)
+			if !ok {
+				return newError("argument to `duration_seconds` must be DURATION, got %s", args[0].Type())
+			}
+
+			return &object.Float{Value: d.Value.Seconds()}
+		},
+	},
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+func timeArg(name string, args []object.Object, idx int) (time.Time, *object.Error) {
+	t, ok := args[idx].(*object.Time)
+	if !ok {
+		if len(args) == 1 {
+			return time.Time{}, newError("argument to `%s` must be TIME, got %s", name, args[idx].Type())
+		}
+		return time.Time{}, newError("%s argument to `%s` must be TIME, got %s", ordinals[idx], name, args[idx].Type())
+	}
+	return t.Value, nil
+}
+
+// NOTE: one of the names in timeLayouts or the layout itself
+func layoutArg(name string, args []object.Object, idx int) (string, *object.Error) {
+	layout, err := stringArg(name, args, idx)
+	if err != nil {
+		return "", err
+	}
+	if named, ok := timeLayouts[layout]; ok {
+		return named, nil
+	}
+	return layout, nil
+}
+
+// NOTE: an IANA zone name like "Europe/Warsaw", or "UTC" / "Local"
+func locationArg(name string, args []object.Object, idx int) (*time.Location, *object.Error) {
+	zone, err := stringArg(name, args, idx)
+	if err != nil {
+		return nil, err
+	}
+	location, loadErr := time.LoadLocation(zone)
+	if loadErr != nil {
+		return nil, newError("%s: unknown time zone %q", name, zone)
+	}
+	return location, nil
+}
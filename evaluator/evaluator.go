@@ -1,9 +1,13 @@
 package evaluator
 
 import (
+	"cmp"
 	"fmt"
+	"math"
 	"mfiorek/waiig/ast"
 	"mfiorek/waiig/object"
+	"mfiorek/waiig/suggest"
+	"time"
 )
 
 var (
@@ -33,11 +37,16 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(evaluated) {
 			return evaluated
 		}
-		env.Set(node.Name.Value, evaluated)
+		if node.Pattern != nil {
+			return evalDestructuring(node.Pattern, evaluated, env)
+		}
+		bind(env, node.Name, evaluated)
 
 	// INFO: Expressions:
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.StringLiteral:
@@ -49,6 +58,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evalPrefixExpression(node.Operator, rightEvaluated)
 	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
 		leftEvaluated := Eval(node.Left, env)
 		if isError(leftEvaluated) {
 			return leftEvaluated
@@ -65,7 +77,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{Parameters: params, Rest: node.Rest, Body: body, Env: env}
 	case *ast.CallExpression:
 		function := Eval(node.Function, env)
 		if isError(function) {
@@ -75,7 +87,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		return applyFunction(function, args, env.Config())
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -91,9 +103,20 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(index) {
 			return index
 		}
-		return evalIndexExpression(left, index)
+		return evalIndexExpression(left, index, env.Config().StrictIndexing)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
+
+	// NOTE: placeholders the parser leaves where the source didn't parse - only there when someone evaluates a program
+	// despite its parser errors
+	case *ast.BadStatement, *ast.BadExpression:
+		return newError("cannot evaluate code with syntax errors (at %q)", node.TokenLiteral())
 	}
 
 	return nil
@@ -135,6 +158,19 @@ func evalStatements(stmts []ast.Statement, env *object.Environment) object.Objec
 	return result
 }
 
+// NOTE: let [a, b] = ... and let {"x": x} = ... - a value that doesn't fit the pattern is an error, not a null
+func evalDestructuring(pattern ast.Pattern, value object.Object, env *object.Environment) object.Object {
+	matched, err := matchPattern(pattern, value, env)
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return newError("cannot destructure %s into %s", value.Inspect(), pattern.String())
+	}
+
+	return nil
+}
+
 // INFO: ==================================== EXPRESSIONS ====================================
 
 // INFO: PrefixExpressions:
@@ -147,6 +183,8 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
+	case "~":
+		return evalTildePrefixOperatorExpression(right)
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
 	}
@@ -189,12 +227,25 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 // }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: -right.Value}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	case *object.Duration:
+		return &object.Duration{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
+}
+
+func evalTildePrefixOperatorExpression(right object.Object) object.Object {
+	if right.Type() != object.INTEGER_OBJ {
+		return newError("unknown operator: ~%s", right.Type())
+	}
 
 	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
+	return &object.Integer{Value: ^value}
 }
 
 // INFO: InfixExpressions:
@@ -220,8 +271,12 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
+	case isTimeOrDuration(left) || isTimeOrDuration(right):
+		return evalTimeInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right) // <-- comparing equality between pointers (it works because we always use the same two pointers for booleans)
 	case operator == "!=":
@@ -247,11 +302,99 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "*":
 		return &object.Integer{Value: leftValue * rightValue}
 	case "/":
+		if rightValue == 0 {
+			return newError("division by zero: %d / %d", leftValue, rightValue)
+		}
 		return &object.Integer{Value: leftValue / rightValue}
+	case "%":
+		if rightValue == 0 {
+			return newError("division by zero: %d %% %d", leftValue, rightValue)
+		}
+		return &object.Integer{Value: leftValue % rightValue}
+	case "**":
+		// NOTE: a negative exponent can't give a whole number, so we fall back to a FLOAT
+		if rightValue < 0 {
+			return &object.Float{Value: math.Pow(float64(leftValue), float64(rightValue))}
+		}
+		return &object.Integer{Value: integerPower(leftValue, rightValue)}
+	case "&":
+		return &object.Integer{Value: leftValue & rightValue}
+	case "|":
+		return &object.Integer{Value: leftValue | rightValue}
+	case "^":
+		return &object.Integer{Value: leftValue ^ rightValue}
+	case "<<":
+		if rightValue < 0 {
+			return newError("negative shift count: %d", rightValue)
+		}
+		return &object.Integer{Value: leftValue << rightValue}
+	case ">>":
+		if rightValue < 0 {
+			return newError("negative shift count: %d", rightValue)
+		}
+		return &object.Integer{Value: leftValue >> rightValue}
 	case "<":
 		return nativeBoolToBooleanObject(leftValue < rightValue)
 	case ">":
 		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
+	case "==":
+		return nativeBoolToBooleanObject(leftValue == rightValue)
+	case "!=":
+		return nativeBoolToBooleanObject(leftValue != rightValue)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// WARN: Helper method used only in evalIntegerInfixExpression - exponentiation by squaring (overflows wrap, like * does)
+func integerPower(base, exponent int64) int64 {
+	result := int64(1)
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			result *= base
+		}
+		base *= base
+		exponent >>= 1
+	}
+	return result
+}
+
+// NOTE: used when at least one side is a FLOAT - INTEGERs get promoted, so 1 + 0.5 gives 1.5
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftValue := toFloat(left)
+	rightValue := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftValue + rightValue}
+	case "-":
+		return &object.Float{Value: leftValue - rightValue}
+	case "*":
+		return &object.Float{Value: leftValue * rightValue}
+	case "/":
+		if rightValue == 0 {
+			return newError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: leftValue / rightValue}
+	case "%":
+		if rightValue == 0 {
+			return newError("division by zero: %s %% %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: math.Mod(leftValue, rightValue)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftValue, rightValue)}
+	case "<":
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
 	case "==":
 		return nativeBoolToBooleanObject(leftValue == rightValue)
 	case "!=":
@@ -268,6 +411,14 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 	switch operator {
 	case "+":
 		return &object.String{Value: leftValue + rightValue}
+	case "<":
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
 	case "==":
 		return nativeBoolToBooleanObject(leftValue == rightValue)
 	case "!=":
@@ -277,6 +428,106 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 	}
 }
 
+// NOTE: the arithmetic of times & durations - time - time = duration, time ± duration = time, durations add up and
+// scale by numbers (duration / duration gives their ratio as FLOAT). Both times and durations compare with the usual operators.
+func evalTimeInfixExpression(operator string, left, right object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Time:
+		switch right := right.(type) {
+		case *object.Time:
+			if operator == "-" {
+				return &object.Duration{Value: left.Value.Sub(right.Value)}
+			}
+			if result, ok := compareWithOperator(operator, left.Value.Compare(right.Value)); ok {
+				return result
+			}
+		case *object.Duration:
+			switch operator {
+			case "+":
+				return &object.Time{Value: left.Value.Add(right.Value)}
+			case "-":
+				return &object.Time{Value: left.Value.Add(-right.Value)}
+			}
+		}
+	case *object.Duration:
+		switch right := right.(type) {
+		case *object.Time:
+			if operator == "+" {
+				return &object.Time{Value: right.Value.Add(left.Value)}
+			}
+		case *object.Duration:
+			switch operator {
+			case "+":
+				return &object.Duration{Value: left.Value + right.Value}
+			case "-":
+				return &object.Duration{Value: left.Value - right.Value}
+			case "/", "%":
+				if right.Value == 0 {
+					return newError("division by zero: %s %s %s", left.Inspect(), operator, right.Inspect())
+				}
+				if operator == "%" {
+					return &object.Duration{Value: left.Value % right.Value}
+				}
+				return &object.Float{Value: float64(left.Value) / float64(right.Value)}
+			}
+			if result, ok := compareWithOperator(operator, cmp.Compare(left.Value, right.Value)); ok {
+				return result
+			}
+		case *object.Integer, *object.Float:
+			switch operator {
+			case "*":
+				return &object.Duration{Value: time.Duration(float64(left.Value) * toFloat(right))}
+			case "/":
+				if toFloat(right) == 0 {
+					return newError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+				}
+				return &object.Duration{Value: time.Duration(float64(left.Value) / toFloat(right))}
+			}
+		}
+	case *object.Integer, *object.Float:
+		if right, ok := right.(*object.Duration); ok && operator == "*" {
+			return &object.Duration{Value: time.Duration(toFloat(left) * float64(right.Value))}
+		}
+	}
+
+	switch {
+	case operator == "==":
+		return FALSE
+	case operator == "!=":
+		return TRUE
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// INFO: LogicalExpressions:
+
+// NOTE: && and || are short-circuiting, so the right operand is only evaluated when the left one doesn't decide the result.
+// Just like in JS, the deciding operand itself is returned (not a boolean), i.e. `null || 5` gives 5
+func evalLogicalExpression(ie *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(ie.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	switch ie.Operator {
+	case "&&":
+		if !isTruthy(left) {
+			return left
+		}
+	case "||":
+		if isTruthy(left) {
+			return left
+		}
+	default:
+		return newError("unknown operator: %s %s", left.Type(), ie.Operator)
+	}
+
+	return Eval(ie.Right, env)
+}
+
 // INFO: IfExpressions:
 
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
@@ -296,7 +547,15 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 
 // INFO: Identifiers:
 
+// NOTE: a resolved identifier is looked up in its slot - when that's empty the name wasn't bound where the resolver
+// expected it (yet), so it's looked up by name like an unresolved one, which gives the same result as without the resolver
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if node.Resolved {
+		if value := env.GetSlot(node.Depth, node.Slot); value != nil {
+			return value
+		}
+	}
+
 	if value, ok := env.Get(node.Value); ok {
 		return value
 	}
@@ -305,7 +564,37 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return builtin
 	}
 
-	return newError("identifier not found: %s", node.Value)
+	if constant, ok := constants[node.Value]; ok {
+		return constant
+	}
+
+	err := newError("identifier not found: %s", node.Value)
+	err.Token = node.Token
+	if suggestion := suggest.Closest(node.Value, knownNames(env)); suggestion != "" {
+		err.Hint = fmt.Sprintf("did you mean `%s`?", suggestion)
+	}
+	return err
+}
+
+// NOTE: binds the name of a let, a parameter or a pattern - in its slot too, when the resolver gave it one
+func bind(env *object.Environment, name *ast.Identifier, value object.Object) {
+	if name.Resolved {
+		env.SetSlot(name.Slot, name.Value, value)
+		return
+	}
+	env.Set(name.Value, value)
+}
+
+// WARN: Helper method used only in evalIdentifier - everything an identifier can refer to
+func knownNames(env *object.Environment) []string {
+	names := env.Names()
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range constants {
+		names = append(names, name)
+	}
+	return names
 }
 
 // INFO: CallExpressions (not explicitly, but all this is needed for CallExpressions):
@@ -313,11 +602,24 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 // it would call evalExpression, extendFunctionEnv, unwrapReturnValue
 // and contain the applyFunction + the case *ast.CallExpression logic
 
-// NOTE: for evaluating function parameters
+// NOTE: for evaluating function parameters (and array elements) - a spread (...xs) puts all the elements of xs in place
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
 
 	for _, exp := range exps {
+		if spread, ok := exp.(*ast.SpreadExpression); ok {
+			eval := Eval(spread.Value, env)
+			if isError(eval) {
+				return []object.Object{eval}
+			}
+			array, ok := eval.(*object.Array)
+			if !ok {
+				return []object.Object{newError("cannot spread %s, must be ARRAY", eval.Type())}
+			}
+			result = append(result, array.Elements...)
+			continue
+		}
+
 		eval := Eval(exp, env)
 		if isError(eval) {
 			return []object.Object{eval}
@@ -329,29 +631,83 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 }
 
 // NOTE: evaluating the CallExpression by applying the function
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+func applyFunction(fn object.Object, args []object.Object, config *object.Config) object.Object {
 
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
+		extendedEnv, err := extendFunctionEnv(fn, args)
+		if err != nil {
+			return err
+		}
 		evaluated := Eval(fn.Body, extendedEnv)
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
-		return fn.Fn(args...)
+		return fn.Fn(config, args...)
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
 // WARN: Helper method used only in applyFunction - extending the env of a function by it's parameters
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+// NOTE: parameters are patterns, so they get bound the same way as in a let destructuring (defaults included)
+func extendFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, *object.Error) {
+	if err := checkArity(fn, len(args)); err != nil {
+		return nil, err
+	}
+
 	env := object.NewEnclosedEnvironment(fn.Env)
 
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		var arg object.Object
+		if paramIdx < len(args) {
+			arg = args[paramIdx]
+		}
+
+		matched, err := matchPattern(param, arg, env)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			if arg == nil {
+				return nil, newError("missing argument for parameter %s", param.String())
+			}
+			return nil, newError("cannot destructure %s into parameter %s", arg.Inspect(), param.String())
+		}
+	}
+
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		bind(env, fn.Rest, &object.Array{Elements: rest})
+	}
+
+	return env, nil
+}
+
+// WARN: Helper method used only in extendFunctionEnv - parameters with a default are optional, a rest parameter takes any number of extra arguments
+func checkArity(fn *object.Function, got int) *object.Error {
+	required := 0
+	for _, param := range fn.Parameters {
+		if _, ok := param.(*ast.DefaultPattern); !ok {
+			required++
+		}
 	}
+	allowed := len(fn.Parameters)
 
-	return env
+	switch {
+	case fn.Rest != nil && got < required:
+		return newError("wrong number of arguments. got=%d, want>=%d", got, required)
+	case fn.Rest != nil:
+		return nil
+	case required == allowed && got != required:
+		return newError("wrong number of arguments. got=%d, want=%d", got, required)
+	case got < required || got > allowed:
+		return newError("wrong number of arguments. got=%d, want=%d..%d", got, required, allowed)
+	default:
+		return nil
+	}
 }
 
 // WARN: Helper method used only in applyFunction - needed because in monkey both the last statement and the return statement can be returned
@@ -366,10 +722,12 @@ func unwrapReturnValue(obj object.Object) object.Object {
 
 // INFO: IndexExpression
 
-func evalIndexExpression(left, index object.Object) object.Object {
+func evalIndexExpression(left, index object.Object, strict bool) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
-		return evalArrayIndexExpression(left, index)
+		return evalArrayIndexExpression(left, index, strict)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index, strict)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
@@ -378,18 +736,46 @@ func evalIndexExpression(left, index object.Object) object.Object {
 }
 
 // WARN: Helper method used only in evalIndexExpression
-func evalArrayIndexExpression(array, index object.Object) object.Object {
+func evalArrayIndexExpression(array, index object.Object, strict bool) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(arrayObject.Elements))
 
-	if idx < 0 || idx > max {
+	if !ok {
+		if strict {
+			return newError("index out of range: %d (length %d)", index.(*object.Integer).Value, len(arrayObject.Elements))
+		}
 		return NULL
 	}
 
 	return arrayObject.Elements[idx]
 }
 
+// WARN: Helper method used only in evalIndexExpression - strings are indexed by runes, not bytes
+func evalStringIndexExpression(str, index object.Object, strict bool) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(runes))
+
+	if !ok {
+		if strict {
+			return newError("index out of range: %d (length %d)", index.(*object.Integer).Value, len(runes))
+		}
+		return NULL
+	}
+
+	return &object.String{Value: string(runes[idx])}
+}
+
+// NOTE: negative indices are counted from the end, so -1 is the last element
+func normalizeIndex(idx int64, length int) (int64, bool) {
+	if idx < 0 {
+		idx += int64(length)
+	}
+	if idx < 0 || idx >= int64(length) {
+		return 0, false
+	}
+	return idx, true
+}
+
 // WARN: Helper method used only in evalIndexExpression
 func evalHashIndexExpression(hash, key object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
@@ -407,6 +793,174 @@ func evalHashIndexExpression(hash, key object.Object) object.Object {
 	return pair.Value
 }
 
+// INFO: SliceExpression
+
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	start, err := evalSliceBound(node.Start, env)
+	if err != nil {
+		return err
+	}
+	end, err := evalSliceBound(node.End, env)
+	if err != nil {
+		return err
+	}
+	step, err := evalSliceBound(node.Step, env)
+	if err != nil {
+		return err
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		indices, err := sliceIndices(len(left.Elements), start, end, step, env.Config().StrictIndexing)
+		if err != nil {
+			return err
+		}
+		elements := make([]object.Object, 0, len(indices))
+		for _, idx := range indices {
+			elements = append(elements, left.Elements[idx])
+		}
+		return &object.Array{Elements: elements}
+	case *object.String:
+		runes := []rune(left.Value)
+		indices, err := sliceIndices(len(runes), start, end, step, env.Config().StrictIndexing)
+		if err != nil {
+			return err
+		}
+		sliced := make([]rune, 0, len(indices))
+		for _, idx := range indices {
+			sliced = append(sliced, runes[idx])
+		}
+		return &object.String{Value: string(sliced)}
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+}
+
+// WARN: Helper method used only in evalSliceExpression - an omitted bound gives nil
+func evalSliceBound(exp ast.Expression, env *object.Environment) (*int64, object.Object) {
+	if exp == nil {
+		return nil, nil
+	}
+
+	bound := Eval(exp, env)
+	if isError(bound) {
+		return nil, bound
+	}
+
+	integer, ok := bound.(*object.Integer)
+	if !ok {
+		return nil, newError("slice indices must be INTEGER, got %s", bound.Type())
+	}
+
+	return &integer.Value, nil
+}
+
+// WARN: Helper method used only in evalSliceExpression - works like Python slices:
+// omitted bounds default to the whole sequence (walked backwards for a negative step),
+// negative bounds count from the end and bounds past either end are clamped (unless strict)
+func sliceIndices(length int, start, end, step *int64, strict bool) ([]int64, *object.Error) {
+	n := int64(length)
+
+	stepValue := int64(1)
+	if step != nil {
+		stepValue = *step
+	}
+	if stepValue == 0 {
+		return nil, newError("slice step cannot be zero")
+	}
+
+	for _, bound := range []*int64{start, end} {
+		if strict && bound != nil && (*bound < -n || *bound > n) {
+			return nil, newError("slice index out of range: %d (length %d)", *bound, length)
+		}
+	}
+
+	normalize := func(bound *int64, fallback int64) int64 {
+		if bound == nil {
+			return fallback
+		}
+		value := *bound
+		if value < 0 {
+			value += n
+		}
+		if stepValue > 0 {
+			return max(0, min(value, n))
+		}
+		return max(-1, min(value, n-1))
+	}
+
+	// NOTE: the loops stop before stepping past stop - i += stepValue could overflow with a huge step
+	indices := []int64{}
+	if stepValue > 0 {
+		for i, stop := normalize(start, 0), normalize(end, n); i < stop; i += stepValue {
+			indices = append(indices, i)
+			if stepValue >= stop-i {
+				break
+			}
+		}
+	} else {
+		for i, stop := normalize(start, n-1), normalize(end, -1); i > stop; i += stepValue {
+			indices = append(indices, i)
+			if stepValue <= stop-i {
+				break
+			}
+		}
+	}
+
+	return indices, nil
+}
+
+// INFO: AssignExpression
+
+// NOTE: arrays and hashes are mutated in place, so every variable pointing at them sees the change
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	target, ok := node.Target.(*ast.IndexExpression)
+	if !ok {
+		return newError("invalid assignment target: %s", node.Target.String())
+	}
+
+	container := Eval(target.Left, env)
+	if isError(container) {
+		return container
+	}
+	index := Eval(target.Index, env)
+	if isError(index) {
+		return index
+	}
+	value := Eval(node.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	switch container := container.(type) {
+	case *object.Array:
+		integer, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		idx, ok := normalizeIndex(integer.Value, len(container.Elements))
+		if !ok {
+			return newError("index out of range: %d (length %d)", integer.Value, len(container.Elements))
+		}
+		container.Elements[idx] = value
+	case *object.Hash:
+		hashableKey, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		container.Pairs[hashableKey.HashKey()] = object.HashPair{Key: index, Value: value}
+	default:
+		return newError("index assignment not supported: %s", container.Type())
+	}
+
+	return value
+}
+
 // INFO: HashLiteral
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
@@ -435,6 +989,145 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 	return hash
 }
 
+// INFO: MatchExpression
+
+// NOTE: arms are tried top to bottom, every arm gets its own env, so bindings of an arm that didn't match don't leak
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(node.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range node.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+		matched, err := matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return Eval(arm.Body, armEnv)
+	}
+
+	return newError("no match arm matched value: %s", subject.Inspect())
+}
+
+// NOTE: binds the names from the pattern in env while matching, so it should be a fresh env.
+// A nil value means "missing" (array too short, no such key, no argument) - only a DefaultPattern matches it.
+// The error is only set when evaluating a default fails
+func matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	if defaultPattern, ok := pattern.(*ast.DefaultPattern); ok {
+		if value == nil {
+			value = Eval(defaultPattern.Default, env)
+			if err, ok := value.(*object.Error); ok {
+				return false, err
+			}
+		}
+		return matchPattern(defaultPattern.Pattern, value, env)
+	}
+	if value == nil {
+		return false, nil
+	}
+
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.IdentifierPattern:
+		bind(env, pattern.Name, value)
+		return true, nil
+	case *ast.LiteralPattern:
+		literal := Eval(pattern.Value, env)
+		return !isError(literal) && objectsEqual(literal, value), nil
+	case *ast.ArrayPattern:
+		return matchArrayPattern(pattern, value, env)
+	case *ast.HashPattern:
+		return matchHashPattern(pattern, value, env)
+	default:
+		return false, nil
+	}
+}
+
+// WARN: Helper method used only in matchPattern
+func matchArrayPattern(pattern *ast.ArrayPattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	array, ok := value.(*object.Array)
+	if !ok {
+		return false, nil
+	}
+	if pattern.Rest == nil && len(array.Elements) > len(pattern.Elements) {
+		return false, nil
+	}
+
+	for i, element := range pattern.Elements {
+		var elementValue object.Object
+		if i < len(array.Elements) {
+			elementValue = array.Elements[i]
+		}
+		if ok, err := matchPattern(element, elementValue, env); !ok || err != nil {
+			return false, err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := []object.Object{}
+		if len(array.Elements) > len(pattern.Elements) {
+			rest = append(rest, array.Elements[len(pattern.Elements):]...)
+		}
+		bind(env, pattern.Rest, &object.Array{Elements: rest})
+	}
+
+	return true, nil
+}
+
+// WARN: Helper method used only in matchPattern
+func matchHashPattern(pattern *ast.HashPattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	hash, ok := value.(*object.Hash)
+	if !ok {
+		return false, nil
+	}
+
+	matchedKeys := map[object.HashKey]bool{}
+	for _, pair := range pattern.Pairs {
+		key, ok := Eval(pair.Key, env).(object.Hashable)
+		if !ok {
+			return false, nil
+		}
+		hashKey := key.HashKey()
+		matchedKeys[hashKey] = true
+
+		var pairValue object.Object
+		if hashPair, ok := hash.Pairs[hashKey]; ok {
+			pairValue = hashPair.Value
+		}
+		if ok, err := matchPattern(pair.Value, pairValue, env); !ok || err != nil {
+			return false, err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+		for hashKey, hashPair := range hash.Pairs {
+			if !matchedKeys[hashKey] {
+				rest.Pairs[hashKey] = hashPair
+			}
+		}
+		bind(env, pattern.Rest, rest)
+	}
+
+	return true, nil
+}
+
 // INFO: ==================================== Helper methods ====================================
 
 func nativeBoolToBooleanObject(input bool) *object.Boolean {
@@ -457,6 +1150,94 @@ func isTruthy(obj object.Object) bool {
 	}
 }
 
+// NOTE: value equality for numbers, strings, booleans and null (1 == 1.0 like in evalInfixExpression),
+// arrays and hashes are compared element by element, everything else by identity
+func objectsEqual(left, right object.Object) bool {
+	switch {
+	case isNumber(left) && isNumber(right):
+		if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
+			return left.(*object.Integer).Value == right.(*object.Integer).Value
+		}
+		return toFloat(left) == toFloat(right)
+	case left.Type() != right.Type():
+		return false
+	}
+
+	switch left := left.(type) {
+	case *object.String:
+		return left.Value == right.(*object.String).Value
+	case *object.Boolean:
+		return left.Value == right.(*object.Boolean).Value
+	case *object.Null:
+		return true
+	case *object.Array:
+		rightArray := right.(*object.Array)
+		if len(left.Elements) != len(rightArray.Elements) {
+			return false
+		}
+		for i := range left.Elements {
+			if !objectsEqual(left.Elements[i], rightArray.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *object.Hash:
+		rightHash := right.(*object.Hash)
+		if len(left.Pairs) != len(rightHash.Pairs) {
+			return false
+		}
+		for key, pair := range left.Pairs {
+			rightPair, ok := rightHash.Pairs[key]
+			if !ok || !objectsEqual(pair.Value, rightPair.Value) {
+				return false
+			}
+		}
+		return true
+	default:
+		return left == right
+	}
+}
+
+func isTimeOrDuration(obj object.Object) bool {
+	return obj.Type() == object.TIME_OBJ || obj.Type() == object.DURATION_OBJ
+}
+
+// NOTE: result is the outcome of a cmp.Compare-like function - ok is false when operator isn't a comparison
+func compareWithOperator(operator string, result int) (object.Object, bool) {
+	switch operator {
+	case "<":
+		return nativeBoolToBooleanObject(result < 0), true
+	case ">":
+		return nativeBoolToBooleanObject(result > 0), true
+	case "<=":
+		return nativeBoolToBooleanObject(result <= 0), true
+	case ">=":
+		return nativeBoolToBooleanObject(result >= 0), true
+	case "==":
+		return nativeBoolToBooleanObject(result == 0), true
+	case "!=":
+		return nativeBoolToBooleanObject(result != 0), true
+	default:
+		return nil, false
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+// NOTE: only call it after checking isNumber
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
 func newError(format string, a ...any) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
@@ -1,10 +1,18 @@
 package evaluator
 
 import (
+	"bytes"
+	"mfiorek/waiig/ast"
 	"mfiorek/waiig/lexer"
 	"mfiorek/waiig/object"
 	"mfiorek/waiig/parser"
+	"mfiorek/waiig/resolver"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 // INFO: ==================================== Tests ====================================
@@ -37,6 +45,56 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestEvalExtendedIntegerOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"10 % 3", 1},
+		{"-7 % 3", -1},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 2", 4},
+		{"5 ** 0", 1},
+		{"6 & 3", 2},
+		{"6 | 3", 7},
+		{"6 ^ 3", 5},
+		{"~5", -6},
+		{"1 << 4", 16},
+		{"256 >> 4", 16},
+		{"1 + 2 << 1", 6},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5", 1.5},
+		{"-1.5", -1.5},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"0.5 * 4", 2.0},
+		{"7 / 2.0", 3.5},
+		{"5.5 % 2", 1.5},
+		{"2.0 ** 3", 8.0},
+		{"2 ** -1", 0.5},
+		{"4 ** 0.5", 2.0},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testFloatObject(t, evaluated, tt.expected)
+	}
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -61,6 +119,19 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"1.5 < 2", true},
+		{"2 >= 2.0", true},
+		{"1 == 1.0", true},
+		{"0.1 + 0.2 != 0.3", true},
+		{`"a" < "b"`, true},
+		{`"b" <= "a"`, false},
+		{`"abc" >= "abc"`, true},
+		{`"b" > "abc"`, true},
 	}
 
 	for _, tt := range tests {
@@ -88,6 +159,42 @@ func TestBangOperator(t *testing.T) {
 	}
 }
 
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false && true", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		{"5 && 10", 10},
+		{"5 || 10", 5},
+		{"false || 10", 10},
+		{"if (false) { 1 } || 7", 7},
+		{"if (false) { 1 } && 7", nil},
+		// the right operand must not be evaluated when the left one decides
+		{"false && foobar", false},
+		{"true || foobar", true},
+		{"let f = fn() { 1 + true }; false && f()", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
 func TestIfElseExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -184,6 +291,38 @@ if (10 > 1) {
 			"foobar",
 			"identifier not found: foobar",
 		},
+		{
+			"true && foobar",
+			"identifier not found: foobar",
+		},
+		{
+			"5 / 0",
+			"division by zero: 5 / 0",
+		},
+		{
+			"5 % 0",
+			"division by zero: 5 % 0",
+		},
+		{
+			"1.5 / 0",
+			"division by zero: 1.5 / 0",
+		},
+		{
+			"1 << -1",
+			"negative shift count: -1",
+		},
+		{
+			"1.5 & 1",
+			"unknown operator: FLOAT & INTEGER",
+		},
+		{
+			"~1.5",
+			"unknown operator: ~FLOAT",
+		},
+		{
+			`"a" % "b"`,
+			"unknown operator: STRING % STRING",
+		},
 		{
 			`"Hello" - "World"`,
 			"unknown operator: STRING - STRING",
@@ -192,6 +331,43 @@ if (10 > 1) {
 			`{"name": "Monkey"}[fn(x) { x }];`,
 			"unusable as hash key: FUNCTION",
 		},
+		{
+			"[1, 2, 3][::0]",
+			"slice step cannot be zero",
+		},
+		{
+			`[1, 2, 3]["a":]`,
+			"slice indices must be INTEGER, got STRING",
+		},
+		{
+			`{"a": 1}[1:2]`,
+			"slice operator not supported: HASH",
+		},
+		{
+			"[1, 2][2] = 3",
+			"index out of range: 2 (length 2)",
+		},
+		{
+			`[1, 2]["a"] = 3`,
+			"array index must be INTEGER, got STRING",
+		},
+		{
+			`{}[[1]] = 3`,
+			"unusable as hash key: ARRAY",
+		},
+		{
+			`"abc"[0] = "x"`,
+			"index assignment not supported: STRING",
+		},
+		// NOTE: the placeholders of a program with parser errors
+		{
+			"let = 5; 1",
+			`cannot evaluate code with syntax errors (at "let")`,
+		},
+		{
+			"let x = 1 + ;",
+			`cannot evaluate code with syntax errors (at ";")`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -211,6 +387,39 @@ if (10 > 1) {
 	}
 }
 
+// NOTE: an unknown identifier knows where it is, and what it might have been meant to be
+func TestIdentifierNotFound(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedLine   int
+		expectedColumn int
+		expectedHint   string
+	}{
+		{"lenn([1, 2])", 1, 1, "did you mean `len`?"},
+		{"let counter = 1;\ncountr + 1", 2, 1, "did you mean `counter`?"},
+		{"let f = fn(value) { valeu * 2 }; f(1)", 1, 21, "did you mean `value`?"},
+		{"PO", 1, 1, "did you mean `PI`?"},
+		{"completely_unknown", 1, 1, ""},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Token.Line != tt.expectedLine || errObj.Token.Column != tt.expectedColumn {
+			t.Errorf("wrong position for %q. expected=%d:%d, got=%d:%d",
+				tt.input, tt.expectedLine, tt.expectedColumn, errObj.Token.Line, errObj.Token.Column)
+		}
+		if errObj.Hint != tt.expectedHint {
+			t.Errorf("wrong hint for %q. expected=%q, got=%q", tt.input, tt.expectedHint, errObj.Hint)
+		}
+	}
+}
+
 func TestLetStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -227,6 +436,68 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+// NOTE: the identifiers testEval resolved are looked up by (depth, slot) - these are the cases where the slot and the
+// name have to agree, or where the slot isn't set yet and the lookup falls back to the name
+func TestResolvedIdentifiers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = 1; let a = a + 1; a", "2"},
+		{"let a = 1; let f = fn(a) { a * 10 }; f(2) + a", "21"},
+		{"let a = 1; if (true) { let a = 5; }; a", "5"},
+		{"let a = 1; if (false) { let a = 5; }; a", "1"},
+		{"let f = fn() { g() }; let g = fn() { 3 }; f()", "3"},
+		{"let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(10)", "55"},
+		{"let len = fn(x) { 42 }; len([1])", "42"},
+		{"let x = 2; match (5) { x => x * 10 } + x", "52"},
+		{"let f = fn() { x }; if (false) { let x = 1; }; f()", "ERROR: identifier not found: x"},
+		{"let [a, b] = [1, 2]; let {\"c\": c, ...rest} = {\"c\": 3, \"d\": 4}; [a, b, c, rest]", "[1, 2, 3, {d:4}]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = [1, 2]; a + b", "3"},
+		{"let [a, [b, c]] = [1, [2, 3]]; a + b + c", "6"},
+		{"let [a, ...rest] = [1, 2, 3]; rest", "[2, 3]"},
+		{"let [a, _, c] = [1, 2, 3]; a + c", "4"},
+		{"let [a, b = 10] = [1]; a + b", "11"},
+		{"let [a, b = a * 2] = [4]; b", "8"},
+		{`let {"x": x, "y": y} = {"x": 1, "y": 2, "z": 3}; x + y`, "3"},
+		{`let {"x": x, "y": y = 5} = {"x": 1}; x + y`, "6"},
+		{`let {"a": a, ...others} = {"a": 1, "b": 2}; others`, "{b:2}"},
+		{`let {"p": [a, b]} = {"p": [1, 2]}; a * b`, "2"},
+		{"let [a, b] = [1]; a", "ERROR: cannot destructure [1] into [a, b]"},
+		{"let [a] = [1, 2]; a", "ERROR: cannot destructure [1, 2] into [a]"},
+		{"let [a] = 5; a", "ERROR: cannot destructure 5 into [a]"},
+		{`let {"x": x} = {}; x`, "ERROR: cannot destructure {} into {x:x}"},
+		{"let [a = foo] = []; a", "ERROR: identifier not found: foo"},
+		{"let f = fn([a, b]) { a + b }; f([1, 2])", "3"},
+		{`let f = fn({"name": name}, greeting) { greeting + name }; f({"name": "ann"}, "hi ")`, "hi ann"},
+		{"let f = fn([a, ...rest]) { rest }; f([1, 2, 3])", "[2, 3]"},
+		{"let f = fn([a, b] = [1, 2]) { a + b }; f()", "3"},
+		{"let f = fn([a, b]) { a + b }; f(1)", "ERROR: cannot destructure 1 into parameter [a, b]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
 func TestFunctionObject(t *testing.T) {
 	input := "fn(x) { x + 2; };"
 
@@ -263,6 +534,8 @@ func TestFunctionApplication(t *testing.T) {
 		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
 		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
 		{"fn(x) { x; }(5)", 5},
+		{"let add = fn(x: int, y: int = 1): int { x + y; }; add(5, 5);", 10},
+		{`let x: string = 5; x;`, 5},
 	}
 
 	for _, tt := range tests {
@@ -270,6 +543,68 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestFunctionArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fn(x, y = 10) { x + y }; f(1)", "11"},
+		{"let f = fn(x, y = 10) { x + y }; f(1, 2)", "3"},
+		{"let f = fn(x, y = x + 1) { x + y }; f(1)", "3"},
+		{"let f = fn(first, ...rest) { rest }; f(1, 2, 3)", "[2, 3]"},
+		{"let f = fn(first, ...rest) { rest }; f(1)", "[]"},
+		{"let f = fn(...args) { len(args) }; f()", "0"},
+		{"let f = fn(a = 1, ...rest) { [a, rest] }; f()", "[1, []]"},
+		{"let add = fn(x, y) { x + y }; add(...[1, 2])", "3"},
+		{"let add = fn(x, y, z) { x + y + z }; add(1, ...[2, 3])", "6"},
+		{"let f = fn(...args) { args }; f(...[1, 2], 3, ...[])", "[1, 2, 3]"},
+		{"let xs = [2, 3]; [1, ...xs, 4]", "[1, 2, 3, 4]"},
+		{"[...[], ...[1]]", "[1]"},
+		{"len(...[[1, 2]])", "2"},
+		{"let f = fn(x, y) { x }; f(1)", "ERROR: wrong number of arguments. got=1, want=2"},
+		{"let f = fn(x) { x }; f(1, 2)", "ERROR: wrong number of arguments. got=2, want=1"},
+		{"let f = fn() { 1 }; f(1)", "ERROR: wrong number of arguments. got=1, want=0"},
+		{"let f = fn(x, y = 1) { x }; f()", "ERROR: wrong number of arguments. got=0, want=1..2"},
+		{"let f = fn(x, y = 1) { x }; f(1, 2, 3)", "ERROR: wrong number of arguments. got=3, want=1..2"},
+		{"let f = fn(x, ...rest) { x }; f()", "ERROR: wrong number of arguments. got=0, want>=1"},
+		{"let f = fn(x = 1, y) { y }; f(5)", "ERROR: missing argument for parameter y"},
+		{"let f = fn(x) { x }; f(...5)", "ERROR: cannot spread INTEGER, must be ARRAY"},
+		{"[1, ...foo]", "ERROR: identifier not found: foo"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestPipelines(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let double = fn(x) { x * 2 }; 5 |> double", "10"},
+		{"let add = fn(x, y) { x + y }; 5 |> add(1)", "6"},
+		{"let sub = fn(x, y) { x - y }; 5 |> sub(1) |> sub(2)", "2"},
+		{`"abc" |> len`, "3"},
+		{"[1, 2] |> push(3) |> len()", "3"},
+		{"let add = fn(x) { fn(y) { x + y } }; 1 |> add(2)()", "3"},
+		{"let add = fn(x) { fn(y) { x + y } }; 1 |> (2 |> add)", "3"},
+		{"let add = fn(x) { fn(y) { x + y } }; 1 |> (add(2))", "3"},
+		{"[1, 2, 3] |> len == 3 && 1 |> fn(x) { x > 0 }", "true"},
+		{"1 |> 2", "ERROR: not a function: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 let newAdder = fn(x) {
@@ -318,6 +653,7 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
+		{`len("héllo")`, 5},
 		{`len(1)`, "argument to `len` not supported, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
 	}
@@ -343,6 +679,489 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+func TestCollectionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"map([1, 2, 3], fn(x) { x * 2 })", "[2, 4, 6]"},
+		{"map([], fn(x) { x * 2 })", "[]"},
+		{`map(["a", "bc"], len)`, "[1, 2]"},
+		{"filter([1, 2, 3, 4], fn(x) { x % 2 == 0 })", "[2, 4]"},
+		{"reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)", "16"},
+		{"reduce([1, 2, 3], fn(acc, x) { acc * x })", "6"},
+		{"reduce([], fn(acc, x) { acc + x }, 0)", "0"},
+		{"let seen = []; each([1, 2], fn(x) { append(seen, x) }); seen", "[1, 2]"},
+		{"find([1, 5, 10], fn(x) { x > 3 })", "5"},
+		{"find([1, 2], fn(x) { x > 3 })", "null"},
+		{"any([1, 5], fn(x) { x > 3 })", "true"},
+		{"any([], fn(x) { true })", "false"},
+		{"all([4, 5], fn(x) { x > 3 })", "true"},
+		{"all([1, 5], fn(x) { x > 3 })", "false"},
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{"sort([2.5, 1, 2])", "[1, 2, 2.5]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{"sort([1, 3, 2], fn(a, b) { b - a })", "[3, 2, 1]"},
+		{`sort([[2, "b"], [1, "a"], [2, "a"]], fn(a, b) { a[0] - b[0] })`, "[[1, a], [2, b], [2, a]]"},
+		{"let a = [2, 1]; sort(a); a", "[2, 1]"},
+		{"reverse([1, 2, 3])", "[3, 2, 1]"},
+		{`reverse("héllo")`, "olléh"},
+		{"zip([1, 2, 3], [4, 5])", "[[1, 4], [2, 5]]"},
+		{`zip([1], ["a"], [true])`, "[[1, a, true]]"},
+		{"flatten([1, [2, [3, [4]]]])", "[1, 2, [3, [4]]]"},
+		{"flatten([1, [2, [3, [4]]]], 2)", "[1, 2, 3, [4]]"},
+		{"let a = [1]; flatten([a, a, [a]], 2)", "[1, 1, 1]"},
+		{"range(4)", "[0, 1, 2, 3]"},
+		{"range(2, 5)", "[2, 3, 4]"},
+		{"range(10, 0, -3)", "[10, 7, 4, 1]"},
+		{"range(0)", "[]"},
+		{"range(9223372036854775800, 9223372036854775807, 5)", "[9223372036854775800, 9223372036854775805]"},
+		{"range(-1, MIN_INT, MIN_INT)", "[-1]"},
+		{"range(5, 0)", "[]"},
+		{"sum([1, 2, 3])", "6"},
+		{"sum([1, 2.5])", "3.5"},
+		{"sum([])", "0"},
+		{"min([3, 1, 2])", "1"},
+		{"max([3, 1, 2])", "3"},
+		{"min(3, 1.5)", "1.5"},
+		{`max("a", "c", "b")`, "c"},
+		{"min([])", "null"},
+		{`keys({"b": 2, "a": 1})`, "[a, b]"},
+		{`values({"b": 2, "a": 1})`, "[1, 2]"},
+		{`entries({2: "b", 1: "a"})`, "[[1, a], [2, b]]"},
+		{"range(1, 11) |> filter(fn(x) { x % 2 == 0 }) |> map(fn(x) { x * x }) |> sum", "220"},
+		{"map([1], fn(x) { x + true })", "ERROR: type mismatch: INTEGER + BOOLEAN"},
+		{"map(1, fn(x) { x })", "ERROR: first argument to `map` must be ARRAY, got INTEGER"},
+		{"filter([1], 1)", "ERROR: second argument to `filter` must be FUNCTION, got INTEGER"},
+		{"map([1], fn(x, y) { x })", "ERROR: wrong number of arguments. got=1, want=2"},
+		{"reduce([], fn(acc, x) { acc })", "ERROR: reduce of empty ARRAY with no initial value"},
+		{`sort([1, "a"])`, "ERROR: cannot compare STRING and INTEGER"},
+		{"sort([1, 2], fn(a, b) { true })", "ERROR: comparator passed to `sort` must return INTEGER, got BOOLEAN"},
+		{"range(1, 2, 0)", "ERROR: `range` step cannot be zero"},
+		{"let a = [1]; a[0] = a; flatten(a, 100000000)", "ERROR: cannot flatten a cyclic array"},
+		{"let a = [1]; let b = [a]; a[0] = b; flatten([b], 3)", "ERROR: cannot flatten a cyclic array"},
+		{"range(0, 9223372036854775807)", "ERROR: `range` would have 9223372036854775807 elements, the most it can have is 16777216"},
+		{"range(9223372036854775807, -9223372036854775807, -1)", "ERROR: `range` would have 18446744073709551614 elements, the most it can have is 16777216"},
+		{`sum([1, "a"])`, "ERROR: `sum` can only add INTEGER and FLOAT, got STRING"},
+		{"keys([1])", "ERROR: argument to `keys` must be HASH, got ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`split("a,b,,c", ",")`, "[a, b, , c]"},
+		{`split("  a b   c ")`, "[a, b, c]"},
+		{`split("héj", "")`, "[h, é, j]"},
+		{`join(["a", "b"], ", ")`, "a, b"},
+		{`join([1, true, "x"])`, "1truex"},
+		{`trim("  hi  ")`, "hi"},
+		{`trim("xxhixx", "x")`, "hi"},
+		{`trim_left("  hi  ")`, "hi  "},
+		{`trim_right("  hi  ")`, "  hi"},
+		{`trim_right("hi!!", "!")`, "hi"},
+		{`upper("żółw")`, "ŻÓŁW"},
+		{`lower("ÀB")`, "àb"},
+		{`contains("monkey", "key")`, "true"},
+		{`contains("monkey", "x")`, "false"},
+		{`starts_with("monkey", "mon")`, "true"},
+		{`ends_with("monkey", "mon")`, "false"},
+		{`index_of("héllo", "l")`, "2"},
+		{`index_of("héllo", "x")`, "-1"},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`replace("a-b-c", "-", "+", 1)`, "a+b-c"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("", 9223372036854775807)`, ""},
+		{`pad_left("7", 3, "0")`, "007"},
+		{`pad_right("é", 3)`, "é  "},
+		{`pad_left("long", 2)`, "long"},
+		{`chars("añb")`, "[a, ñ, b]"},
+		{`format("%s is %d", "x", 5)`, "x is 5"},
+		{`format("%.2f|%5s|%-3d|%05d", 3.14159, "ab", 7, 42)`, "3.14|   ab|7  |00042"},
+		{`sprintf("%v %v %t %q %%", [1, 2], 1.5, true, "q")`, `[1, 2] 1.5 true "q" %`},
+		{`format("%x", 255)`, "ff"},
+		{`format("%f", 1)`, "1.000000"},
+		{`parse_int("42")`, "42"},
+		{`parse_int(" -7 ")`, "-7"},
+		{`parse_int("ff", 16)`, "255"},
+		{`parse_int("0x10")`, "16"},
+		{`parse_float("2.5")`, "2.5"},
+		{`to_string(12)`, "12"},
+		{`to_string([1, "a"])`, "[1, a]"},
+		{`to_string("s")`, "s"},
+		{`upper(1)`, "ERROR: argument to `upper` must be STRING, got INTEGER"},
+		{`contains("a")`, "ERROR: wrong number of arguments. got=1, want=2"},
+		{`contains("a", 1)`, "ERROR: second argument to `contains` must be STRING, got INTEGER"},
+		{`repeat("a", -1)`, "ERROR: negative count passed to `repeat`: -1"},
+		{`pad_left("a", 3, "ab")`, "ERROR: third argument to `pad_left` must be a single character, got \"ab\""},
+		{`format("%d", "a")`, "ERROR: format: %d expects INTEGER, got STRING"},
+		{`format("%s %s", "a")`, "ERROR: format: missing argument for %s"},
+		{`format("%s", "a", "b")`, "ERROR: format: too many arguments. got=2, used=1"},
+		{`format("%y", 1)`, "ERROR: format: unknown verb %y"},
+		{`repeat("ab", 9223372036854775807)`, "ERROR: `repeat` would make a string of more than 268435456 bytes"},
+		{`pad_left("a", 9223372036854775807, " ")`, "ERROR: `pad_left` would make a string of more than 268435456 bytes"},
+		{`pad_right("a", 300000000)`, "ERROR: `pad_right` would make a string of more than 268435456 bytes"},
+		{`format("%99999999d", 1)`, "ERROR: format: width or precision of %99999999d is above 1000000"},
+		{`format("%.99999999f", 1.5)`, "ERROR: format: width or precision of %.99999999f is above 1000000"},
+		{`parse_int("abc")`, "ERROR: could not parse \"abc\" as integer"},
+		{`parse_float("1.2.3")`, "ERROR: could not parse \"1.2.3\" as float"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestRegexBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`regex("a+b")`, "/a+b/"},
+		{`let digits = regex("\d+"); [regex_match(digits, "1"), regex_match(digits, "a")]`, "[true, false]"},
+		{`regex_match(regex("^\d+$"), "123")`, "true"},
+		{`regex_match(regex("^\d+$"), "12a")`, "false"},
+		{`regex_find(regex("\d+"), "ab 12 cd 345")`, "12"},
+		{`regex_find(regex("\d+"), "abc")`, "null"},
+		{`regex_find_all(regex("\d+"), "ab 12 cd 345 e6")`, "[12, 345, 6]"},
+		{`regex_find_all(regex("\d+"), "ab 12 cd 345 e6", 2)`, "[12, 345]"},
+		{`regex_find_all(regex("\d+"), "abc")`, "[]"},
+		{`regex_captures(regex("(\w+)@(\w+)(\.com)?"), "mail: joe@example")`, "[joe@example, joe, example, null]"},
+		{`regex_captures(regex("(\d)"), "abc")`, "null"},
+		{`regex_captures_all(regex("(\w)=(\d)"), "a=1, b=2")`, "[[a=1, a, 1], [b=2, b, 2]]"},
+		{`regex_named_captures(regex("(?P<key>\w+)=(?P<value>\w+)"), "x: a=b")`, "{key:a, value:b}"},
+		{`regex_named_captures(regex("(?P<key>\w+)="), "nothing")`, "null"},
+		{`regex_replace(regex("(\w+)@(\w+)"), "joe@example", "$2 at ${1}")`, "example at joe"},
+		{`regex_replace(regex("\d+"), "a1b22c333", fn(m) { to_string(len(m[0])) })`, "a1b2c3"},
+		{`regex_replace(regex("(\w)(\w)"), "abcd", fn(m) { m[2] + m[1] })`, "badc"},
+		{`regex_replace(regex("x"), "abc", fn(m) { 1 })`, "abc"},
+		{`regex_split(regex("\s*,\s*"), "a , b,c ,d")`, "[a, b, c, d]"},
+		{`regex_split(regex(","), "a,b,c", 2)`, "[a, b,c]"},
+		{`regex("(")`, "ERROR: regex: invalid pattern: error parsing regexp: missing closing ): `(`"},
+		{`regex(1)`, "ERROR: argument to `regex` must be STRING, got INTEGER"},
+		{`regex_match("a", "a")`, "ERROR: first argument to `regex_match` must be REGEX, got STRING"},
+		{`regex_match(regex("a"), 1)`, "ERROR: second argument to `regex_match` must be STRING, got INTEGER"},
+		{`regex_find_all(regex("a"), "a", "1")`, "ERROR: third argument to `regex_find_all` must be INTEGER, got STRING"},
+		{`regex_replace(regex("a"), "abc", 1)`, "ERROR: third argument to `regex_replace` must be STRING or FUNCTION, got INTEGER"},
+		{`regex_replace(regex("a"), "abc", fn(m) { 1 })`, "ERROR: regex_replace: callback must return STRING, got INTEGER"},
+		{`regex_replace(regex("a"), "abc")`, "ERROR: wrong number of arguments. got=2, want=3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestJSONBuiltins(t *testing.T) {
+	tests := []struct {
+		json     string // available as `input` in the script, since monkey strings have no escapes
+		input    string
+		expected string
+	}{
+		{"1", "json_parse(input)", "1"},
+		{"-2.5", "json_parse(input)", "-2.5"},
+		{"1e3", "json_parse(input)", "1000.0"},
+		{"99999999999999999999", "json_parse(input)", "1e+20"},
+		{"true", "json_parse(input)", "true"},
+		{"null", "json_parse(input)", "null"},
+		{`[1, "a", [false]]`, "json_parse(input)", "[1, a, [false]]"},
+		{`{"b": {"c": 1}, "a": []}`, `json_parse(input)["b"]["c"]`, "1"},
+		{`{"b": 1, "a": 2}`, "json_parse(input)", "{a:2, b:1}"},
+		{`"a\"<b>"`, "json_parse(input)", `a"<b>`},
+		{`"a\"<b>"`, "json_stringify(json_parse(input))", `"a\"<b>"`},
+		{"", "json_stringify(1)", "1"},
+		{"", "json_stringify(2.0)", "2.0"},
+		{"", `json_stringify([1, "a", true, json_parse("null")])`, `[1,"a",true,null]`},
+		{"", `json_stringify({"b": 1, "a": [2], 3: "x", true: 1})`, `{"3":"x","a":[2],"b":1,"true":1}`},
+		{"", `json_stringify({"a": [1, 2]}, 2)`, "{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{"", `json_stringify({"a": 1}, "--")`, "{\n--\"a\": 1\n}"},
+		{`{"x": [1, 2.5, "s", {"y": false}, null]}`, "json_stringify(json_parse(input))", `{"x":[1,2.5,"s",{"y":false},null]}`},
+		{"{", "json_parse(input)", "ERROR: json_parse: invalid JSON: unexpected EOF"},
+		{"1 2", "json_parse(input)", "ERROR: json_parse: invalid JSON: unexpected data after the top-level value"},
+		{"", "json_parse(1)", "ERROR: argument to `json_parse` must be STRING, got INTEGER"},
+		{"", "json_stringify(fn(x) { x })", "ERROR: json_stringify: cannot serialize FUNCTION"},
+		{"", `json_stringify({"f": len})`, "ERROR: json_stringify: cannot serialize BUILTIN"},
+		{"", `json_stringify({1: 1, "1": 2})`, "ERROR: json_stringify: duplicate object key \"1\""},
+		{"", "let a = [1]; a[0] = a; json_stringify(a)", "ERROR: json_stringify: cannot serialize cyclic value"},
+		{"", `let h = {}; h["me"] = [h]; json_stringify(h)`, "ERROR: json_stringify: cannot serialize cyclic value"},
+		{"", `let a = [1]; json_stringify({"x": a, "y": [a, a]})`, `{"x":[1],"y":[[1],[1]]}`},
+		{"", "json_stringify([1], 9223372036854775807)", "ERROR: json_stringify: indent above 10: 9223372036854775807"},
+		{"", "json_stringify([1], 10)", "[\n          1\n]"},
+		{"", "json_stringify(1, [])", "ERROR: second argument to `json_stringify` must be INTEGER or STRING, got ARRAY"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("input", &object.String{Value: tt.json})
+
+		evaluated := testEvalWithEnv(tt.input, env)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestIOBuiltins(t *testing.T) {
+	tests := []struct {
+		input          string
+		stdin          string
+		expected       string
+		expectedStdout string
+		expectedStderr string
+	}{
+		{`puts("a", 1, [2])`, "", "null", "a\n1\n[2]\n", ""},
+		{`print("a", 1); print([2])`, "", "null", "a 1[2]", ""},
+		{`print()`, "", "null", "", ""},
+		{`eprint("oops", 2)`, "", "null", "", "oops\n2\n"},
+		{`[read_line(), read_line(), read_line()]`, "first\r\nsecond", "[first, second, null]", "", ""},
+		{`[read_line(), read_all()]`, "first\nsecond\nthird\n", "[first, second\nthird\n]", "", ""},
+		{`read_line()`, "", "null", "", ""},
+		{`read_all()`, "", "", "", ""},
+		{`each(["x", "y"], puts)`, "", "null", "x\ny\n", ""},
+		{`read_line(1)`, "", "ERROR: wrong number of arguments. got=1, want=0", "", ""},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		config := object.NewConfig()
+		config.Stdout = &stdout
+		config.Stderr = &stderr
+		config.Stdin = strings.NewReader(tt.stdin)
+
+		evaluated := testEvalWithEnv(tt.input, object.NewEnvironmentWithConfig(config))
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("wrong stdout for %q. expected=%q, got=%q", tt.input, tt.expectedStdout, stdout.String())
+		}
+		if stderr.String() != tt.expectedStderr {
+			t.Errorf("wrong stderr for %q. expected=%q, got=%q", tt.input, tt.expectedStderr, stderr.String())
+		}
+	}
+}
+
+func TestFSBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dir, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	config := object.NewConfig()
+	config.FSRoot = root
+	env := object.NewEnvironmentWithConfig(config)
+
+	// NOTE: the cases share the root directory, so their order matters
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`exists("a.txt")`, "false"},
+		{`write_file("a.txt", "hello")`, "null"},
+		{`[exists("a.txt"), read_file("a.txt")]`, "[true, hello]"},
+		{`append_file("a.txt", " world"); read_file("a.txt")`, "hello world"},
+		{`write_file("a.txt", "bye"); read_file("./sub/../a.txt")`, "bye"},
+		{`append_file("b.txt", "new"); read_file("b.txt")`, "new"},
+		{`list_dir()`, "[a.txt, b.txt, escape]"},
+		{`remove("b.txt"); [exists("b.txt"), list_dir(".")]`, "[false, [a.txt, escape]]"},
+		{`read_file("missing.txt")`, "ERROR: read_file: no such file or directory"},
+		{`list_dir("missing")`, "ERROR: list_dir: no such file or directory"},
+		{`remove("missing.txt")`, "ERROR: remove: no such file or directory"},
+		{`read_file("../secret.txt")`, "ERROR: read_file: path escapes the root directory: ../secret.txt"},
+		{`exists("sub/../../secret.txt")`, "ERROR: exists: path escapes the root directory: sub/../../secret.txt"},
+		{`write_file("/tmp/x.txt", "x")`, "ERROR: write_file: path escapes the root directory: /tmp/x.txt"},
+		{`read_file("escape/secret.txt")`, "ERROR: read_file: path escapes from parent"},
+		{`read_file(1)`, "ERROR: argument to `read_file` must be STRING, got INTEGER"},
+		{`write_file("a.txt", 1)`, "ERROR: second argument to `write_file` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithEnv(tt.input, env)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+
+	disabled := []struct {
+		config   *object.Config
+		expected string
+	}{
+		{object.NewConfig(), "ERROR: read_file: file system access is disabled"},
+		{&object.Config{FSRoot: root, Sandbox: true}, "ERROR: read_file: file system access is disabled in sandbox mode"},
+	}
+
+	for _, tt := range disabled {
+		evaluated := testEvalWithEnv(`read_file("a.txt")`, object.NewEnvironmentWithConfig(tt.config))
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result. expected=%q, got=%+v", tt.expected, evaluated)
+		}
+	}
+}
+
+func TestTimeBuiltins(t *testing.T) {
+	config := object.NewConfig()
+	config.Clock = func() time.Time { return time.Date(2024, time.March, 10, 12, 30, 0, 0, time.UTC) }
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`now()`, "2024-03-10T12:30:00Z"},
+		{`since(time_date(2024, 3, 10, 12))`, "30m0s"},
+		{`time_parse("2024-01-02T03:04:05+02:00")`, "2024-01-02T03:04:05+02:00"},
+		{`time_parse("2024-01-02 03:04", "2006-01-02 15:04")`, "2024-01-02T03:04:00Z"},
+		{`time_parse("2024-07-01 12:00:00", "DateTime", "Europe/Warsaw")`, "2024-07-01T12:00:00+02:00"},
+		{`time_format(now(), "DateOnly")`, "2024-03-10"},
+		{`time_format(now(), "Mon Jan 2 15:04")`, "Sun Mar 10 12:30"},
+		{`time_format(time_in(now(), "America/New_York"))`, "2024-03-10T08:30:00-04:00"},
+		{`time_date(2024, 2, 30)`, "2024-03-01T00:00:00Z"},
+		{`time_date(2024, 1, 1, 9, 15, 30, "Asia/Tokyo")`, "2024-01-01T09:15:30+09:00"},
+		{`time_unix(time_date(1970, 1, 2))`, "86400"},
+		{`time_from_unix(86400)`, "1970-01-02T00:00:00Z"},
+		{`let p = time_parts(now()); [p["year"], p["month"], p["day"], p["weekday"], p["yearday"], p["zone"]]`, "[2024, 3, 10, Sunday, 70, UTC]"},
+		{`duration("1h30m")`, "1h30m0s"},
+		{`duration_seconds(duration("1m30s"))`, "90.0"},
+		{`now() - time_date(2024, 3, 10)`, "12h30m0s"},
+		{`now() + duration("1h")`, "2024-03-10T13:30:00Z"},
+		{`duration("1h") + now()`, "2024-03-10T13:30:00Z"},
+		{`now() - duration("30m")`, "2024-03-10T12:00:00Z"},
+		{`duration("1h") - duration("15m")`, "45m0s"},
+		{`duration("1m") * 3`, "3m0s"},
+		{`2 * duration("1m")`, "2m0s"},
+		{`duration("1m") * 1.5`, "1m30s"},
+		{`duration("1m") / 4`, "15s"},
+		{`duration("1h") / duration("20m")`, "3.0"},
+		{`duration("70s") % duration("1m")`, "10s"},
+		{`-duration("1s")`, "-1s"},
+		{`now() > time_date(2024, 1, 1)`, "true"},
+		{`now() == time_in(now(), "Asia/Tokyo")`, "true"},
+		{`now() != now() + duration("1ns")`, "true"},
+		{`duration("1h") <= duration("60m")`, "true"},
+		{`duration("1s") == 1`, "false"},
+		{`now() + 1`, "ERROR: type mismatch: TIME + INTEGER"},
+		{`now() + now()`, "ERROR: unknown operator: TIME + TIME"},
+		{`duration("1s") / 0`, "ERROR: division by zero: 1s / 0"},
+		{`duration("1s") / duration("0s")`, "ERROR: division by zero: 1s / 0s"},
+		{`duration("soon")`, "ERROR: duration: time: invalid duration \"soon\""},
+		{`time_parse("yesterday")`, "ERROR: time_parse: parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""},
+		{`time_in(now(), "Mars/Olympus")`, "ERROR: time_in: unknown time zone \"Mars/Olympus\""},
+		{`time_date(2024, "1", 1)`, "ERROR: second argument to `time_date` must be INTEGER, got STRING"},
+		{`time_format("now")`, "ERROR: argument to `time_format` must be TIME, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithEnv(tt.input, object.NewEnvironmentWithConfig(config))
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestMathBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`abs(-5)`, "5"},
+		{`abs(-2.5)`, "2.5"},
+		{`pow(2, 10)`, "1024"},
+		{`pow(2, -1)`, "0.5"},
+		{`sqrt(16)`, "4.0"},
+		{`floor(2.7)`, "2"},
+		{`floor(-2.5)`, "-3"},
+		{`ceil(2.1)`, "3"},
+		{`ceil(5)`, "5"},
+		{`round(2.5)`, "3"},
+		{`round(-2.5)`, "-3"},
+		{`round(3.14159, 2)`, "3.14"},
+		{`sin(0)`, "0.0"},
+		{`cos(PI)`, "-1.0"},
+		{`atan2(1, 1) * 4 == PI`, "true"},
+		{`exp(0)`, "1.0"},
+		{`log(E)`, "1.0"},
+		{`log(8, 2)`, "3.0"},
+		{`log10(1000)`, "3.0"},
+		{`log2(8)`, "3.0"},
+		{`clamp(5, 0, 3)`, "3"},
+		{`clamp(-1.5, 0, 3)`, "0"},
+		{`clamp(1.5, 0, 3)`, "1.5"},
+		{`[MAX_INT, MIN_INT, INF]`, "[9223372036854775807, -9223372036854775808, +Inf]"},
+		{`let PI = 3; PI`, "3"},
+		{`sqrt(-1)`, "ERROR: sqrt: math domain error"},
+		{`log(-1)`, "ERROR: log: math domain error"},
+		{`floor(INF)`, "ERROR: floor: cannot convert +Inf to INTEGER"},
+		{`clamp(1, 3, 0)`, "ERROR: clamp: low bound 3 is greater than high bound 0"},
+		{`sqrt("4")`, "ERROR: argument to `sqrt` must be INTEGER or FLOAT, got STRING"},
+		{`pow(2, "a")`, "ERROR: second argument to `pow` must be INTEGER or FLOAT, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestRandomBuiltins(t *testing.T) {
+	input := `[rand_int(100), rand_int(-5, 5), rand_float(), choice(["a", "b", "c"]), shuffle([1, 2, 3, 4, 5])]`
+
+	// NOTE: the same seed has to give the same values - whether it's set by the host or the script
+	seeded := object.NewConfig()
+	seeded.Seed(42)
+	first := testEvalWithEnv(input, object.NewEnvironmentWithConfig(seeded)).Inspect()
+	second := testEvalWithEnv("rand_seed(42); "+input, object.NewEnvironment()).Inspect()
+	if first != second {
+		t.Errorf("same seed gave different values. first=%s, second=%s", first, second)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`all(map(range(200), fn(_) { rand_int(3) }), fn(x) { x >= 0 && x < 3 })`, "true"},
+		{`all(map(range(200), fn(_) { rand_int(MIN_INT, MAX_INT) }), fn(x) { x < MAX_INT })`, "true"},
+		{`all(map(range(200), fn(_) { rand_float(1, 2) }), fn(x) { x >= 1 && x < 2 })`, "true"},
+		{`len(filter(range(200), fn(_) { choice([1, 2]) == 1 })) > 0`, "true"},
+		{`sort(shuffle(range(10)))`, "[0, 1, 2, 3, 4, 5, 6, 7, 8, 9]"},
+		{`let a = [1, 2, 3]; shuffle(a); a`, "[1, 2, 3]"},
+		{`rand_int(0)`, "ERROR: rand_int: empty range 0..0"},
+		{`rand_int(5, 1)`, "ERROR: rand_int: empty range 5..1"},
+		{`rand_float(1)`, "ERROR: wrong number of arguments. got=1, want=0 or 2"},
+		{`choice([])`, "ERROR: choice: empty array"},
+		{`rand_seed("a")`, "ERROR: argument to `rand_seed` must be INTEGER, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -405,6 +1224,14 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
 			nil,
 		},
 	}
@@ -420,6 +1247,211 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4, 5][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4, 5][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4, 5][3:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][:]", "[1, 2, 3, 4, 5]"},
+		{"[1, 2, 3, 4, 5][-2:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][:-2]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4, 5][::2]", "[1, 3, 5]"},
+		{"[1, 2, 3, 4, 5][::-1]", "[5, 4, 3, 2, 1]"},
+		{"[1, 2, 3, 4, 5][3:0:-1]", "[4, 3, 2]"},
+		{"[1, 2, 3, 4, 5][-1:-4:-2]", "[5, 3]"},
+		{"[1, 2, 3][1:100]", "[2, 3]"},
+		{"[1, 2, 3][-100:1]", "[1]"},
+		{"[1, 2, 3][2:1]", "[]"},
+		{"[1, 2, 3][1::9223372036854775807]", "[2]"},
+		{"[1, 2, 3][1::MIN_INT]", "[2]"},
+		{`"hello"[::9223372036854775807]`, "h"},
+		{`"hello"[1:3]`, "el"},
+		{`"hello"[::-1]`, "olleh"},
+		{`"héllo wörld"[1:5]`, "éllo"},
+		{`"héllo wörld"[-4:]`, "örld"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong slice for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`"hello"[0]`, "h"},
+		{`"hello"[4]`, "o"},
+		{`"hello"[-1]`, "o"},
+		{`"héllo"[1]`, "é"},
+		{`"héllo"[2]`, "l"},
+		{`"hello"[5]`, nil},
+		{`"hello"[-6]`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		expected, ok := tt.expected.(string)
+		if !ok {
+			testNullObject(t, evaluated)
+			continue
+		}
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+		}
+	}
+}
+
+func TestStrictIndexing(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"[1, 2, 3][3]", "index out of range: 3 (length 3)"},
+		{"[1, 2, 3][-4]", "index out of range: -4 (length 3)"},
+		{`"abc"[10]`, "index out of range: 10 (length 3)"},
+		{"[1, 2, 3][0:4]", "slice index out of range: 4 (length 3)"},
+		{"[1, 2, 3][-5:]", "slice index out of range: -5 (length 3)"},
+	}
+
+	for _, tt := range tests {
+		config := object.NewConfig()
+		config.StrictIndexing = true
+		evaluated := testEvalWithEnv(tt.input, object.NewEnvironmentWithConfig(config))
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+
+	config := object.NewConfig()
+	config.StrictIndexing = true
+	testIntegerObject(t, testEvalWithEnv("[1, 2, 3][-1]", object.NewEnvironmentWithConfig(config)), 3)
+}
+
+func TestIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1, 2, 3]; a[0] = 10; a", "[10, 2, 3]"},
+		{"let a = [1, 2, 3]; a[-1] = 10; a", "[1, 2, 10]"},
+		{"let a = [1, 2, 3]; a[1] = 10", "10"},
+		{"let a = [1]; let b = a; b[0] = 2; a", "[2]"},
+		{"let a = [1]; let b = [2]; a[0] = b[0] = 3; a[0] + b[0]", "6"},
+		{`let h = {}; h["k"] = 1; h["k"]`, "1"},
+		{`let h = {"k": 1}; h["k"] = 2; h`, "{k:2}"},
+		{`let h = {}; let set = fn(h) { h[1] = true }; set(h); h[1]`, "true"},
+		{`let m = [[0, 0], [0, 0]]; m[1][0] = 5; m`, "[[0, 0], [5, 0]]"},
+		{"let a = [1]; a[0] = a", "[[...]]"},
+		{`let h = {}; h["me"] = h; h`, "{me:{...}}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestMutatingBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1]; append(a, 2, 3); a", "[1, 2, 3]"},
+		{"let a = [1]; push(a, 2); a", "[1]"},
+		{"let a = [1, 2]; pop(a)", "2"},
+		{"let a = [1, 2]; pop(a); a", "[1]"},
+		{"pop([])", "null"},
+		{"let a = [1, 2]; shift(a)", "1"},
+		{"let a = [1, 2]; shift(a); a", "[2]"},
+		{"let a = [2]; unshift(a, 1); a", "[1, 2]"},
+		{"let a = [1, 3]; insert(a, 1, 2); a", "[1, 2, 3]"},
+		{"let a = [1, 2]; insert(a, 2, 3); a", "[1, 2, 3]"},
+		{"let a = [1, 3]; insert(a, -1, 2); a", "[1, 2, 3]"},
+		{"let a = [1, 2, 3]; delete(a, 1); a", "[1, 3]"},
+		{"let a = [1, 2, 3]; delete(a, -1)", "3"},
+		{"delete([1], 5)", "null"},
+		{`let h = {"a": 1, "b": 2}; delete(h, "a"); h`, "{b:2}"},
+		{`let h = {"a": 1}; delete(h, "a")`, "1"},
+		{`delete({}, "a")`, "null"},
+		{`let h = {"a": 1}; clear(h); h`, "{}"},
+		{"let a = [1, 2]; clear(a); len(a)", "0"},
+		{"let build = fn(n) { let a = []; let i = [0]; let loop = fn() { if (i[0] < n) { append(a, i[0]); i[0] = i[0] + 1; loop() } }; loop(); a }; build(5)", "[0, 1, 2, 3, 4]"},
+		{"append(1, 2)", "ERROR: argument to `append` must be ARRAY, got INTEGER"},
+		{"append([])", "ERROR: wrong number of arguments. got=1, want>=2"},
+		{"insert([1], 5, 2)", "ERROR: index out of range: 5 (length 1)"},
+		{`delete("abc", 1)`, "ERROR: argument to `delete` must be HASH or ARRAY, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (1) { 1 => 10, _ => 20 }", "10"},
+		{"match (2) { 1 => 10, _ => 20 }", "20"},
+		{"match (-1) { -1 => 10, _ => 20 }", "10"},
+		{"match (2.0) { 2 => 10, _ => 20 }", "10"},
+		{`match ("b") { "a" => 1, "b" => 2 }`, "2"},
+		{"match (false) { true => 1, false => 0 }", "0"},
+		{"match (5) { n => n * 2 }", "10"},
+		{"match (5) { n if n > 10 => 1, n if n > 3 => 2, _ => 3 }", "2"},
+		{"match ([]) { [] => 0, [x] => x, _ => -1 }", "0"},
+		{"match ([7]) { [] => 0, [x] => x, _ => -1 }", "7"},
+		{"match ([1, 2]) { [x] => x, _ => -1 }", "-1"},
+		{"match ([1, 2, 3]) { [a, ...rest] => rest }", "[2, 3]"},
+		{"match ([1]) { [a, ...rest] => rest }", "[]"},
+		{"match ([1, [2, 3]]) { [a, [b, c]] => a + b + c }", "6"},
+		{"match ([1, 2]) { [1, x] => x, _ => 0 }", "2"},
+		{"match ([3, 2]) { [1, x] => x, _ => 0 }", "0"},
+		{`match ({"kind": "user", "name": "ann", "age": 3}) { {"kind": "admin"} => 1, {"kind": "user", "name": n} => n }`, "ann"},
+		{`match ({"a": 1}) { {"a": 1, "b": b} => b, {"a": a} => a }`, "1"},
+		{`match ({1: [1, 2]}) { {1: [_, x]} => x }`, "2"},
+		{`match ("x") { {} => 1, [] => 2, _ => 3 }`, "3"},
+		{"let x = 1; match (2) { x => x }; x", "1"},
+		{"match (5) { n if n > 10 => 1 }", "ERROR: no match arm matched value: 5"},
+		{"match (5) { n if foo => 1 }", "ERROR: identifier not found: foo"},
+		{"match (foo) { _ => 1 }", "ERROR: identifier not found: foo"},
+		{"match ([1]) { [a, b = 5] => a + b }", "6"},
+		{`match ({"a": 1, "b": 2}) { {"a": a, ...rest} => rest }`, "{b:2}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `let two = "two";
     {
@@ -506,13 +1538,79 @@ func TestHashIndexExpressions(t *testing.T) {
 	}
 }
 
+// NOTE: a program sent through the JSON encoding must evaluate just like the parsed one
+func TestEvalDecodedAST(t *testing.T) {
+	tests := []string{
+		"let add = fn(a, b = 2, ...rest) { a + b + len(rest) }; add(1) * add(1, 2, 3)",
+		"let fib = fn(n) { if (n < 2) { return n; } else { fib(n - 1) + fib(n - 2) } }; fib(10)",
+		`let h = {"a": 1, "b": [2, 3], true: -4.5}; [h["b"][1], h[true], h["c"]]`,
+		"let xs = [1, 2, 3, 4]; xs[0] = 10; [xs[1:], xs[::2], ...xs]",
+		"[1, 2, 3] |> map(fn(x) { x ** 2 }) |> reduce(fn(a, b) { a + b }, 0)",
+		`let [a, [b, _], ...rest] = [1, [2, 3], 4, 5]; let {"k": k, ...others} = {"k": "v"}; [a, b, rest, k, len(others)]`,
+		`let describe = fn(x) { match (x) { 0 => "zero", [first, ...r] if first > 1 => "big", {"n": n} => n, _ => "other" } };
+		[describe(0), describe([2, 3]), describe({"n": "named"}), describe(!true)]`,
+		"1 / 0",
+		"let scale = fn(xs: [int], by: float = 2.0): [float] { map(xs, fn(x: int): float { x * by }) }; let h: {string: fn(int): int} = {}; scale([1, 2])",
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+
+		encoded, err := ast.EncodeJSON(program)
+		if err != nil {
+			t.Fatalf("EncodeJSON(%q) returned error: %s", input, err)
+		}
+		decoded, err := ast.DecodeJSON(encoded)
+		if err != nil {
+			t.Fatalf("DecodeJSON of %q returned error: %s", input, err)
+		}
+
+		expected := Eval(program, object.NewEnvironment())
+		got := Eval(decoded, object.NewEnvironment())
+		if got.Type() != expected.Type() || got.Inspect() != expected.Inspect() {
+			t.Errorf("decoded %q evaluated differently. expected=%s, got=%s", input, expected.Inspect(), got.Inspect())
+		}
+	}
+}
+
+func TestBuiltinDocs(t *testing.T) {
+	names := BuiltinNames()
+	if len(names) != len(builtins)+len(constants) {
+		t.Fatalf("BuiltinNames() has wrong length. got=%d, want=%d", len(names), len(builtins)+len(constants))
+	}
+
+	for _, name := range names {
+		doc := BuiltinDoc(name)
+		if !strings.HasPrefix(doc, name) {
+			t.Errorf("doc of %s should start with its signature. got=%q", name, doc)
+		}
+	}
+	for name := range builtinDocs {
+		if !slices.Contains(names, name) {
+			t.Errorf("doc of %s documents no builtin", name)
+		}
+	}
+}
+
 // INFO: ==================================== Helper methods ====================================
 
+// NOTE: resolved, so every test goes through the slots of the resolved identifiers too -
+// testEvalWithEnv doesn't resolve, as an environment has to stay with the one resolver that filled its slots
 func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
-	env := object.NewEnvironment()
+	resolver.New(BuiltinNames()).Resolve(program)
+
+	return Eval(program, object.NewEnvironment())
+}
+
+func testEvalWithEnv(input string, env *object.Environment) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
 
 	return Eval(program, env)
 }
@@ -540,6 +1638,21 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g",
+			result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
 func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
 	result, ok := obj.(*object.Boolean)
 	if !ok {
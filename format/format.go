@@ -0,0 +1,693 @@
+package format
+
+import (
+	"fmt"
+	"math"
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/lexer"
+	"mfiorek/waiig/parser"
+	"mfiorek/waiig/token"
+	"sort"
+	"strings"
+)
+
+// INFO: Formatter (monkeyfmt) - prints an AST back as idiomatic Monkey source: two spaces of indentation, one statement
+// per line, parentheses only where the precedences of the parser need them, comments and (single) blank lines kept.
+// NOTE: the layout choices that can't be derived from the AST alone come from the token positions - a block, match
+// or hash literal written on one line stays on one line, everything else gets one element per line.
+// Comments are kept between statements, match arms and the elements of multi-line array and hash literals;
+// a comment anywhere else (e.g. between the arguments of a call) moves after the statement it was in.
+
+const indentation = "  "
+
+// NOTE: formats a whole source file - the source has to parse without errors
+func Source(src string) (string, error) {
+	l := lexer.New(src)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return "", fmt.Errorf("parser errors:\n\t%s", strings.Join(p.Errors(), "\n\t"))
+	}
+
+	pr := newPrinter(l.Comments())
+	pr.collectLines(src)
+	pr.program(program)
+
+	return pr.out.String(), nil
+}
+
+// NOTE: formats a single node, without comments (there is no source to take them from)
+func Node(node ast.Node) string {
+	pr := newPrinter(nil)
+
+	switch node := node.(type) {
+	case *ast.Program:
+		pr.program(node)
+	case ast.Statement:
+		pr.statement(node, false)
+	case ast.Expression:
+		pr.expression(node, parser.LOWEST)
+	case ast.Pattern:
+		pr.pattern(node)
+	}
+
+	return pr.out.String()
+}
+
+// INFO: printer
+
+type printer struct {
+	out    strings.Builder
+	indent int
+
+	newlines       int  // how many newlines the output ends with (0 in the middle of a line)
+	started        bool // whether anything got written yet
+	atBlockStart   bool // right after a {, where no blank line is wanted
+	lineHasComment bool // the current output line ends with a comment, so nothing else may go on it
+
+	comments    []token.Token // the comments not printed yet, in source order
+	sourceLines map[int]bool  // the source lines having some token or comment on them
+	firstColumn map[int]int   // the column of the first token on each source line
+}
+
+func newPrinter(comments []token.Token) *printer {
+	return &printer{
+		comments:    comments,
+		sourceLines: map[int]bool{},
+		firstColumn: map[int]int{},
+	}
+}
+
+// NOTE: lexes the source once more, to know which lines are blank and which comments follow code on their line
+func (p *printer) collectLines(src string) {
+	l := lexer.New(src)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		p.sourceLines[tok.Line] = true
+		if _, ok := p.firstColumn[tok.Line]; !ok {
+			p.firstColumn[tok.Line] = tok.Column
+		}
+	}
+	for _, comment := range p.comments {
+		p.sourceLines[comment.Line] = true
+	}
+}
+
+// INFO: ==================================== Output ====================================
+
+func (p *printer) write(str string) {
+	if p.lineHasComment {
+		p.newline()
+	}
+	if p.newlines > 0 {
+		p.out.WriteString(strings.Repeat(indentation, p.indent))
+	}
+	p.out.WriteString(str)
+	p.newlines = 0
+	p.started = true
+	p.atBlockStart = false
+}
+
+func (p *printer) newline() {
+	p.out.WriteString("\n")
+	p.newlines++
+	p.lineHasComment = false
+}
+
+// NOTE: makes sure the next write starts on a fresh line - preceded by an empty one when blank is set
+func (p *printer) breakLine(blank bool) {
+	if !p.started {
+		return
+	}
+	want := 1
+	if blank {
+		want = 2
+	}
+	for p.newlines < want {
+		p.newline()
+	}
+}
+
+// NOTE: a blank line is kept when the source had one right above line (but never right after a {)
+func (p *printer) blankBefore(line int) bool {
+	return !p.atBlockStart && line > 1 && len(p.sourceLines) > 0 && !p.sourceLines[line-1]
+}
+
+// NOTE: prints the comments that come before the given source line - the ones that followed code on their own line
+// get appended to the current output line, the rest go on lines of their own
+func (p *printer) flushComments(beforeLine int) {
+	for len(p.comments) > 0 && p.comments[0].Line < beforeLine {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+
+		if p.isTrailing(comment) && p.started && p.newlines == 0 {
+			p.out.WriteString(" " + comment.Literal)
+		} else {
+			p.breakLine(p.blankBefore(comment.Line))
+			p.write(comment.Literal)
+		}
+		p.lineHasComment = true
+	}
+}
+
+func (p *printer) isTrailing(comment token.Token) bool {
+	column, ok := p.firstColumn[comment.Line]
+	return ok && column < comment.Column
+}
+
+func (p *printer) hasCommentsBefore(line int) bool {
+	return len(p.comments) > 0 && p.comments[0].Line < line
+}
+
+// INFO: ==================================== STATEMENTS! ====================================
+
+func (p *printer) program(program *ast.Program) {
+	p.atBlockStart = true
+	p.statements(program.Statements)
+	p.flushComments(math.MaxInt)
+	if p.started {
+		p.breakLine(false)
+		// NOTE: exactly one newline at the end of the file
+		for strings.HasSuffix(p.out.String(), "\n\n") {
+			str := strings.TrimSuffix(p.out.String(), "\n")
+			p.out.Reset()
+			p.out.WriteString(str)
+		}
+	}
+}
+
+func (p *printer) statements(statements []ast.Statement) {
+	for i, stmt := range statements {
+		line := startToken(stmt).Line
+		p.flushComments(line)
+		p.breakLine(p.blankBefore(line))
+
+		var next ast.Statement
+		if i+1 < len(statements) {
+			next = statements[i+1]
+		}
+		p.statement(stmt, needsSemicolon(stmt, next))
+	}
+}
+
+// NOTE: every statement ends with a semicolon, except an if or match used as a statement - unless the next statement
+// starts with something that would continue the expression (if (x) { 1 }; -1 isn't if (x) { 1 } - 1)
+func needsSemicolon(stmt, next ast.Statement) bool {
+	exprStmt, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return true
+	}
+	switch exprStmt.Expression.(type) {
+	case *ast.IfExpression, *ast.MatchExpression:
+	default:
+		return true
+	}
+	if next == nil {
+		return false
+	}
+	switch startToken(next).Type {
+	case token.MINUS, token.LPAREN, token.LBRACKET:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *printer) statement(stmt ast.Statement, semicolon bool) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		p.write("let ")
+		if stmt.Pattern != nil {
+			p.pattern(stmt.Pattern)
+		} else {
+			p.write(stmt.Name.Value)
+		}
+		p.annotation(stmt.Type)
+		p.write(" = ")
+		p.expression(stmt.Value, parser.LOWEST)
+	case *ast.ReturnStatement:
+		p.write("return")
+		if stmt.ReturnValue != nil {
+			p.write(" ")
+			p.expression(stmt.ReturnValue, parser.LOWEST)
+		}
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression, parser.LOWEST)
+	case *ast.BlockStatement:
+		p.block(stmt)
+		return
+	}
+
+	if semicolon {
+		p.write(";")
+	}
+}
+
+// NOTE: { stmt } when the block was written on one line and has at most one statement, one statement per line otherwise
+func (p *printer) block(block *ast.BlockStatement) {
+	if len(block.Statements) == 0 && !p.hasCommentsBefore(block.EndToken.Line) {
+		p.write("{}")
+		return
+	}
+
+	if onOneLine(block.Token, block.EndToken) && len(block.Statements) == 1 {
+		p.write("{ ")
+		p.statement(block.Statements[0], false)
+		p.write(" }")
+		return
+	}
+
+	p.write("{")
+	p.indent++
+	p.atBlockStart = true
+	p.statements(block.Statements)
+	p.flushComments(block.EndToken.Line)
+	p.indent--
+	p.breakLine(false)
+	p.write("}")
+}
+
+func onOneLine(start, end token.Token) bool {
+	return start.Line > 0 && start.Line == end.Line
+}
+
+// INFO: ==================================== EXPRESSIONS! ====================================
+
+// NOTE: anything that isn't an operator binds tighter than all of them
+const primary = parser.INDEX + 1
+
+// NOTE: prints expr, wrapped in parentheses when it binds looser than minPrecedence
+func (p *printer) expression(expr ast.Expression, minPrecedence int) {
+	if precedence(expr) < minPrecedence {
+		p.write("(")
+		p.expression(expr, parser.LOWEST)
+		p.write(")")
+		return
+	}
+
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		p.write(expr.Value)
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.Boolean:
+		p.write(expr.TokenLiteral())
+	case *ast.StringLiteral:
+		p.write(`"` + expr.Value + `"`)
+	case *ast.PrefixExpression:
+		p.write(expr.Operator)
+		p.expression(expr.Right, parser.PREFIX)
+	case *ast.InfixExpression:
+		p.infixExpression(expr)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(expr.Condition, parser.LOWEST)
+		p.write(") ")
+		p.block(expr.Consequence)
+		if expr.Alternative != nil {
+			p.write(" else ")
+			p.block(expr.Alternative)
+		}
+	case *ast.FunctionLiteral:
+		p.write("fn(")
+		for i, param := range expr.Parameters {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.pattern(param)
+		}
+		if expr.Rest != nil {
+			if len(expr.Parameters) > 0 {
+				p.write(", ")
+			}
+			p.write("..." + expr.Rest.Value)
+		}
+		p.write(")")
+		p.annotation(expr.ReturnType)
+		p.write(" ")
+		p.block(expr.Body)
+	case *ast.CallExpression:
+		p.callExpression(expr)
+	case *ast.ArrayLiteral:
+		p.list("[", "]", expr.Elements, false)
+	case *ast.SpreadExpression:
+		p.write("...")
+		p.expression(expr.Value, parser.LOWEST)
+	case *ast.IndexExpression:
+		p.expression(expr.Left, parser.CALL)
+		p.write("[")
+		p.expression(expr.Index, parser.LOWEST)
+		p.write("]")
+	case *ast.SliceExpression:
+		p.expression(expr.Left, parser.CALL)
+		p.write("[")
+		p.optionalExpression(expr.Start)
+		p.write(":")
+		p.optionalExpression(expr.End)
+		if expr.Step != nil {
+			p.write(":")
+			p.expression(expr.Step, parser.LOWEST)
+		}
+		p.write("]")
+	case *ast.AssignExpression:
+		p.expression(expr.Target, parser.CALL)
+		p.write(" = ")
+		p.expression(expr.Value, parser.ASSIGN)
+	case *ast.HashLiteral:
+		p.hashLiteral(expr)
+	case *ast.MatchExpression:
+		p.matchExpression(expr)
+	}
+}
+
+func (p *printer) optionalExpression(expr ast.Expression) {
+	if expr != nil {
+		p.expression(expr, parser.LOWEST)
+	}
+}
+
+func precedence(expr ast.Expression) int {
+	switch expr := expr.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(expr.Token.Type)
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.CallExpression:
+		if expr.Piped {
+			return parser.PIPELINE
+		}
+		return parser.CALL
+	case *ast.IndexExpression, *ast.SliceExpression:
+		return parser.CALL
+	case *ast.AssignExpression:
+		return parser.ASSIGN
+	default:
+		return primary
+	}
+}
+
+// NOTE: left-associative operators need a tighter right operand (a - (b - c)), ** is right-associative so it's the
+// other way around ((2 ** 3) ** 2). A prefix expression never needs parentheses on the right (a * -b, 2 ** -1).
+func (p *printer) infixExpression(expr *ast.InfixExpression) {
+	current := parser.Precedence(expr.Token.Type)
+	leftMin, rightMin := current, current+1
+	if expr.Token.Type == token.POWER {
+		leftMin, rightMin = current+1, current
+	}
+	if _, ok := expr.Right.(*ast.PrefixExpression); ok {
+		rightMin = min(rightMin, parser.PREFIX)
+	}
+
+	p.expression(expr.Left, leftMin)
+	p.write(" " + expr.Operator + " ")
+	p.expression(expr.Right, rightMin)
+}
+
+// NOTE: a pipeline is printed back as one - `x |> f` when there is nothing but the piped argument, unless the function
+// is a call itself (x |> g()() can't lose its parentheses, x |> g() would call g with x)
+func (p *printer) callExpression(expr *ast.CallExpression) {
+	if !expr.Piped || len(expr.Arguments) == 0 {
+		p.expression(expr.Function, parser.CALL)
+		p.list("(", ")", expr.Arguments, true)
+		return
+	}
+
+	p.expression(expr.Arguments[0], parser.PIPELINE)
+	p.write(" |> ")
+	p.expression(expr.Function, parser.PIPELINE+1)
+
+	function, isCall := expr.Function.(*ast.CallExpression)
+	if len(expr.Arguments) > 1 || (isCall && !function.Piped) {
+		p.list("(", ")", expr.Arguments[1:], true)
+	}
+}
+
+// NOTE: elements written on different lines get one line each (so do comments between them), unless inline is set
+func (p *printer) list(open, close string, elements []ast.Expression, inline bool) {
+	starts := make([]token.Token, len(elements))
+	for i, el := range elements {
+		starts[i] = startToken(el)
+	}
+
+	if inline || !onDifferentLines(starts) {
+		p.write(open)
+		for i, el := range elements {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expression(el, parser.LOWEST)
+		}
+		p.write(close)
+		return
+	}
+
+	p.write(open)
+	p.indent++
+	p.atBlockStart = true
+	for i, el := range elements {
+		p.flushComments(starts[i].Line)
+		p.breakLine(false)
+		p.expression(el, parser.LOWEST)
+		// NOTE: arrays & call arguments don't allow a trailing comma
+		if i < len(elements)-1 {
+			p.write(",")
+		}
+	}
+	p.indent--
+	p.breakLine(false)
+	p.write(close)
+}
+
+func onDifferentLines(tokens []token.Token) bool {
+	for _, tok := range tokens {
+		if tok.Line > 0 && tok.Line != tokens[0].Line {
+			return true
+		}
+	}
+	return false
+}
+
+// NOTE: HashLiteral keeps its pairs in a map, so the source order is recovered from the positions of the keys
+func (p *printer) hashLiteral(hash *ast.HashLiteral) {
+	keys := make([]ast.Expression, 0, len(hash.Pairs))
+	for key := range hash.Pairs {
+		keys = append(keys, key)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		left, right := startToken(keys[i]), startToken(keys[j])
+		if left.Line != right.Line {
+			return left.Line < right.Line
+		}
+		if left.Column != right.Column {
+			return left.Column < right.Column
+		}
+		return keys[i].String() < keys[j].String()
+	})
+
+	starts := make([]token.Token, len(keys))
+	for i, key := range keys {
+		starts[i] = startToken(key)
+	}
+
+	if !onDifferentLines(starts) {
+		p.write("{")
+		for i, key := range keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.hashPair(key, hash.Pairs[key])
+		}
+		p.write("}")
+		return
+	}
+
+	p.write("{")
+	p.indent++
+	p.atBlockStart = true
+	for i, key := range keys {
+		p.flushComments(starts[i].Line)
+		p.breakLine(false)
+		p.hashPair(key, hash.Pairs[key])
+		p.write(",")
+	}
+	p.indent--
+	p.breakLine(false)
+	p.write("}")
+}
+
+func (p *printer) hashPair(key, value ast.Expression) {
+	p.expression(key, parser.LOWEST)
+	p.write(": ")
+	p.expression(value, parser.LOWEST)
+}
+
+func (p *printer) matchExpression(expr *ast.MatchExpression) {
+	p.write("match (")
+	p.expression(expr.Subject, parser.LOWEST)
+	p.write(") ")
+
+	if len(expr.Arms) == 0 {
+		p.write("{}")
+		return
+	}
+
+	if onOneLine(expr.Token, expr.EndToken) {
+		p.write("{ ")
+		for i, arm := range expr.Arms {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.matchArm(arm)
+		}
+		p.write(" }")
+		return
+	}
+
+	p.write("{")
+	p.indent++
+	p.atBlockStart = true
+	for _, arm := range expr.Arms {
+		line := startToken(arm.Pattern).Line
+		p.flushComments(line)
+		p.breakLine(p.blankBefore(line))
+		p.matchArm(arm)
+		p.write(",")
+	}
+	p.flushComments(expr.EndToken.Line)
+	p.indent--
+	p.breakLine(false)
+	p.write("}")
+}
+
+func (p *printer) matchArm(arm *ast.MatchArm) {
+	p.pattern(arm.Pattern)
+	if arm.Guard != nil {
+		p.write(" if ")
+		p.expression(arm.Guard, parser.LOWEST)
+	}
+	p.write(" => ")
+	p.expression(arm.Body, parser.LOWEST)
+}
+
+// INFO: ==================================== PATTERNS! ====================================
+
+func (p *printer) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		p.write("_")
+	case *ast.LiteralPattern:
+		p.expression(pattern.Value, parser.LOWEST)
+	case *ast.IdentifierPattern:
+		p.write(pattern.Name.Value)
+		p.annotation(pattern.Type)
+	case *ast.DefaultPattern:
+		p.pattern(pattern.Pattern)
+		p.write(" = ")
+		// NOTE: the default is parsed with ASSIGN precedence, so it can't be an assignment without parentheses
+		p.expression(pattern.Default, parser.ASSIGN+1)
+	case *ast.ArrayPattern:
+		p.write("[")
+		for i, el := range pattern.Elements {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.pattern(el)
+		}
+		p.rest(pattern.Rest, len(pattern.Elements) > 0)
+		p.write("]")
+	case *ast.HashPattern:
+		p.write("{")
+		for i, pair := range pattern.Pairs {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expression(pair.Key, parser.LOWEST)
+			p.write(": ")
+			p.pattern(pair.Value)
+		}
+		p.rest(pattern.Rest, len(pattern.Pairs) > 0)
+		p.write("}")
+	}
+}
+
+func (p *printer) rest(rest *ast.Identifier, afterOthers bool) {
+	if rest == nil {
+		return
+	}
+	if afterOthers {
+		p.write(", ")
+	}
+	p.write("..." + rest.Value)
+}
+
+// NOTE: types have one way to be written, which is their String()
+func (p *printer) annotation(typ ast.Type) {
+	if typ != nil {
+		p.write(": " + typ.String())
+	}
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+// NOTE: the first token of a node in the source - the Token of most nodes, but an infix expression, a call or an
+// index start with their left side (the Token is the operator / the ( / the [ there)
+func startToken(node ast.Node) token.Token {
+	switch node := node.(type) {
+	case *ast.LetStatement:
+		return node.Token
+	case *ast.ReturnStatement:
+		return node.Token
+	case *ast.ExpressionStatement:
+		return node.Token
+	case *ast.BlockStatement:
+		return node.Token
+	case *ast.Identifier:
+		return node.Token
+	case *ast.IntegerLiteral:
+		return node.Token
+	case *ast.FloatLiteral:
+		return node.Token
+	case *ast.Boolean:
+		return node.Token
+	case *ast.StringLiteral:
+		return node.Token
+	case *ast.PrefixExpression:
+		return node.Token
+	case *ast.InfixExpression:
+		return startToken(node.Left)
+	case *ast.IfExpression:
+		return node.Token
+	case *ast.FunctionLiteral:
+		return node.Token
+	case *ast.CallExpression:
+		if node.Piped && len(node.Arguments) > 0 {
+			return startToken(node.Arguments[0])
+		}
+		return startToken(node.Function)
+	case *ast.ArrayLiteral:
+		return node.Token
+	case *ast.SpreadExpression:
+		return node.Token
+	case *ast.IndexExpression:
+		return startToken(node.Left)
+	case *ast.SliceExpression:
+		return startToken(node.Left)
+	case *ast.AssignExpression:
+		return startToken(node.Target)
+	case *ast.HashLiteral:
+		return node.Token
+	case *ast.MatchExpression:
+		return node.Token
+	case *ast.WildcardPattern:
+		return node.Token
+	case *ast.LiteralPattern:
+		return node.Token
+	case *ast.IdentifierPattern:
+		return node.Token
+	case *ast.DefaultPattern:
+		return startToken(node.Pattern)
+	case *ast.ArrayPattern:
+		return node.Token
+	case *ast.HashPattern:
+		return node.Token
+	default:
+		return token.Token{}
+	}
+}
@@ -0,0 +1,224 @@
+package format
+
+import (
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/lexer"
+	"mfiorek/waiig/parser"
+	"testing"
+)
+
+// INFO: ==================================== Tests ====================================
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let   x=5", "let x = 5;\n"},
+		{"let add=fn(a,b){a+b};add(1,2)", "let add = fn(a, b) { a + b };\nadd(1, 2);\n"},
+		{"return x", "return x;\n"},
+		{`let s = "a  b"`, "let s = \"a  b\";\n"},
+		{"let f = fn(x) {\nlet y = x * 2\ny\n}", "let f = fn(x) {\n  let y = x * 2;\n  y;\n};\n"},
+		{"let f = fn() { let a = 1; a }", "let f = fn() {\n  let a = 1;\n  a;\n};\n"},
+		{"let f = fn() {\n}", "let f = fn() {};\n"},
+		{"if (x) {\n1\n} else {\n2\n}", "if (x) {\n  1;\n} else {\n  2;\n}\n"},
+		{"if (x) { 1 } else { 2 }", "if (x) { 1 } else { 2 }\n"},
+		{"if (x) { 1 }; -1", "if (x) { 1 };\n-1;\n"},
+		{"if (x) { 1 }; y", "if (x) { 1 }\ny;\n"},
+		{"[1,2,3]", "[1, 2, 3];\n"},
+		{"[\n1,\n2\n]", "[\n  1,\n  2\n];\n"},
+		{`{"a":1,"b":2}`, "{\"a\": 1, \"b\": 2};\n"},
+		{"{\"b\": 1,\n\"a\": 2}", "{\n  \"b\": 1,\n  \"a\": 2,\n};\n"},
+		{"{}", "{};\n"},
+		{"f(...xs, 1)", "f(...xs, 1);\n"},
+		{"x|>f", "x |> f;\n"},
+		{"x |> f(1) |> g", "x |> f(1) |> g;\n"},
+		{"x |> g()()", "x |> g()();\n"},
+		{"x |> (y |> f)", "x |> (y |> f);\n"},
+		{"x |> (f(a))", "x |> f(a)();\n"},
+		{"a[1:2]; a[:]; a[::2]; a[1:]", "a[1:2];\na[:];\na[::2];\na[1:];\n"},
+		{"a[0] = b[0] = 1", "a[0] = b[0] = 1;\n"},
+		{"let [a, b = 2, ...rest] = xs", "let [a, b = 2, ...rest] = xs;\n"},
+		{`let {"a": a, "b": [b], ...others} = h`, "let {\"a\": a, \"b\": [b], ...others} = h;\n"},
+		{"fn(a, b = 1 + 2, ...rest) { a }", "fn(a, b = 1 + 2, ...rest) { a };\n"},
+		{"fn(...rest) { rest }", "fn(...rest) { rest };\n"},
+		{"let x:int=5", "let x: int = 5;\n"},
+		{"let f=fn(a:[int],b:{string:bool}=x,c):fn(int):float{a}", "let f = fn(a: [int], b: {string: bool} = x, c): fn(int): float { a };\n"},
+		{`match (x) { 1 => "one", -2 => "minus two", _ => "other" }`, "match (x) { 1 => \"one\", -2 => \"minus two\", _ => \"other\" }\n"},
+		{"match (x) {\n[a, ...r] if a > 1 => a,\n_ => 0\n}", "match (x) {\n  [a, ...r] if a > 1 => a,\n  _ => 0,\n}\n"},
+		// comments & blank lines
+		{"// header\nlet x = 1; // one\n\n\n// two\nlet y = 2;", "// header\nlet x = 1; // one\n\n// two\nlet y = 2;\n"},
+		{"let f = fn() { // doc\nx // the x\n// end\n}", "let f = fn() { // doc\n  x; // the x\n  // end\n};\n"},
+		{"let f = fn() {\n\n  x\n\n}", "let f = fn() {\n  x;\n};\n"},
+		{"let h = {\n// first\n\"a\": 1, // one\n\"b\": 2\n}", "let h = {\n  // first\n  \"a\": 1, // one\n  \"b\": 2,\n};\n"},
+		{"match (x) {\n// one\n1 => 1,\n\n_ => 0 // rest\n}", "match (x) {\n  // one\n  1 => 1,\n\n  _ => 0, // rest\n}\n"},
+		{"f(a, // moved\nb)", "f(a, b); // moved\n"},
+		{"// only a comment", "// only a comment\n"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Source(tt.input)
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %s", tt.input, err)
+			continue
+		}
+		if formatted != tt.expected {
+			t.Errorf("wrong formatting of %q.\nexpected=%q\ngot=     %q", tt.input, tt.expected, formatted)
+		}
+	}
+}
+
+// NOTE: the formatter must only add the parentheses the precedences need - and never change what the code means,
+// which is checked by comparing the fully parenthesized String() of the ASTs before and after formatting
+func TestSourceParentheses(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(1 + 2) * 3", "(1 + 2) * 3;\n"},
+		{"1 + (2 * 3)", "1 + 2 * 3;\n"},
+		{"(1 - 2) - 3", "1 - 2 - 3;\n"},
+		{"1 - (2 - 3)", "1 - (2 - 3);\n"},
+		{"2 ** (3 ** 2)", "2 ** 3 ** 2;\n"},
+		{"(2 ** 3) ** 2", "(2 ** 3) ** 2;\n"},
+		{"-(2 ** 2)", "-2 ** 2;\n"},
+		{"(-2) ** 2", "(-2) ** 2;\n"},
+		{"2 ** (-1)", "2 ** -1;\n"},
+		{"a * (-b)", "a * -b;\n"},
+		{"-(a + b)", "-(a + b);\n"},
+		{"!(a == b)", "!(a == b);\n"},
+		{"(a && b) || c", "a && b || c;\n"},
+		{"a && (b || c)", "a && (b || c);\n"},
+		{"(a | b) & c", "(a | b) & c;\n"},
+		{"(1 << 2) + 3", "(1 << 2) + 3;\n"},
+		{"(a < b) == (c < d)", "a < b == c < d;\n"},
+		{"(f)(x)", "f(x);\n"},
+		{"(fn(x) { x })(1)", "fn(x) { x }(1);\n"},
+		{"(-a)[0]", "(-a)[0];\n"},
+		{"-(a[0])", "-a[0];\n"},
+		{"(f())[0]", "f()[0];\n"},
+		{"(a + b)[0]", "(a + b)[0];\n"},
+		{"(x |> f) + 1", "(x |> f) + 1;\n"},
+		{"(x |> f) == 1 && (y |> g)", "x |> f == 1 && y |> g;\n"},
+		{"x |> (f == g)", "x |> (f == g);\n"},
+		{"(a + b) |> f", "a + b |> f;\n"},
+		{"x |> (f + g)", "x |> f + g;\n"},
+		{"(a[0] = 1) + 2", "(a[0] = 1) + 2;\n"},
+		{"a[0] = (b |> f)", "a[0] = b |> f;\n"},
+		{"let f = fn(x = (a[0] = 1)) { x }", "let f = fn(x = (a[0] = 1)) { x };\n"},
+		{"(if (x) { 1 } else { 2 }) + 1", "if (x) { 1 } else { 2 } + 1;\n"},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Source(tt.input)
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %s", tt.input, err)
+			continue
+		}
+		if formatted != tt.expected {
+			t.Errorf("wrong formatting of %q.\nexpected=%q\ngot=     %q", tt.input, tt.expected, formatted)
+		}
+
+		before, after := parse(t, tt.input), parse(t, formatted)
+		if before != after {
+			t.Errorf("formatting %q changed its meaning. before=%q, after=%q", tt.input, before, after)
+		}
+	}
+}
+
+func TestSourceIsIdempotent(t *testing.T) {
+	inputs := []string{
+		sampleProgram,
+		"let x = {\n\"a\": fn() {\nx\n},\n\"b\": [1,\n2]}",
+		"if (a) { b } else { c }\n-1",
+		"let f = fn(x) { match (x) {\n  1 => 2, // one\n  _ => 3 } }",
+	}
+
+	for _, input := range inputs {
+		once, err := Source(input)
+		if err != nil {
+			t.Fatalf("Source(%q) returned error: %s", input, err)
+		}
+		twice, err := Source(once)
+		if err != nil {
+			t.Fatalf("Source(%q) returned error: %s", once, err)
+		}
+		if once != twice {
+			t.Errorf("formatting is not idempotent.\nonce= %q\ntwice=%q", once, twice)
+		}
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	_, err := Source("let = 5;")
+	if err == nil {
+		t.Fatalf("expected an error for invalid source")
+	}
+
+	expected := "parser errors:\n\texpected next token to be IDENT, got = instead"
+	if err.Error() != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, err.Error())
+	}
+}
+
+func TestNode(t *testing.T) {
+	l := lexer.New("let f = fn(x) {\n  (x + 1) * 2\n};")
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{program, "let f = fn(x) {\n  (x + 1) * 2;\n};\n"},
+		{program.Statements[0], "let f = fn(x) {\n  (x + 1) * 2;\n}"},
+	}
+
+	for _, tt := range tests {
+		got := Node(tt.node)
+		if got != tt.expected {
+			t.Errorf("wrong formatting. expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+func parse(t *testing.T, input string) string {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program.String()
+}
+
+const sampleProgram = `// fibonacci, the slow way
+let fib = fn(n) {
+  if (n < 2) { return n; }
+  fib(n - 1) + fib(n - 2)
+};
+
+let people = [{"name": "Alice", "age": 24}, {"name": "Anna", "age": 28}];
+
+// everybody's name, in capitals
+let names = people
+  |> map(fn(p) { p["name"] })
+  |> map(upper);
+
+let describe = fn(value) {
+  match (value) {
+    [] => "empty",
+    [x] => "one: " + to_string(x),
+    [x, ...rest] if len(rest) > 3 => "long",
+    {"name": name} => name,
+    _ => "something else", // anything
+  }
+};
+
+let swap = fn([a, b]) { [b, a] };
+let total = reduce([1, 2, 3], fn(acc, x) { acc + x }, 0);
+puts(fib(10), names, describe([1]), swap([1, 2]), total);
+`
@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"mfiorek/waiig/token"
+	"strings"
 )
 
 type Lexer struct {
@@ -9,17 +10,35 @@ type Lexer struct {
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
 	ch           byte // current char under examination
+	line         int  // line of the current char
+	column       int  // column of the current char
+
+	comments []token.Token
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
 
+// NOTE: the comments skipped so far, in source order - the parser doesn't need them, but the formatter does
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
 func (l *Lexer) NextToken() token.Token {
+	l.skipWhitespaceAndComments()
+
+	line, column := l.line, l.column
+	tok := l.readToken()
+	tok.Line, tok.Column = line, column
+
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
 	var tok token.Token
-	l.skipWhitespace()
 
 	switch l.ch {
 	case ';':
@@ -43,9 +62,23 @@ func (l *Lexer) NextToken() token.Token {
 	case '"':
 		tok = l.newStringToken()
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		switch l.peekChar() {
+		case '=':
+			tok = newTwoCharToken(token.LT_EQ, l)
+		case '<':
+			tok = newTwoCharToken(token.SHIFT_LEFT, l)
+		default:
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		switch l.peekChar() {
+		case '=':
+			tok = newTwoCharToken(token.GT_EQ, l)
+		case '>':
+			tok = newTwoCharToken(token.SHIFT_RIGHT, l)
+		default:
+			tok = newToken(token.GT, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			tok = newTwoCharToken(token.NOT_EQ, l)
@@ -53,11 +86,41 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '=':
-		if l.peekChar() == '=' {
+		switch l.peekChar() {
+		case '=':
 			tok = newTwoCharToken(token.EQ, l)
-		} else {
+		case '>':
+			tok = newTwoCharToken(token.ARROW, l)
+		default:
 			tok = newToken(token.ASSIGN, l.ch)
 		}
+	case '.':
+		if l.peekChar() == '.' && l.peekCharAt(1) == '.' {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '&':
+		if l.peekChar() == '&' {
+			tok = newTwoCharToken(token.AND, l)
+		} else {
+			tok = newToken(token.AMPERSAND, l.ch)
+		}
+	case '|':
+		switch l.peekChar() {
+		case '|':
+			tok = newTwoCharToken(token.OR, l)
+		case '>':
+			tok = newTwoCharToken(token.PIPELINE, l)
+		default:
+			tok = newToken(token.PIPE, l.ch)
+		}
+	case '^':
+		tok = newToken(token.CARET, l.ch)
+	case '~':
+		tok = newToken(token.TILDE, l.ch)
 	case '+':
 		tok = newToken(token.PLUS, l.ch)
 	case '-':
@@ -65,7 +128,13 @@ func (l *Lexer) NextToken() token.Token {
 	case '/':
 		tok = newToken(token.SLASH, l.ch)
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '*' {
+			tok = newTwoCharToken(token.POWER, l)
+		} else {
+			tok = newToken(token.ASTERISK, l.ch)
+		}
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -75,9 +144,7 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
-			return tok
+			return l.newNumberToken()
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
@@ -98,9 +165,14 @@ func newTwoCharToken(tokenType token.TokenType, l *Lexer) token.Token {
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
 	l.ch = l.peekChar()
 	l.position = l.readPosition
 	l.readPosition += 1
+	l.column++
 }
 func (l *Lexer) peekChar() byte {
 	if l.readPosition >= len(l.input) {
@@ -110,9 +182,18 @@ func (l *Lexer) peekChar() byte {
 	}
 }
 
+// NOTE: like peekChar, but looks further ahead - peekCharAt(0) is the same as peekChar()
+func (l *Lexer) peekCharAt(offset int) byte {
+	if l.readPosition+offset >= len(l.input) {
+		return 0
+	}
+	return l.input[l.readPosition+offset]
+}
+
+// NOTE: an identifier starts with a letter, but may contain digits after that (log10, atan2)
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || isDigit(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
@@ -132,6 +213,20 @@ func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+// NOTE: a number is a FLOAT only when the dot is followed by a digit, so `1.` stays an INT followed by ILLEGAL
+func (l *Lexer) newNumberToken() token.Token {
+	position := l.position
+	l.readNumber()
+
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		l.readChar()
+		l.readNumber()
+		return token.Token{Type: token.FLOAT, Literal: l.input[position:l.position]}
+	}
+
+	return token.Token{Type: token.INT, Literal: l.input[position:l.position]}
+}
+
 func (l *Lexer) newStringToken() token.Token {
 	l.readChar()
 	position := l.position
@@ -149,3 +244,21 @@ func (l *Lexer) skipWhitespace() {
 		l.readChar()
 	}
 }
+
+func (l *Lexer) skipWhitespaceAndComments() {
+	l.skipWhitespace()
+	for l.ch == '/' && l.peekChar() == '/' {
+		l.readComment()
+		l.skipWhitespace()
+	}
+}
+
+// WARN: Helper method used only in skipWhitespaceAndComments - the comment runs up to (not including) the end of the line
+func (l *Lexer) readComment() {
+	position, line, column := l.position, l.line, l.column
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	literal := strings.TrimRight(l.input[position:l.position], " \t\r")
+	l.comments = append(l.comments, token.Token{Type: token.COMMENT, Literal: literal, Line: line, Column: column})
+}
@@ -5,6 +5,153 @@ import (
 	"testing"
 )
 
+func TestNextToken5(t *testing.T) {
+	input := `// header
+let x = 10; // ten
+  x >= "a b"
+// trailing`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.LET, "let", 2, 1},
+		{token.IDENT, "x", 2, 5},
+		{token.ASSIGN, "=", 2, 7},
+		{token.INT, "10", 2, 9},
+		{token.SEMICOLON, ";", 2, 11},
+		{token.IDENT, "x", 3, 3},
+		{token.GT_EQ, ">=", 3, 5},
+		{token.STRING, "a b", 3, 8},
+		{token.EOF, "", 4, 12},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+
+	comments := []token.Token{
+		{Type: token.COMMENT, Literal: "// header", Line: 1, Column: 1},
+		{Type: token.COMMENT, Literal: "// ten", Line: 2, Column: 13},
+		{Type: token.COMMENT, Literal: "// trailing", Line: 4, Column: 1},
+	}
+	if len(l.Comments()) != len(comments) {
+		t.Fatalf("wrong number of comments. expected=%d, got=%d", len(comments), len(l.Comments()))
+	}
+	for i, comment := range comments {
+		if l.Comments()[i] != comment {
+			t.Errorf("comments[%d] wrong. expected=%+v, got=%+v", i, comment, l.Comments()[i])
+		}
+	}
+}
+
+func TestNextToken4(t *testing.T) {
+	input := `a <= b >= c;
+10 % 3 ** 2;
+x & y | z ^ ~w;
+1 << 2 >> 3;
+3.14 + 1.;
+match (x) { [a, ...b] => a }
+x |> f;
+log10 x2_y;
+`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "c"},
+		{token.SEMICOLON, ";"},
+		{token.INT, "10"},
+		{token.PERCENT, "%"},
+		{token.INT, "3"},
+		{token.POWER, "**"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.AMPERSAND, "&"},
+		{token.IDENT, "y"},
+		{token.PIPE, "|"},
+		{token.IDENT, "z"},
+		{token.CARET, "^"},
+		{token.TILDE, "~"},
+		{token.IDENT, "w"},
+		{token.SEMICOLON, ";"},
+		{token.INT, "1"},
+		{token.SHIFT_LEFT, "<<"},
+		{token.INT, "2"},
+		{token.SHIFT_RIGHT, ">>"},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.FLOAT, "3.14"},
+		{token.PLUS, "+"},
+		{token.INT, "1"},
+		{token.ILLEGAL, "."},
+		{token.SEMICOLON, ";"},
+		{token.MATCH, "match"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.LBRACKET, "["},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "b"},
+		{token.RBRACKET, "]"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "a"},
+		{token.RBRACE, "}"},
+		{token.IDENT, "x"},
+		{token.PIPELINE, "|>"},
+		{token.IDENT, "f"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "log10"},
+		{token.IDENT, "x2_y"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
 func TestNextToken3(t *testing.T) {
 	input := `let five = 5;
 let ten = 10;
@@ -31,6 +178,7 @@ if (5 < 10) {
 
 [1, 2];
 {"foo": "bar"}
+a && b || c;
 `
 
 	tests := []struct {
@@ -123,6 +271,12 @@ if (5 < 10) {
 		{token.COLON, ":"},
 		{token.STRING, "bar"},
 		{token.RBRACE, "}"},
+		{token.IDENT, "a"},
+		{token.AND, "&&"},
+		{token.IDENT, "b"},
+		{token.OR, "||"},
+		{token.IDENT, "c"},
+		{token.SEMICOLON, ";"},
 		{token.EOF, ""},
 	}
 
@@ -0,0 +1,221 @@
+package lint
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/lexer"
+	"mfiorek/waiig/parser"
+	"mfiorek/waiig/token"
+	"os"
+	"slices"
+	"strings"
+)
+
+// INFO: Linter - checks a program for the mistakes that parse and run fine but are most likely not what was meant.
+// Every rule can be turned off in the config, and a single issue with a comment in the source:
+//
+//	puts(x == true); // lint:ignore bool-comparison
+//
+//	// lint:ignore constant-condition, unreachable-code
+//	if (true) { ... }
+//
+//	// lint:file-ignore names
+//
+// `lint:ignore` after code covers its line, on a line of its own the next one - `lint:file-ignore` covers the whole
+// file. Without rule names they cover every rule.
+
+// NOTE: the pseudo-rule of the parser's errors - it can't be turned off, the other rules only run on programs that parse
+const SyntaxRule = "syntax"
+
+type Rule struct {
+	Name        string
+	Description string
+	check       func(p *pass)
+}
+
+// NOTE: a diagnostic of a rule - the position, message and hint are in the embedded Diagnostic
+type Issue struct {
+	Rule string
+	parser.Diagnostic
+}
+
+// NOTE: the rules as they run, sorted by name
+func Rules() []*Rule {
+	return rules
+}
+
+func FindRule(name string) *Rule {
+	for _, rule := range rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+// INFO: ==================================== Config ====================================
+
+// NOTE: read from JSON - {"rules": {"bool-comparison": false}}. The rules missing from it are enabled.
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for name := range config.Rules {
+		if FindRule(name) == nil {
+			return nil, fmt.Errorf("%s: unknown rule %q", path, name)
+		}
+	}
+	return config, nil
+}
+
+// NOTE: a nil config has every rule enabled
+func (c *Config) Enabled(rule string) bool {
+	if c == nil {
+		return true
+	}
+	enabled, ok := c.Rules[rule]
+	return !ok || enabled
+}
+
+func (c *Config) Set(rule string, enabled bool) error {
+	if FindRule(rule) == nil {
+		return fmt.Errorf("unknown rule %q", rule)
+	}
+	if c.Rules == nil {
+		c.Rules = map[string]bool{}
+	}
+	c.Rules[rule] = enabled
+	return nil
+}
+
+// INFO: ==================================== Running the rules ====================================
+
+// NOTE: the issues of a source file in source order - only the syntax errors when it doesn't parse
+func Source(src string, config *Config) []Issue {
+	l := lexer.New(src)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		issues := []Issue{}
+		for _, d := range p.Diagnostics() {
+			issues = append(issues, Issue{Rule: SyntaxRule, Diagnostic: d})
+		}
+		return issues
+	}
+
+	issues := Program(program, config)
+	return suppress(issues, l.Comments(), strings.Split(src, "\n"))
+}
+
+// NOTE: runs the enabled rules on a parsed program - there are no comments here, so nothing is suppressed
+func Program(program *ast.Program, config *Config) []Issue {
+	issues := []Issue{}
+	for _, rule := range rules {
+		if !config.Enabled(rule.Name) {
+			continue
+		}
+		p := &pass{rule: rule, program: program}
+		rule.check(p)
+		issues = append(issues, p.issues...)
+	}
+
+	slices.SortStableFunc(issues, func(a, b Issue) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return issues
+}
+
+type pass struct {
+	rule    *Rule
+	program *ast.Program
+	issues  []Issue
+}
+
+func (p *pass) report(tok token.Token, hint string, format string, args ...any) {
+	p.add(parser.Diagnostic{
+		Severity: parser.SeverityWarning,
+		Line:     tok.Line,
+		Column:   tok.Column,
+		Message:  fmt.Sprintf(format, args...),
+		Found:    tok,
+		Hint:     hint,
+	})
+}
+
+func (p *pass) add(d parser.Diagnostic) {
+	p.issues = append(p.issues, Issue{Rule: p.rule.Name, Diagnostic: d})
+}
+
+// INFO: ==================================== Suppression comments ====================================
+
+type suppression struct {
+	file  bool
+	line  int
+	rules []string // empty for all of them
+}
+
+func (s suppression) covers(issue Issue) bool {
+	if !s.file && issue.Line != s.line {
+		return false
+	}
+	return len(s.rules) == 0 || slices.Contains(s.rules, issue.Rule)
+}
+
+func suppress(issues []Issue, comments []token.Token, lines []string) []Issue {
+	suppressions := parseSuppressions(comments, lines)
+	if len(suppressions) == 0 {
+		return issues
+	}
+
+	kept := []Issue{}
+	for _, issue := range issues {
+		if !slices.ContainsFunc(suppressions, func(s suppression) bool { return s.covers(issue) }) {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
+
+// WARN: Helper method used only in suppress - the rule names can be separated by commas or spaces
+func parseSuppressions(comments []token.Token, lines []string) []suppression {
+	var suppressions []suppression
+	for _, comment := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Literal, "//"))
+
+		var s suppression
+		switch {
+		case strings.HasPrefix(text, "lint:file-ignore"):
+			s.file = true
+			text = strings.TrimPrefix(text, "lint:file-ignore")
+		case strings.HasPrefix(text, "lint:ignore"):
+			s.line = comment.Line
+			if strings.TrimSpace(lines[comment.Line-1][:comment.Column-1]) == "" {
+				s.line++
+			}
+			text = strings.TrimPrefix(text, "lint:ignore")
+		default:
+			continue
+		}
+		// NOTE: lint:ignored is not lint:ignore
+		if text != "" && text[0] != ' ' && text[0] != '\t' {
+			continue
+		}
+
+		s.rules = strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		suppressions = append(suppressions, s)
+	}
+	return suppressions
+}
@@ -0,0 +1,189 @@
+package lint
+
+import (
+	"fmt"
+	"mfiorek/waiig/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// NOTE: an issue as "line:column rule: message" - the hint is checked by TestHints
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule     string
+		input    string
+		expected []string
+	}{
+		{"bool-comparison", "let x = 1; puts(x == true, false != x, x == 1, true == false)", []string{
+			"1:22 bool-comparison: comparison with true is redundant",
+			"1:28 bool-comparison: comparison with false is redundant",
+			"1:56 bool-comparison: comparison with false is redundant",
+		}},
+		{"constant-condition", `if (true) { 1 }; if (1 > 2) { 1 } else { 2 }; if ("a" - 1) { 1 }; if (fn() { false }) { 1 }
+let x = 1; if (x > 2) { 1 }; if (len([]) == 0) { 1 }`, []string{
+			"1:1 constant-condition: the condition of this if is always true",
+			"1:18 constant-condition: the condition of this if is always false",
+			"1:47 constant-condition: the condition of this if is constant",
+			"1:67 constant-condition: the condition of this if is always true",
+		}},
+		// NOTE: indexing and slicing aren't folded - this one used to crash the evaluator, and with it the linter
+		{"constant-condition", "if ([1, 2, 3][1::9223372036854775807]) { 1 }; if ([1][0]) { 1 }; if (!{}) { 1 }", []string{
+			"1:66 constant-condition: the condition of this if is always false",
+		}},
+		{"duplicate-key", `puts({"a": 1, "b": 2, "a": 3}, {1: 1, true: 2, "1": 3, 1: 4, true: 5}, {"x": {"x": 1}})`, []string{
+			"1:23 duplicate-key: duplicate key \"a\" in hash literal",
+			"1:56 duplicate-key: duplicate key 1 in hash literal",
+			"1:62 duplicate-key: duplicate key true in hash literal",
+		}},
+		{"inconsistent-return", `let a = fn(x) { if (x) { return 1; } };
+let b = fn(x) { if (x) { return 1; }; 2 };
+let c = fn(x) { if (x) { return 1; } else { 2 } };
+let d = fn(x) { if (x) { return 1; }; let y = x; };
+let e = fn(x) { let y = x; };
+let f = fn(x) { let g = fn() { return 1; }; let y = x; };
+let h = fn(x) { if (x) { return 1; } else { return 2; }; };`, []string{
+			"1:9 inconsistent-return: function returns a value on some paths and falls through to null on others",
+			"4:9 inconsistent-return: function returns a value on some paths and falls through to null on others",
+		}},
+		{"names", "let counter = 1; puts(countr); let f = fn(len) { 1 }; f(1)", []string{
+			"1:5 names: variable counter is never used",
+			"1:23 names: identifier not found: countr",
+			"1:43 names: parameter len is never used",
+		}},
+		{"unreachable-code", `let f = fn(x) {
+  if (x) { return 1; puts("a"); puts("b") } else { return 2 };
+  puts("c");
+};
+return 1;
+f(1);`, []string{
+			"2:22 unreachable-code: unreachable code",
+			"3:3 unreachable-code: unreachable code",
+			"6:1 unreachable-code: unreachable code",
+		}},
+	}
+
+	for _, tt := range tests {
+		config := &Config{}
+		for _, rule := range Rules() {
+			config.Set(rule.Name, rule.Name == tt.rule)
+		}
+		testIssues(t, tt.input, Source(tt.input, config), tt.expected)
+	}
+}
+
+func TestHints(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1; puts(x == true)", "use `x` instead"},
+		{"let x = 1; puts(x != true)", "use `!x` instead"},
+		{"if (true) { 1 } else { 2 }", "the else branch never runs"},
+		{"if (true) { 1 }", ""},
+		{"if (false) { 1 }", "the consequence never runs"},
+		{`puts({"a": 1, "a": 2})`, "the same key is at 1:7 - only one of the values is kept"},
+		{"let f = fn() { return 1; f() }; f()", "the return at 1:16 always returns before it"},
+	}
+
+	for _, tt := range tests {
+		issues := Source(tt.input, nil)
+		if len(issues) != 1 {
+			t.Errorf("expected 1 issue for %q. got=%d (%v)", tt.input, len(issues), issues)
+			continue
+		}
+		if issues[0].Hint != tt.expected {
+			t.Errorf("wrong hint for %q. expected=%q, got=%q", tt.input, tt.expected, issues[0].Hint)
+		}
+	}
+}
+
+func TestSuppression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; puts(x == true); // lint:ignore bool-comparison\nputs(x == true);", []string{
+			"2:11 bool-comparison: comparison with true is redundant",
+		}},
+		{"let x = 1;\n// lint:ignore bool-comparison\nputs(x == true);\nputs(x == true);", []string{
+			"4:11 bool-comparison: comparison with true is redundant",
+		}},
+		{"// lint:ignore\nif (true) { 1 == true }", nil},
+		{"// lint:ignore names, constant-condition\nif (true) { puts(y == true) }", []string{
+			"2:23 bool-comparison: comparison with true is redundant",
+		}},
+		{"if (true) { 1 } // lint:ignored\n// lint:file-ignore constant-condition\nif (false) { 1 }", nil},
+		{"if (true) { 1 } // lint:ignorebool\n", []string{
+			"1:1 constant-condition: the condition of this if is always true",
+		}},
+	}
+
+	for _, tt := range tests {
+		testIssues(t, tt.input, Source(tt.input, nil), tt.expected)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	issues := Source("let = 1; if (true) { 1 }", nil)
+	if len(issues) == 0 {
+		t.Fatalf("expected syntax errors")
+	}
+	for _, issue := range issues {
+		if issue.Rule != SyntaxRule || issue.Severity != parser.SeverityError {
+			t.Errorf("expected only syntax errors. got=%s %s", issue.Rule, issue.Severity)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	unknown := filepath.Join(dir, "unknown.json")
+	os.WriteFile(valid, []byte(`{"rules": {"names": false, "bool-comparison": true}}`), 0o644)
+	os.WriteFile(unknown, []byte(`{"rules": {"no-such-rule": false}}`), 0o644)
+
+	config, err := LoadConfig(valid)
+	if err != nil {
+		t.Fatalf("LoadConfig returned an error: %s", err)
+	}
+	for _, tt := range []struct {
+		rule     string
+		expected bool
+	}{{"names", false}, {"bool-comparison", true}, {"duplicate-key", true}} {
+		if config.Enabled(tt.rule) != tt.expected {
+			t.Errorf("wrong Enabled(%q). expected=%t", tt.rule, tt.expected)
+		}
+	}
+
+	if _, err := LoadConfig(unknown); err == nil || !strings.Contains(err.Error(), "no-such-rule") {
+		t.Errorf("expected an unknown rule error. got=%v", err)
+	}
+	if _, err := LoadConfig(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("expected an error for a missing config")
+	}
+	if err := config.Set("no-such-rule", true); err == nil {
+		t.Errorf("expected an error setting an unknown rule")
+	}
+	if !(*Config)(nil).Enabled("names") {
+		t.Errorf("a nil config should enable every rule")
+	}
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+func testIssues(t *testing.T, input string, issues []Issue, expected []string) {
+	t.Helper()
+	got := []string{}
+	for _, issue := range issues {
+		got = append(got, formatIssue(issue))
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong issues for %q.\nexpected=%q\ngot=     %q", input, expected, got)
+	}
+}
+
+func formatIssue(issue Issue) string {
+	return fmt.Sprintf("%d:%d %s: %s", issue.Line, issue.Column, issue.Rule, issue.Message)
+}
@@ -0,0 +1,317 @@
+package lint
+
+import (
+	"cmp"
+	"fmt"
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/evaluator"
+	"mfiorek/waiig/object"
+	"mfiorek/waiig/resolver"
+	"mfiorek/waiig/token"
+	"slices"
+	"strconv"
+)
+
+// INFO: The rules - each one walks the whole program and reports what it finds
+
+var rules = []*Rule{
+	{
+		Name:        "bool-comparison",
+		Description: "comparisons with true or false (x == true is just x, x == false is !x)",
+		check:       checkBoolComparison,
+	},
+	{
+		Name:        "constant-condition",
+		Description: "if conditions that don't depend on anything, so one of the branches never runs",
+		check:       checkConstantCondition,
+	},
+	{
+		Name:        "duplicate-key",
+		Description: "hash literals with the same key twice - only one of the values is kept",
+		check:       checkDuplicateKey,
+	},
+	{
+		Name:        "inconsistent-return",
+		Description: "functions that return a value on some paths and fall through (to null) on others",
+		check:       checkInconsistentReturn,
+	},
+	{
+		Name:        "names",
+		Description: "undefined names, names shadowing an outer one and unused variables and parameters",
+		check:       checkNames,
+	},
+	{
+		Name:        "unreachable-code",
+		Description: "statements after a return (or an if whose branches all return)",
+		check:       checkUnreachableCode,
+	},
+}
+
+// INFO: ==================================== bool-comparison ====================================
+
+func checkBoolComparison(p *pass) {
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		infix, ok := node.(*ast.InfixExpression)
+		if !ok || (infix.Operator != "==" && infix.Operator != "!=") {
+			return true
+		}
+
+		literal, other := booleanOperand(infix)
+		if literal == nil {
+			return true
+		}
+		// NOTE: x != true is !x just as x == false is
+		if literal.Value == (infix.Operator == "==") {
+			p.report(literal.Token, fmt.Sprintf("use `%s` instead", other), "comparison with %s is redundant", literal.Token.Literal)
+		} else {
+			p.report(literal.Token, fmt.Sprintf("use `!%s` instead", other), "comparison with %s is redundant", literal.Token.Literal)
+		}
+		return true
+	})
+}
+
+// WARN: Helper method used only in checkBoolComparison - the literal can be on either side
+func booleanOperand(infix *ast.InfixExpression) (*ast.Boolean, ast.Expression) {
+	if literal, ok := infix.Right.(*ast.Boolean); ok {
+		return literal, infix.Left
+	}
+	if literal, ok := infix.Left.(*ast.Boolean); ok {
+		return literal, infix.Right
+	}
+	return nil, nil
+}
+
+// INFO: ==================================== constant-condition ====================================
+
+func checkConstantCondition(p *pass) {
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		ifExp, ok := node.(*ast.IfExpression)
+		if !ok || !isConstant(ifExp.Condition) {
+			return true
+		}
+
+		value := evalConstant(ifExp.Condition)
+		switch {
+		case value == nil || value.Type() == object.ERROR_OBJ:
+			p.report(ifExp.Token, "", "the condition of this if is constant")
+		case truthy(value):
+			p.report(ifExp.Token, elseHint(ifExp, "the else branch never runs"), "the condition of this if is always true")
+		default:
+			p.report(ifExp.Token, "the consequence never runs", "the condition of this if is always false")
+		}
+		return true
+	})
+}
+
+// NOTE: literals and the prefix and infix operators on them - nothing else is folded, not even indexing and slicing
+func isConstant(exp ast.Expression) bool {
+	constant := true
+	ast.Inspect(exp, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean, *ast.ArrayLiteral, *ast.HashLiteral,
+			*ast.PrefixExpression, *ast.InfixExpression, nil:
+		case *ast.FunctionLiteral:
+			// NOTE: a function is truthy whatever its body does
+			return false
+		default:
+			constant = false
+		}
+		return constant
+	})
+	return constant
+}
+
+// WARN: Helper method used only in checkConstantCondition - the condition can't have side effects, so evaluating it here
+// is safe. It can still be an error ("a" - 1), and a bug of the evaluator mustn't crash the linter, so a panic is nil
+// (a condition that is just constant).
+func evalConstant(exp ast.Expression) (value object.Object) {
+	defer func() {
+		if recover() != nil {
+			value = nil
+		}
+	}()
+	return evaluator.Eval(exp, object.NewEnvironment())
+}
+
+// WARN: Helper method used only in checkConstantCondition - the way the evaluator tells (only false and null are falsy)
+func truthy(value object.Object) bool {
+	switch value := value.(type) {
+	case *object.Boolean:
+		return value.Value
+	case *object.Null:
+		return false
+	default:
+		return true
+	}
+}
+
+// WARN: Helper method used only in checkConstantCondition - without an else there is no branch that never runs
+func elseHint(ifExp *ast.IfExpression, hint string) string {
+	if ifExp.Alternative == nil {
+		return ""
+	}
+	return hint
+}
+
+// INFO: ==================================== duplicate-key ====================================
+
+func checkDuplicateKey(p *pass) {
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		hash, ok := node.(*ast.HashLiteral)
+		if !ok {
+			return true
+		}
+
+		// NOTE: the pairs are a map, so they are looked at in source order to report the later key of the two
+		keys := []ast.Expression{}
+		for key := range hash.Pairs {
+			keys = append(keys, key)
+		}
+		sortByPosition(keys)
+
+		seen := map[string]token.Token{}
+		for _, key := range keys {
+			id, display, ok := keyIdentity(key)
+			if !ok {
+				continue
+			}
+			tok, _ := ast.TokenOf(key)
+			if first, ok := seen[id]; ok {
+				p.report(tok, fmt.Sprintf("the same key is at %d:%d - only one of the values is kept", first.Line, first.Column),
+					"duplicate key %s in hash literal", display)
+				continue
+			}
+			seen[id] = tok
+		}
+		return true
+	})
+}
+
+// WARN: Helper method used only in checkDuplicateKey - only literal keys can be compared without running the program
+func keyIdentity(key ast.Expression) (string, string, bool) {
+	switch key := key.(type) {
+	case *ast.StringLiteral:
+		return "STRING " + key.Value, strconv.Quote(key.Value), true
+	case *ast.IntegerLiteral:
+		return "INTEGER " + strconv.FormatInt(key.Value, 10), key.Token.Literal, true
+	case *ast.Boolean:
+		return "BOOLEAN " + strconv.FormatBool(key.Value), key.Token.Literal, true
+	}
+	return "", "", false
+}
+
+func sortByPosition(exps []ast.Expression) {
+	slices.SortFunc(exps, func(a, b ast.Expression) int {
+		ta, _ := ast.TokenOf(a)
+		tb, _ := ast.TokenOf(b)
+		return cmp.Or(cmp.Compare(ta.Line, tb.Line), cmp.Compare(ta.Column, tb.Column))
+	})
+}
+
+// INFO: ==================================== inconsistent-return ====================================
+
+func checkInconsistentReturn(p *pass) {
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		fn, ok := node.(*ast.FunctionLiteral)
+		if !ok || fn.Body == nil || !hasReturn(fn.Body) || endsWithValue(fn.Body) {
+			return true
+		}
+		p.report(fn.Token, "end every path with a return or an expression (an if needs an else)",
+			"function returns a value on some paths and falls through to null on others")
+		return true
+	})
+}
+
+// NOTE: the returns of the function itself - the ones of the functions defined in it don't count
+func hasReturn(body *ast.BlockStatement) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.ReturnStatement:
+			found = true
+		case *ast.FunctionLiteral:
+			return false
+		}
+		return !found
+	})
+	return found
+}
+
+// NOTE: whether every path through the block gives a value - a return, or an expression as the last statement
+// (an if only with an else that does too)
+func endsWithValue(block *ast.BlockStatement) bool {
+	if block == nil || len(block.Statements) == 0 {
+		return false
+	}
+	if blockReturns(block) {
+		return true
+	}
+
+	last, ok := block.Statements[len(block.Statements)-1].(*ast.ExpressionStatement)
+	if !ok || last.Expression == nil {
+		return false
+	}
+	if ifExp, ok := last.Expression.(*ast.IfExpression); ok {
+		return endsWithValue(ifExp.Consequence) && endsWithValue(ifExp.Alternative)
+	}
+	return true
+}
+
+// INFO: ==================================== names ====================================
+
+// NOTE: the resolver's diagnostics - the builtins and the constants are there without being declared
+func checkNames(p *pass) {
+	for _, d := range resolver.New(evaluator.BuiltinNames()).Resolve(p.program).Diagnostics {
+		p.add(d)
+	}
+}
+
+// INFO: ==================================== unreachable-code ====================================
+
+func checkUnreachableCode(p *pass) {
+	check := func(statements []ast.Statement) {
+		for idx, stmt := range statements[:max(len(statements)-1, 0)] {
+			if !returns(stmt) {
+				continue
+			}
+			tok, _ := ast.TokenOf(stmt)
+			next, _ := ast.TokenOf(statements[idx+1])
+			p.report(next, fmt.Sprintf("the %s at %d:%d always returns before it", tok.Literal, tok.Line, tok.Column), "unreachable code")
+			return
+		}
+	}
+
+	check(p.program.Statements)
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		if block, ok := node.(*ast.BlockStatement); ok {
+			check(block.Statements)
+		}
+		return true
+	})
+}
+
+// NOTE: a return, or an if whose branches both return
+func returns(stmt ast.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStatement:
+		return true
+	case *ast.BlockStatement:
+		return blockReturns(stmt)
+	case *ast.ExpressionStatement:
+		ifExp, ok := stmt.Expression.(*ast.IfExpression)
+		return ok && blockReturns(ifExp.Consequence) && blockReturns(ifExp.Alternative)
+	}
+	return false
+}
+
+func blockReturns(block *ast.BlockStatement) bool {
+	if block == nil {
+		return false
+	}
+	for _, stmt := range block.Statements {
+		if returns(stmt) {
+			return true
+		}
+	}
+	return false
+}
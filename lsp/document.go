@@ -0,0 +1,193 @@
+package lsp
+
+import (
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/evaluator"
+	"mfiorek/waiig/lexer"
+	"mfiorek/waiig/parser"
+	"mfiorek/waiig/resolver"
+	"mfiorek/waiig/token"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// INFO: An open document - its text, parsed and resolved again on every change
+
+type document struct {
+	uri     string
+	version int
+	text    string
+	lines   []string
+
+	program     *ast.Program
+	diagnostics []parser.Diagnostic
+	names       *resolver.Result
+}
+
+func newDocument(uri string, version int, text string) *document {
+	p := parser.New(lexer.New(text))
+	program := p.ParseProgram()
+	names := resolver.New(evaluator.BuiltinNames()).Resolve(program)
+
+	// NOTE: the resolver's diagnostics only once the source parses - in a half-typed line the name that's used
+	// further on may be in the part that didn't parse
+	diagnostics := p.Diagnostics()
+	if len(diagnostics) == 0 {
+		diagnostics = names.Diagnostics
+	}
+
+	return &document{
+		uri:         uri,
+		version:     version,
+		text:        text,
+		lines:       strings.Split(text, "\n"),
+		program:     program,
+		diagnostics: diagnostics,
+		names:       names,
+	}
+}
+
+// INFO: ==================================== Positions ====================================
+
+// NOTE: a place in the source the way tokens have it - 1-based line and byte column
+type sourcePos struct {
+	line, column int
+}
+
+func (p sourcePos) before(other sourcePos) bool {
+	return p.line < other.line || p.line == other.line && p.column < other.column
+}
+
+func tokenStart(tok token.Token) sourcePos {
+	return sourcePos{line: tok.Line, column: tok.Column}
+}
+
+// NOTE: just past the token - the quotes of a string count too
+func tokenEnd(tok token.Token) sourcePos {
+	width := len(tok.Literal)
+	switch tok.Type {
+	case token.EOF:
+		width = 0
+	case token.STRING:
+		width += 2
+	}
+	return sourcePos{line: tok.Line, column: tok.Column + width}
+}
+
+// NOTE: from the first to the end of the last token of the nodes - the tokens of a node can come in any order
+// (an infix has its operator), so all of them are looked at
+func span(nodes ...ast.Node) (sourcePos, sourcePos) {
+	var start, end sourcePos
+	add := func(tok token.Token) {
+		if tok.Line == 0 {
+			return
+		}
+		if start.line == 0 || tokenStart(tok).before(start) {
+			start = tokenStart(tok)
+		}
+		if end.before(tokenEnd(tok)) {
+			end = tokenEnd(tok)
+		}
+	}
+
+	for _, node := range nodes {
+		ast.Inspect(node, func(n ast.Node) bool {
+			if tok, ok := ast.TokenOf(n); ok {
+				add(tok)
+			}
+			switch n := n.(type) {
+			case *ast.BlockStatement:
+				add(n.EndToken)
+			case *ast.MatchExpression:
+				add(n.EndToken)
+			}
+			return n != nil
+		})
+	}
+	return start, end
+}
+
+// NOTE: the LSP position of a source position - the column is clamped to the line, so a span running past it
+// (a string with a newline in it) ends at the end of its first line
+func (d *document) position(pos sourcePos) Position {
+	if pos.line < 1 {
+		return Position{}
+	}
+	if pos.line > len(d.lines) {
+		return d.endPosition()
+	}
+	line := d.lines[pos.line-1]
+	prefix := line[:min(max(pos.column-1, 0), len(line))]
+	return Position{Line: pos.line - 1, Character: utf16Length(prefix)}
+}
+
+// NOTE: the source position of an LSP position - a character past the end of the line is the end of the line
+func (d *document) sourcePos(pos Position) sourcePos {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return sourcePos{}
+	}
+	line := d.lines[pos.Line]
+	units, offset := 0, 0
+	for offset < len(line) && units < pos.Character {
+		r, size := utf8.DecodeRuneInString(line[offset:])
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+	return sourcePos{line: pos.Line + 1, column: offset + 1}
+}
+
+func (d *document) endPosition() Position {
+	last := len(d.lines) - 1
+	return Position{Line: last, Character: utf16Length(d.lines[last])}
+}
+
+func (d *document) rangeOf(start, end sourcePos) Range {
+	return Range{Start: d.position(start), End: d.position(end)}
+}
+
+func (d *document) tokenRange(tok token.Token) Range {
+	return d.rangeOf(tokenStart(tok), tokenEnd(tok))
+}
+
+func (d *document) nodeRange(nodes ...ast.Node) Range {
+	return d.rangeOf(span(nodes...))
+}
+
+func utf16Length(s string) int {
+	length := 0
+	for _, r := range s {
+		length += utf16.RuneLen(r)
+	}
+	return length
+}
+
+// INFO: ==================================== Diagnostics ====================================
+
+// NOTE: the parser's (or the resolver's) diagnostics the LSP way - a missing token at the end of the source (EOF) gets an empty range there
+func (d *document) lspDiagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, diag := range d.diagnostics {
+		tok := diag.Found
+		if tok.Line == 0 {
+			tok = token.Token{Type: token.ILLEGAL, Line: diag.Line, Column: diag.Column}
+		}
+
+		severity := severityError
+		if diag.Severity == parser.SeverityWarning {
+			severity = severityWarning
+		}
+		message := diag.Message
+		if diag.Hint != "" {
+			message += " (" + diag.Hint + ")"
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    d.tokenRange(tok),
+			Severity: severity,
+			Source:   "monkey",
+			Message:  message,
+		})
+	}
+	return diagnostics
+}
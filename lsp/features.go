@@ -0,0 +1,285 @@
+package lsp
+
+import (
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/evaluator"
+	"mfiorek/waiig/format"
+	"mfiorek/waiig/object"
+	"mfiorek/waiig/resolver"
+	"mfiorek/waiig/token"
+	"strings"
+)
+
+// INFO: The language features - everything works on the last parsed version of the document,
+// which is fine even with syntax errors, as the parser recovers and keeps the rest of the program
+
+// INFO: ==================================== Hover ====================================
+
+// NOTE: nil when there is nothing to tell about the position
+func (d *document) hover(pos sourcePos) *Hover {
+	ident := d.identAt(pos)
+	if ident == nil {
+		return nil
+	}
+
+	var contents string
+	if binding, ok := d.names.Identifiers[ident]; ok {
+		contents = codeBlock(d.signature(binding))
+	} else if doc := evaluator.BuiltinDoc(ident.Value); doc != "" {
+		signature, description, _ := strings.Cut(doc, " - ")
+		contents = codeBlock(signature) + "\n" + description
+	} else {
+		return nil
+	}
+
+	r := d.tokenRange(ident.Token)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: contents}, Range: &r}
+}
+
+func codeBlock(code string) string {
+	return "```monkey\n" + code + "\n```"
+}
+
+// NOTE: how a binding is shown in hovers - `let x: INTEGER` (the type only when it can be told), `let add = fn(a, b)`
+// or `(parameter) a`
+func (d *document) signature(binding *resolver.Binding) string {
+	name := binding.Name.Value
+	switch {
+	case binding.Kind != resolver.LetBinding:
+		return "(" + binding.Kind.String() + ") " + name
+	case isFunction(binding):
+		return "let " + name + " = " + functionSignature(binding.Value.(*ast.FunctionLiteral))
+	}
+	if typ := d.inferType(binding.Value, 0); typ != "" {
+		return "let " + name + ": " + typ
+	}
+	return "let " + name
+}
+
+func functionSignature(fn *ast.FunctionLiteral) string {
+	params := []string{}
+	for _, param := range fn.Parameters {
+		params = append(params, param.String())
+	}
+	if fn.Rest != nil {
+		params = append(params, "..."+fn.Rest.Value)
+	}
+	return "fn(" + strings.Join(params, ", ") + ")"
+}
+
+// NOTE: the object type the expression evaluates to, when it can be told without running it - "" otherwise.
+// Only what is sure is given: an INTEGER + a FLOAT is a FLOAT, but a call could give anything.
+func (d *document) inferType(exp ast.Expression, depth int) string {
+	// NOTE: let a = b; let b = a; would never end otherwise
+	if depth > 16 {
+		return ""
+	}
+
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return object.INTEGER_OBJ
+	case *ast.FloatLiteral:
+		return object.FLOAT_OBJ
+	case *ast.StringLiteral:
+		return object.STRING_OBJ
+	case *ast.Boolean:
+		return object.BOOLEAN_OBJ
+	case *ast.ArrayLiteral:
+		return object.ARRAY_OBJ
+	case *ast.HashLiteral:
+		return object.HASH_OBJ
+	case *ast.FunctionLiteral:
+		return object.FUNCTION_OBJ
+	case *ast.SliceExpression:
+		if left := d.inferType(exp.Left, depth+1); left == object.ARRAY_OBJ || left == object.STRING_OBJ {
+			return left
+		}
+	case *ast.Identifier:
+		if binding := d.names.Identifiers[exp]; binding != nil && binding.Kind == resolver.LetBinding {
+			return d.inferType(binding.Value, depth+1)
+		}
+	case *ast.PrefixExpression:
+		switch exp.Operator {
+		case "!":
+			return object.BOOLEAN_OBJ
+		case "~":
+			return object.INTEGER_OBJ
+		case "-":
+			if right := d.inferType(exp.Right, depth+1); right == object.INTEGER_OBJ || right == object.FLOAT_OBJ {
+				return right
+			}
+		}
+	case *ast.InfixExpression:
+		return d.inferInfixType(exp, depth)
+	}
+	return ""
+}
+
+// WARN: Helper method used only in inferType
+func (d *document) inferInfixType(exp *ast.InfixExpression, depth int) string {
+	switch exp.Operator {
+	case "==", "!=", "<", ">", "<=", ">=", "&&", "||":
+		return object.BOOLEAN_OBJ
+	case "&", "|", "^", "<<", ">>":
+		return object.INTEGER_OBJ
+	}
+
+	left, right := d.inferType(exp.Left, depth+1), d.inferType(exp.Right, depth+1)
+	isNumber := func(typ string) bool { return typ == object.INTEGER_OBJ || typ == object.FLOAT_OBJ }
+	switch {
+	case left == object.INTEGER_OBJ && right == object.INTEGER_OBJ:
+		return object.INTEGER_OBJ
+	case isNumber(left) && isNumber(right):
+		return object.FLOAT_OBJ
+	case exp.Operator == "+" && left == object.STRING_OBJ && right == object.STRING_OBJ:
+		return object.STRING_OBJ
+	}
+	return ""
+}
+
+// INFO: ==================================== Definition & references ====================================
+
+func (d *document) definition(pos sourcePos) *Location {
+	binding := d.bindingAt(pos)
+	if binding == nil {
+		return nil
+	}
+	return &Location{URI: d.uri, Range: d.tokenRange(binding.Name.Token)}
+}
+
+func (d *document) references(pos sourcePos, includeDeclaration bool) []Location {
+	binding := d.bindingAt(pos)
+	if binding == nil {
+		return nil
+	}
+
+	locations := []Location{}
+	if includeDeclaration {
+		locations = append(locations, Location{URI: d.uri, Range: d.tokenRange(binding.Name.Token)})
+	}
+	for _, use := range binding.Uses {
+		locations = append(locations, Location{URI: d.uri, Range: d.tokenRange(use.Token)})
+	}
+	return locations
+}
+
+// INFO: ==================================== Document symbols ====================================
+
+// NOTE: the lets of the program - the ones of a function's body are the children of the function
+func (d *document) documentSymbols(stmts []ast.Statement) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, stmt := range stmts {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok {
+			continue
+		}
+
+		if let.Name == nil {
+			symbols = append(symbols, d.destructuredSymbols(let)...)
+			continue
+		}
+
+		ds := DocumentSymbol{
+			Name:           let.Name.Value,
+			Kind:           symbolKindVariable,
+			Range:          d.nodeRange(let),
+			SelectionRange: d.tokenRange(let.Name.Token),
+		}
+		if fn, ok := let.Value.(*ast.FunctionLiteral); ok {
+			ds.Kind = symbolKindFunction
+			ds.Detail = functionSignature(fn)
+			if fn.Body != nil {
+				ds.Children = d.documentSymbols(fn.Body.Statements)
+			}
+		} else {
+			ds.Detail = d.inferType(let.Value, 0)
+		}
+		symbols = append(symbols, ds)
+	}
+	return symbols
+}
+
+// WARN: Helper method used only in documentSymbols - every name of a destructuring let is a symbol of its own
+func (d *document) destructuredSymbols(let *ast.LetStatement) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	if let.Pattern == nil {
+		return symbols
+	}
+
+	ast.Inspect(let.Pattern, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Identifier)
+		// NOTE: the identifiers in the defaults are uses, not definitions
+		if ok && d.names.Identifiers[ident] != nil && d.names.Identifiers[ident].Name == ident {
+			symbols = append(symbols, DocumentSymbol{
+				Name:           ident.Value,
+				Kind:           symbolKindVariable,
+				Range:          d.nodeRange(let),
+				SelectionRange: d.tokenRange(ident.Token),
+			})
+		}
+		return node != nil
+	})
+	return symbols
+}
+
+// INFO: ==================================== Completion ====================================
+
+// NOTE: the names visible at the position (the innermost scopes first), then the builtins and the keywords -
+// the client filters them by what has been typed already
+func (d *document) completion(pos sourcePos) []CompletionItem {
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+
+	for _, s := range d.scopesAt(pos) {
+		for _, name := range s.Names() {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			binding := s.Lookup(name)
+			kind := completionKindVariable
+			if isFunction(binding) {
+				kind = completionKindFunction
+			}
+			items = append(items, CompletionItem{Label: name, Kind: kind, Detail: d.signature(binding)})
+		}
+	}
+
+	for _, name := range evaluator.BuiltinNames() {
+		if seen[name] {
+			continue
+		}
+		signature, description, _ := strings.Cut(evaluator.BuiltinDoc(name), " - ")
+		kind := completionKindFunction
+		if strings.ToUpper(name) == name {
+			kind = completionKindConstant
+		}
+		items = append(items, CompletionItem{
+			Label:         name,
+			Kind:          kind,
+			Detail:        signature,
+			Documentation: &MarkupContent{Kind: "markdown", Value: description},
+		})
+	}
+
+	for _, keyword := range token.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: completionKindKeyword})
+	}
+	return items
+}
+
+// INFO: ==================================== Formatting ====================================
+
+// NOTE: one edit replacing the whole document (none when it is formatted already) - nil when it has syntax errors,
+// as the formatter can't format those
+func (d *document) formatting() []TextEdit {
+	formatted, err := format.Source(d.text)
+	if err != nil {
+		return nil
+	}
+	if formatted == d.text {
+		return []TextEdit{}
+	}
+	return []TextEdit{{Range: Range{End: d.endPosition()}, NewText: formatted}}
+}
@@ -0,0 +1,598 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// INFO: ==================================== Tests ====================================
+
+func TestLifecycle(t *testing.T) {
+	c := newTestClient(t)
+
+	err := c.request("textDocument/hover", hoverParams("file:///a.mk", 0, 0), nil)
+	testResponseError(t, err, codeServerNotInitialized)
+
+	var result InitializeResult
+	if err := c.request("initialize", map[string]any{"capabilities": map[string]any{}}, &result); err != nil {
+		t.Fatalf("initialize failed: %s", err)
+	}
+	expected := ServerCapabilities{
+		TextDocumentSync:           syncFull,
+		HoverProvider:              true,
+		DefinitionProvider:         true,
+		ReferencesProvider:         true,
+		DocumentSymbolProvider:     true,
+		DocumentFormattingProvider: true,
+	}
+	if !reflect.DeepEqual(result.Capabilities, expected) {
+		t.Errorf("wrong capabilities. expected=%+v, got=%+v", expected, result.Capabilities)
+	}
+
+	testResponseError(t, c.request("workspace/symbol", map[string]any{}, nil), codeMethodNotFound)
+	testResponseError(t, c.request("textDocument/hover", hoverParams("file:///missing.mk", 0, 0), nil), codeInvalidParams)
+
+	var shutdown any = "not null"
+	if err := c.request("shutdown", nil, &shutdown); err != nil || shutdown != nil {
+		t.Fatalf("shutdown should give null. got=%v (error %v)", shutdown, err)
+	}
+	testResponseError(t, c.request("textDocument/hover", hoverParams("file:///a.mk", 0, 0), nil), codeInvalidRequest)
+
+	c.notify("exit", nil)
+	if err := c.wait(); err != nil {
+		t.Errorf("Run should give nil after shutdown and exit. got=%v", err)
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := newInitializedClient(t)
+	c.notify("exit", nil)
+	if err := c.wait(); err != ErrExitWithoutShutdown {
+		t.Errorf("Run should give ErrExitWithoutShutdown. got=%v", err)
+	}
+}
+
+func TestInvalidMessage(t *testing.T) {
+	c := newInitializedClient(t)
+
+	body := "{not json"
+	io.WriteString(c.out, "Content-Length: "+strconv.Itoa(len(body))+"\r\n\r\n"+body)
+	msg := c.next()
+	// NOTE: a null id decodes to a nil ID
+	if msg.Error == nil || msg.Error.Code != codeParseError || msg.ID != nil {
+		t.Fatalf("expected a parse error for the null id. got=%+v", msg)
+	}
+
+	// NOTE: the server keeps serving after it
+	if err := c.request("shutdown", nil, nil); err != nil {
+		t.Fatalf("shutdown failed: %s", err)
+	}
+}
+
+func TestContentLengthLimit(t *testing.T) {
+	c := newInitializedClient(t)
+
+	io.WriteString(c.out, "Content-Length: 9223372036854775807\r\n\r\n")
+	err := c.wait()
+	if err == nil || err.Error() != "invalid Content-Length: 9223372036854775807 is above the limit of 67108864 bytes" {
+		t.Errorf("Run should give the Content-Length error. got=%v", err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newInitializedClient(t)
+
+	c.open("file:///a.mk", "let x = 1;\nlet y = ;\nlet 😀z = 2;")
+	params := c.diagnostics()
+	expected := []Diagnostic{
+		{Range: rng(1, 8, 1, 9), Severity: severityError, Source: "monkey", Message: "no prefix parse function for ; found"},
+		{Range: rng(2, 4, 2, 6), Severity: severityError, Source: "monkey", Message: "expected next token to be IDENT, got ILLEGAL instead"},
+	}
+	if params.URI != "file:///a.mk" || params.Version != 1 {
+		t.Errorf("wrong document. got=%s (version %d)", params.URI, params.Version)
+	}
+	testDiagnostics(t, params.Diagnostics, expected)
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: "file:///a.mk", Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let x = 1;\nlet y = x +"}},
+	})
+	params = c.diagnostics()
+	if params.Version != 2 {
+		t.Errorf("wrong version. expected=2, got=%d", params.Version)
+	}
+	testDiagnostics(t, params.Diagnostics, []Diagnostic{
+		{Range: rng(1, 11, 1, 11), Severity: severityError, Source: "monkey", Message: "no prefix parse function for EOF found"},
+	})
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: "file:///a.mk", Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let x = 1;\nlet y = x + 1;\nputs(yy);"}},
+	})
+	testDiagnostics(t, c.diagnostics().Diagnostics, []Diagnostic{
+		{Range: rng(1, 4, 1, 5), Severity: severityWarning, Source: "monkey", Message: "variable y is never used"},
+		{Range: rng(2, 5, 2, 7), Severity: severityError, Source: "monkey", Message: "identifier not found: yy (did you mean `y`?)"},
+	})
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: "file:///a.mk", Version: 4},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let x = 1;\nlet y = x + 1;"}},
+	})
+	testDiagnostics(t, c.diagnostics().Diagnostics, []Diagnostic{
+		{Range: rng(1, 4, 1, 5), Severity: severityWarning, Source: "monkey", Message: "variable y is never used"},
+	})
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: "file:///a.mk"}})
+	testDiagnostics(t, c.diagnostics().Diagnostics, []Diagnostic{})
+	testResponseError(t, c.request("textDocument/hover", hoverParams("file:///a.mk", 0, 4), nil), codeInvalidParams)
+}
+
+func TestHover(t *testing.T) {
+	source := `let add = fn(a, b) { a + b };
+let x = 1 + 2.5;
+let s = "😀" + "!"; s;
+let n = len(s);
+let [first, ...others] = [n, x];
+add(x, n) * PI`
+
+	tests := []struct {
+		line, character int
+		expected        string // "" when there should be no hover
+		expectedRange   Range
+	}{
+		{5, 0, "```monkey\nlet add = fn(a, b)\n```", rng(5, 0, 5, 3)},
+		{5, 3, "```monkey\nlet add = fn(a, b)\n```", rng(5, 0, 5, 3)}, // just after the name
+		{0, 21, "```monkey\n(parameter) a\n```", rng(0, 21, 0, 22)},
+		{1, 5, "```monkey\nlet x: FLOAT\n```", rng(1, 4, 1, 5)},
+		{2, 20, "```monkey\nlet s: STRING\n```", rng(2, 20, 2, 21)}, // the emoji takes two UTF-16 units
+		{3, 4, "```monkey\nlet n\n```", rng(3, 4, 3, 5)},
+		{4, 6, "```monkey\nlet first\n```", rng(4, 5, 4, 10)},
+		{3, 9, "```monkey\nlen(x)\n```\nthe number of elements of an ARRAY or the number of characters of a STRING", rng(3, 8, 3, 11)},
+		{5, 13, "```monkey\nPI\n```\nthe ratio of a circle's circumference to its diameter (FLOAT)", rng(5, 12, 5, 14)},
+		{1, 9, "", Range{}},  // the 1
+		{0, 30, "", Range{}}, // past the end of the line
+	}
+
+	c := newInitializedClient(t)
+	c.open("file:///hover.mk", source)
+	c.diagnostics()
+
+	for _, tt := range tests {
+		var hover *Hover
+		if err := c.request("textDocument/hover", hoverParams("file:///hover.mk", tt.line, tt.character), &hover); err != nil {
+			t.Fatalf("hover failed: %s", err)
+		}
+		if tt.expected == "" {
+			if hover != nil {
+				t.Errorf("expected no hover at %d:%d. got=%q", tt.line, tt.character, hover.Contents.Value)
+			}
+			continue
+		}
+		if hover == nil {
+			t.Errorf("expected a hover at %d:%d. got none", tt.line, tt.character)
+			continue
+		}
+		if hover.Contents.Kind != "markdown" || hover.Contents.Value != tt.expected {
+			t.Errorf("wrong hover at %d:%d. expected=%q, got=%q", tt.line, tt.character, tt.expected, hover.Contents.Value)
+		}
+		if hover.Range == nil || *hover.Range != tt.expectedRange {
+			t.Errorf("wrong hover range at %d:%d. expected=%+v, got=%+v", tt.line, tt.character, tt.expectedRange, hover.Range)
+		}
+	}
+}
+
+func TestDefinitionAndReferences(t *testing.T) {
+	source := `let fib = fn(n) {
+  if (n < 2) { return n; }
+  fib(n - 1) + fib(n - 2)
+};
+let n = 10;
+let later = fn() { helper(n) };
+let helper = fn(x, ...rest) { match (x) { [n, ...others] => n + len(others), _ => x } };
+fib(n)`
+
+	tests := []struct {
+		line, character    int
+		expectedDefinition *Range
+		expectedReferences []Range // with the declaration
+	}{
+		// NOTE: a parameter used in the body - shadows the global n
+		{1, 6, ptr(rng(0, 13, 0, 14)), []Range{rng(0, 13, 0, 14), rng(1, 6, 1, 7), rng(1, 22, 1, 23), rng(2, 6, 2, 7), rng(2, 19, 2, 20)}},
+		// NOTE: recursion
+		{2, 2, ptr(rng(0, 4, 0, 7)), []Range{rng(0, 4, 0, 7), rng(2, 2, 2, 5), rng(2, 15, 2, 18), rng(7, 0, 7, 3)}},
+		// NOTE: the global n, used in a function and at the top
+		{7, 4, ptr(rng(4, 4, 4, 5)), []Range{rng(4, 4, 4, 5), rng(5, 26, 5, 27), rng(7, 4, 7, 5)}},
+		// NOTE: a function defined after the one calling it
+		{5, 19, ptr(rng(6, 4, 6, 10)), []Range{rng(6, 4, 6, 10), rng(5, 19, 5, 25)}},
+		// NOTE: the binding of a match arm and the rest parameter
+		{6, 60, ptr(rng(6, 43, 6, 44)), []Range{rng(6, 43, 6, 44), rng(6, 60, 6, 61)}},
+		{6, 51, ptr(rng(6, 49, 6, 55)), []Range{rng(6, 49, 6, 55), rng(6, 68, 6, 74)}},
+		// NOTE: unused - only the declaration
+		{6, 23, ptr(rng(6, 22, 6, 26)), []Range{rng(6, 22, 6, 26)}},
+		// NOTE: a builtin and nothing at all
+		{6, 64, nil, nil},
+		{3, 0, nil, nil},
+	}
+
+	c := newInitializedClient(t)
+	c.open("file:///defs.mk", source)
+	c.diagnostics()
+
+	for _, tt := range tests {
+		var definition *Location
+		if err := c.request("textDocument/definition", hoverParams("file:///defs.mk", tt.line, tt.character), &definition); err != nil {
+			t.Fatalf("definition failed: %s", err)
+		}
+		switch {
+		case tt.expectedDefinition == nil && definition != nil:
+			t.Errorf("expected no definition at %d:%d. got=%+v", tt.line, tt.character, definition)
+		case tt.expectedDefinition != nil && definition == nil:
+			t.Errorf("expected a definition at %d:%d. got none", tt.line, tt.character)
+		case definition != nil && (definition.URI != "file:///defs.mk" || definition.Range != *tt.expectedDefinition):
+			t.Errorf("wrong definition at %d:%d. expected=%+v, got=%+v", tt.line, tt.character, *tt.expectedDefinition, definition)
+		}
+
+		var references []Location
+		params := ReferenceParams{TextDocumentPositionParams: hoverParams("file:///defs.mk", tt.line, tt.character), Context: ReferenceContext{IncludeDeclaration: true}}
+		if err := c.request("textDocument/references", params, &references); err != nil {
+			t.Fatalf("references failed: %s", err)
+		}
+		got := []Range{}
+		for _, ref := range references {
+			got = append(got, ref.Range)
+		}
+		if tt.expectedReferences == nil && references != nil || tt.expectedReferences != nil && !slices.Equal(got, tt.expectedReferences) {
+			t.Errorf("wrong references at %d:%d. expected=%+v, got=%+v", tt.line, tt.character, tt.expectedReferences, got)
+		}
+	}
+
+	// NOTE: without the declaration
+	var references []Location
+	params := ReferenceParams{TextDocumentPositionParams: hoverParams("file:///defs.mk", 4, 4)}
+	if err := c.request("textDocument/references", params, &references); err != nil {
+		t.Fatalf("references failed: %s", err)
+	}
+	if len(references) != 2 || references[0].Range != rng(5, 26, 5, 27) {
+		t.Errorf("wrong references without the declaration. got=%+v", references)
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	source := `let version = "1.0";
+let area = fn(w, h) {
+  let size = w * h;
+  let scale = fn(k) { let scaled = size * k; scaled };
+  scale(1)
+};
+let [a, b = version] = [1];
+area(a, 2)`
+
+	expected := []DocumentSymbol{
+		{Name: "version", Detail: "STRING", Kind: symbolKindVariable, Range: rng(0, 0, 0, 19), SelectionRange: rng(0, 4, 0, 11)},
+		{Name: "area", Detail: "fn(w, h)", Kind: symbolKindFunction, Range: rng(1, 0, 5, 1), SelectionRange: rng(1, 4, 1, 8), Children: []DocumentSymbol{
+			{Name: "size", Kind: symbolKindVariable, Range: rng(2, 2, 2, 18), SelectionRange: rng(2, 6, 2, 10)},
+			{Name: "scale", Detail: "fn(k)", Kind: symbolKindFunction, Range: rng(3, 2, 3, 53), SelectionRange: rng(3, 6, 3, 11), Children: []DocumentSymbol{
+				{Name: "scaled", Kind: symbolKindVariable, Range: rng(3, 22, 3, 43), SelectionRange: rng(3, 26, 3, 32)},
+			}},
+		}},
+		{Name: "a", Kind: symbolKindVariable, Range: rng(6, 0, 6, 25), SelectionRange: rng(6, 5, 6, 6)},
+		{Name: "b", Kind: symbolKindVariable, Range: rng(6, 0, 6, 25), SelectionRange: rng(6, 8, 6, 9)},
+	}
+
+	c := newInitializedClient(t)
+	c.open("file:///symbols.mk", source)
+	c.diagnostics()
+
+	var symbols []DocumentSymbol
+	if err := c.request("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: "file:///symbols.mk"}}, &symbols); err != nil {
+		t.Fatalf("documentSymbol failed: %s", err)
+	}
+	if !reflect.DeepEqual(symbols, expected) {
+		got, _ := json.Marshal(symbols)
+		t.Errorf("wrong symbols. got=%s", got)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	source := `let total = 0;
+let add = fn(amount) {
+  let total = amount;
+  to
+};
+t`
+
+	tests := []struct {
+		line, character int
+		expected        []CompletionItem // the items that have to be there, in this order
+		unexpected      []string
+	}{
+		{3, 4, []CompletionItem{
+			{Label: "amount", Kind: completionKindVariable, Detail: "(parameter) amount"},
+			{Label: "total", Kind: completionKindVariable, Detail: "let total"},
+			{Label: "add", Kind: completionKindFunction, Detail: "let add = fn(amount)"},
+			{Label: "PI", Kind: completionKindConstant, Detail: "PI", Documentation: &MarkupContent{Kind: "markdown", Value: "the ratio of a circle's circumference to its diameter (FLOAT)"}},
+			{Label: "to_string", Kind: completionKindFunction, Detail: "to_string(x)", Documentation: &MarkupContent{Kind: "markdown", Value: "the printed form of the value"}},
+			{Label: "let", Kind: completionKindKeyword},
+		}, nil},
+		{5, 1, []CompletionItem{
+			{Label: "add", Kind: completionKindFunction, Detail: "let add = fn(amount)"},
+			{Label: "total", Kind: completionKindVariable, Detail: "let total: INTEGER"},
+			{Label: "len", Kind: completionKindFunction, Detail: "len(x)", Documentation: &MarkupContent{Kind: "markdown", Value: "the number of elements of an ARRAY or the number of characters of a STRING"}},
+			{Label: "match", Kind: completionKindKeyword},
+		}, []string{"amount"}},
+	}
+
+	c := newInitializedClient(t)
+	c.open("file:///complete.mk", source)
+	c.diagnostics()
+
+	for _, tt := range tests {
+		var items []CompletionItem
+		if err := c.request("textDocument/completion", hoverParams("file:///complete.mk", tt.line, tt.character), &items); err != nil {
+			t.Fatalf("completion failed: %s", err)
+		}
+
+		labels := map[string]int{}
+		for idx, item := range items {
+			if _, ok := labels[item.Label]; ok {
+				t.Errorf("%s is completed twice at %d:%d", item.Label, tt.line, tt.character)
+			}
+			labels[item.Label] = idx
+		}
+
+		last := -1
+		for _, expected := range tt.expected {
+			idx, ok := labels[expected.Label]
+			if !ok {
+				t.Errorf("%s isn't completed at %d:%d", expected.Label, tt.line, tt.character)
+				continue
+			}
+			if !reflect.DeepEqual(items[idx], expected) {
+				t.Errorf("wrong completion at %d:%d. expected=%+v, got=%+v", tt.line, tt.character, expected, items[idx])
+			}
+			if idx < last {
+				t.Errorf("%s is completed too early at %d:%d", expected.Label, tt.line, tt.character)
+			}
+			last = idx
+		}
+		for _, label := range tt.unexpected {
+			if _, ok := labels[label]; ok {
+				t.Errorf("%s shouldn't be completed at %d:%d", label, tt.line, tt.character)
+			}
+		}
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected []TextEdit // nil when the result should be null
+	}{
+		{"let x=1\nputs( x )", []TextEdit{{Range: rng(0, 0, 1, 9), NewText: "let x = 1;\nputs(x);\n"}}},
+		{"let x = 1;\nputs(x);\n", []TextEdit{}},
+		{"let x = ;", nil},
+	}
+
+	c := newInitializedClient(t)
+	for _, tt := range tests {
+		c.open("file:///format.mk", tt.source)
+		c.diagnostics()
+
+		var edits []TextEdit
+		if err := c.request("textDocument/formatting", DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: "file:///format.mk"}}, &edits); err != nil {
+			t.Fatalf("formatting failed: %s", err)
+		}
+		if tt.expected == nil && edits != nil || !reflect.DeepEqual(edits, tt.expected) {
+			t.Errorf("wrong edits for %q. expected=%+v, got=%+v", tt.source, tt.expected, edits)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	d := newDocument("file:///positions.mk", 1, "let a = \"é😀\";\r\nlet b = 1;\n")
+
+	tests := []struct {
+		source sourcePos
+		lsp    Position
+	}{
+		{sourcePos{1, 1}, Position{0, 0}},
+		{sourcePos{1, 10}, Position{0, 9}},  // é
+		{sourcePos{1, 12}, Position{0, 10}}, // 😀
+		{sourcePos{1, 16}, Position{0, 12}}, // "
+		{sourcePos{2, 5}, Position{1, 4}},
+		{sourcePos{3, 1}, Position{2, 0}},
+	}
+
+	for _, tt := range tests {
+		if got := d.position(tt.source); got != tt.lsp {
+			t.Errorf("wrong position of %+v. expected=%+v, got=%+v", tt.source, tt.lsp, got)
+		}
+		if got := d.sourcePos(tt.lsp); got != tt.source {
+			t.Errorf("wrong source position of %+v. expected=%+v, got=%+v", tt.lsp, tt.source, got)
+		}
+	}
+
+	if got := d.endPosition(); got != (Position{2, 0}) {
+		t.Errorf("wrong end position. got=%+v", got)
+	}
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+// NOTE: a client talking to a server running in a goroutine - the messages of the server are read in another one,
+// as a server writing diagnostics would otherwise block a client writing its next request
+type testClient struct {
+	t        *testing.T
+	out      io.Writer
+	messages chan *message
+	done     chan error
+	nextID   int
+	// NOTE: notifications read while waiting for a response
+	notifications []*message
+}
+
+type clientMessage struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+func newTestClient(t *testing.T) *testClient {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &testClient{t: t, out: clientOut, messages: make(chan *message, 100), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer(serverIn, serverOut).Run()
+		serverOut.Close()
+	}()
+	go func() {
+		in := newStream(clientIn, nil)
+		for {
+			msg, err := in.read()
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() { clientOut.Close() })
+
+	return c
+}
+
+func newInitializedClient(t *testing.T) *testClient {
+	c := newTestClient(t)
+	if err := c.request("initialize", map[string]any{"capabilities": map[string]any{}}, nil); err != nil {
+		t.Fatalf("initialize failed: %s", err)
+	}
+	c.notify("initialized", map[string]any{})
+	return c
+}
+
+func (c *testClient) send(msg clientMessage) {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatalf("cannot encode message: %s", err)
+	}
+	io.WriteString(c.out, "Content-Length: "+strconv.Itoa(len(body))+"\r\n\r\n"+string(body))
+}
+
+func (c *testClient) next() *message {
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatalf("the server closed the connection")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timed out waiting for the server")
+	}
+	return nil
+}
+
+// NOTE: the result is decoded into result (when not nil), the error of the response is given back
+func (c *testClient) request(method string, params any, result any) *responseError {
+	c.nextID++
+	c.send(clientMessage{ID: c.nextID, Method: method, Params: params})
+
+	for {
+		msg := c.next()
+		if msg.ID == nil {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+		if string(*msg.ID) != strconv.Itoa(c.nextID) {
+			c.t.Fatalf("response to the wrong request. expected id=%d, got=%s", c.nextID, *msg.ID)
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("cannot decode result of %s: %s", method, err)
+			}
+		}
+		return nil
+	}
+}
+
+func (c *testClient) notify(method string, params any) {
+	c.send(clientMessage{Method: method, Params: params})
+}
+
+func (c *testClient) open(uri, text string) {
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "monkey", Version: 1, Text: text},
+	})
+}
+
+// NOTE: the next publishDiagnostics notification
+func (c *testClient) diagnostics() PublishDiagnosticsParams {
+	for {
+		var msg *message
+		if len(c.notifications) > 0 {
+			msg, c.notifications = c.notifications[0], c.notifications[1:]
+		} else {
+			msg = c.next()
+		}
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			c.t.Fatalf("cannot decode diagnostics: %s", err)
+		}
+		return params
+	}
+}
+
+func (c *testClient) wait() error {
+	select {
+	case err := <-c.done:
+		return err
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timed out waiting for the server to exit")
+	}
+	return nil
+}
+
+func testResponseError(t *testing.T, err *responseError, code int) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("expected an error with code %d. got none", code)
+	} else if err.Code != code {
+		t.Errorf("wrong error code. expected=%d, got=%d (%s)", code, err.Code, err.Message)
+	}
+}
+
+func testDiagnostics(t *testing.T, got, expected []Diagnostic) {
+	t.Helper()
+	if got == nil {
+		t.Errorf("diagnostics should be an empty list, not null")
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("wrong diagnostics.\nexpected=%+v\ngot=     %+v", expected, got)
+	}
+}
+
+func hoverParams(uri string, line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: Position{line, character}}
+}
+
+func rng(startLine, startCharacter, endLine, endCharacter int) Range {
+	return Range{Start: Position{startLine, startCharacter}, End: Position{endLine, endCharacter}}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
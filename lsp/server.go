@@ -0,0 +1,200 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// INFO: A Language Server Protocol server for Monkey - one client, talking JSON-RPC over a pair of streams (stdin/stdout).
+// Diagnostics are published whenever a document is opened or changed, and the server answers hover, definition,
+// references, documentSymbol, completion and formatting requests. Requests are handled one after the other.
+
+type Server struct {
+	stream    *stream
+	documents map[string]*document
+
+	initialized bool
+	shutdown    bool
+}
+
+// NOTE: Run returns it when the client sends exit without asking for a shutdown first (or just disconnects) -
+// the server is supposed to exit with 1 then
+var ErrExitWithoutShutdown = errors.New("lsp: exit without shutdown")
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{stream: newStream(in, out), documents: map[string]*document{}}
+}
+
+// NOTE: serves until the client sends exit - nil when it asked for a shutdown before
+func (s *Server) Run() error {
+	for {
+		msg, err := s.stream.read()
+		var rpcErr *responseError
+		switch {
+		case err == io.EOF:
+			return ErrExitWithoutShutdown
+		case errors.As(err, &rpcErr):
+			// NOTE: the id of a message that isn't JSON can't be known, JSON-RPC says it's null then
+			if err := s.stream.write(errorResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: rpcErr}); err != nil {
+				return err
+			}
+			continue
+		case err != nil:
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		if msg.ID == nil {
+			err = s.handleNotification(msg)
+		} else {
+			err = s.handleRequest(msg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// NOTE: the error is only given back when the response couldn't be written - the errors of the request are its response
+func (s *Server) handleRequest(msg *message) error {
+	result, rpcErr := s.dispatch(msg.Method, msg.Params)
+	if rpcErr != nil {
+		return s.stream.write(errorResponse{JSONRPC: "2.0", ID: *msg.ID, Error: rpcErr})
+	}
+	return s.stream.write(response{JSONRPC: "2.0", ID: *msg.ID, Result: result})
+}
+
+func (s *Server) dispatch(method string, params json.RawMessage) (any, *responseError) {
+	if !s.initialized && method != "initialize" {
+		return nil, &responseError{Code: codeServerNotInitialized, Message: "the server is not initialized yet"}
+	}
+	if s.shutdown {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "the server is shutting down"}
+	}
+
+	switch method {
+	case "initialize":
+		s.initialized = true
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:           syncFull,
+				HoverProvider:              true,
+				DefinitionProvider:         true,
+				ReferencesProvider:         true,
+				DocumentSymbolProvider:     true,
+				CompletionProvider:         CompletionOptions{},
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "monkey"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/hover":
+		return handle(s, params, func(d *document, p TextDocumentPositionParams) any {
+			return d.hover(d.sourcePos(p.Position))
+		})
+	case "textDocument/definition":
+		return handle(s, params, func(d *document, p TextDocumentPositionParams) any {
+			return d.definition(d.sourcePos(p.Position))
+		})
+	case "textDocument/references":
+		return handle(s, params, func(d *document, p ReferenceParams) any {
+			return d.references(d.sourcePos(p.Position), p.Context.IncludeDeclaration)
+		})
+	case "textDocument/documentSymbol":
+		return handle(s, params, func(d *document, p DocumentSymbolParams) any {
+			return d.documentSymbols(d.program.Statements)
+		})
+	case "textDocument/completion":
+		return handle(s, params, func(d *document, p TextDocumentPositionParams) any {
+			return d.completion(d.sourcePos(p.Position))
+		})
+	case "textDocument/formatting":
+		return handle(s, params, func(d *document, p DocumentFormattingParams) any {
+			return d.formatting()
+		})
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", method)}
+}
+
+// NOTE: the params of every textDocument request start with the document they are about
+type documentParams interface {
+	TextDocumentPositionParams | ReferenceParams | DocumentSymbolParams | DocumentFormattingParams
+}
+
+// WARN: Helper method used only in dispatch - decodes the params and finds their document
+func handle[P documentParams](s *Server, params json.RawMessage, f func(*document, P) any) (any, *responseError) {
+	var p P
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+
+	var uri string
+	switch p := any(p).(type) {
+	case TextDocumentPositionParams:
+		uri = p.TextDocument.URI
+	case ReferenceParams:
+		uri = p.TextDocument.URI
+	case DocumentSymbolParams:
+		uri = p.TextDocument.URI
+	case DocumentFormattingParams:
+		uri = p.TextDocument.URI
+	}
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("document not open: %s", uri)}
+	}
+	return f(d, p), nil
+}
+
+// NOTE: notifications get no response, so the ones that aren't known (or have bad params) are just ignored
+func (s *Server) handleNotification(msg *message) error {
+	if !s.initialized {
+		return nil
+	}
+
+	switch msg.Method {
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return nil
+		}
+		item := params.TextDocument
+		return s.update(newDocument(item.URI, item.Version, item.Text))
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		// NOTE: with full sync the last change has the whole text
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.update(newDocument(params.TextDocument.URI, params.TextDocument.Version, text))
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return nil
+		}
+		delete(s.documents, params.TextDocument.URI)
+		// NOTE: the diagnostics of a closed document are cleared, so they don't stay around in the client
+		return s.publishDiagnostics(PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+	}
+	return nil
+}
+
+func (s *Server) update(d *document) error {
+	s.documents[d.uri] = d
+	return s.publishDiagnostics(PublishDiagnosticsParams{URI: d.uri, Version: d.version, Diagnostics: d.lspDiagnostics()})
+}
+
+func (s *Server) publishDiagnostics(params PublishDiagnosticsParams) error {
+	return s.stream.write(notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: params})
+}
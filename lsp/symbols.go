@@ -0,0 +1,63 @@
+package lsp
+
+import (
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/resolver"
+	"slices"
+)
+
+// INFO: Queries on what the names of a document refer to - the resolver finds the bindings, these find them by position
+
+// NOTE: the identifier the position is in (or right after, where the cursor is when typing), nil when there is none
+func (d *document) identAt(pos sourcePos) *ast.Identifier {
+	for ident := range d.names.Identifiers {
+		if identContains(ident, pos) {
+			return ident
+		}
+	}
+	for _, ident := range d.names.Unresolved {
+		if identContains(ident, pos) {
+			return ident
+		}
+	}
+	return nil
+}
+
+func (d *document) bindingAt(pos sourcePos) *resolver.Binding {
+	ident := d.identAt(pos)
+	if ident == nil {
+		return nil
+	}
+	return d.names.Identifiers[ident]
+}
+
+// NOTE: the scopes the position is in, the innermost first - the global one covers the whole document
+func (d *document) scopesAt(pos sourcePos) []*resolver.Scope {
+	var scopes []*resolver.Scope
+	for _, s := range d.names.Scopes {
+		start, end := span(s.Nodes...)
+		if s.Parent == nil || !pos.before(start) && !end.before(pos) {
+			scopes = append(scopes, s)
+		}
+	}
+	slices.SortStableFunc(scopes, func(a, b *resolver.Scope) int { return depth(b) - depth(a) })
+	return scopes
+}
+
+func depth(s *resolver.Scope) int {
+	d := 0
+	for current := s.Parent; current != nil; current = current.Parent {
+		d++
+	}
+	return d
+}
+
+func isFunction(binding *resolver.Binding) bool {
+	_, ok := binding.Value.(*ast.FunctionLiteral)
+	return binding.Kind == resolver.LetBinding && ok
+}
+
+func identContains(ident *ast.Identifier, pos sourcePos) bool {
+	return ident.Token.Line == pos.line &&
+		ident.Token.Column <= pos.column && pos.column <= ident.Token.Column+len(ident.Value)
+}
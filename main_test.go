@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunFmt(t *testing.T) {
+	dir := t.TempDir()
+	messy := filepath.Join(dir, "messy.mk")
+	clean := filepath.Join(dir, "clean.mk")
+	if err := os.WriteFile(messy, []byte("let x=1\nlet y=2\nputs(x+y)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(clean, []byte("let x = 1;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args             []string
+		stdin            string
+		expectedCode     int
+		expectedStdout   string
+		expectedStderrIn string
+	}{
+		{[]string{}, "let a=[1,2]", 0, "let a = [1, 2];\n", ""},
+		{[]string{"-check", clean}, "", 0, "", ""},
+		{[]string{"-check", messy, clean}, "", 1, messy + "\n", ""},
+		{[]string{"-d", clean}, "", 0, "", ""},
+		{[]string{"-d", messy}, "", 0, "--- " + messy + "\n+++ " + messy + "\n@@ -1,3 +1,3 @@\n-let x=1\n-let y=2\n-puts(x+y)\n+let x = 1;\n+let y = 2;\n+puts(x + y);\n", ""},
+		{[]string{}, "let = 1", 2, "", "<stdin>: parser errors:"},
+		{[]string{"-w"}, "", 2, "", "-w needs files"},
+		{[]string{filepath.Join(dir, "missing.mk")}, "", 2, "", "missing.mk"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := runFmt(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+		if code != tt.expectedCode {
+			t.Errorf("runFmt(%v) - wrong exit code. expected=%d, got=%d (stderr=%q)", tt.args, tt.expectedCode, code, stderr.String())
+		}
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("runFmt(%v) - wrong stdout.\nexpected=%q\ngot=     %q", tt.args, tt.expectedStdout, stdout.String())
+		}
+		if !strings.Contains(stderr.String(), tt.expectedStderrIn) {
+			t.Errorf("runFmt(%v) - stderr %q doesn't contain %q", tt.args, stderr.String(), tt.expectedStderrIn)
+		}
+	}
+
+	// NOTE: -w rewrites the file, after which it passes -check
+	var stdout, stderr bytes.Buffer
+	if code := runFmt([]string{"-w", messy}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("runFmt(-w) - wrong exit code. expected=0, got=%d (stderr=%q)", code, stderr.String())
+	}
+	written, _ := os.ReadFile(messy)
+	if string(written) != "let x = 1;\nlet y = 2;\nputs(x + y);\n" {
+		t.Errorf("runFmt(-w) - wrong file content. got=%q", written)
+	}
+	if code := runFmt([]string{"-check", messy}, nil, &stdout, &stderr); code != 0 {
+		t.Errorf("runFmt(-check) after -w - wrong exit code. expected=0, got=%d", code)
+	}
+}
+
+func TestRunLsp(t *testing.T) {
+	frame := func(messages ...string) string {
+		var out strings.Builder
+		for _, msg := range messages {
+			fmt.Fprintf(&out, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+		}
+		return out.String()
+	}
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{}}}`
+	shutdown := `{"jsonrpc":"2.0","id":2,"method":"shutdown"}`
+	exit := `{"jsonrpc":"2.0","method":"exit"}`
+
+	tests := []struct {
+		args             []string
+		stdin            string
+		expectedCode     int
+		expectedStdoutIn string
+		expectedStderrIn string
+	}{
+		{[]string{}, frame(initialize, shutdown, exit), 0, `"id":2,"result":null`, ""},
+		{[]string{}, frame(initialize, exit), 1, `"serverInfo":{"name":"monkey"}`, ""},
+		{[]string{}, frame(initialize), 1, "", ""},
+		{[]string{}, "Content-Length: x\r\n\r\n", 1, "", "invalid Content-Length"},
+		{[]string{"-v"}, "", 2, "", "usage: waiig lsp"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := runLsp(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+		if code != tt.expectedCode {
+			t.Errorf("runLsp(%v, %q) - wrong exit code. expected=%d, got=%d (stderr=%q)", tt.args, tt.stdin, tt.expectedCode, code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), tt.expectedStdoutIn) {
+			t.Errorf("runLsp(%v, %q) - stdout %q doesn't contain %q", tt.args, tt.stdin, stdout.String(), tt.expectedStdoutIn)
+		}
+		if !strings.Contains(stderr.String(), tt.expectedStderrIn) {
+			t.Errorf("runLsp(%v, %q) - stderr %q doesn't contain %q", tt.args, tt.stdin, stderr.String(), tt.expectedStderrIn)
+		}
+	}
+}
+
+func TestRunLint(t *testing.T) {
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.mk")
+	dirty := filepath.Join(dir, "dirty.mk")
+	config := filepath.Join(dir, "lint.json")
+	badConfig := filepath.Join(dir, "bad.json")
+	os.WriteFile(clean, []byte("let x = 1;\nputs(x);\n"), 0o644)
+	os.WriteFile(dirty, []byte("let x = 1;\nputs(x == true);\n"), 0o644)
+	os.WriteFile(config, []byte(`{"rules": {"bool-comparison": false}}`), 0o644)
+	os.WriteFile(badConfig, []byte(`{"rules": {"nope": false}}`), 0o644)
+
+	tests := []struct {
+		args             []string
+		stdin            string
+		expectedCode     int
+		expectedStdoutIn string
+		expectedStderrIn string
+	}{
+		{[]string{clean}, "", 0, "", ""},
+		{[]string{clean, dirty}, "", 1, "warning: comparison with true is redundant [bool-comparison]\n --> " + dirty + ":2:11", ""},
+		{[]string{"-config", config, dirty}, "", 0, "", ""},
+		{[]string{"-config", config, "-enable", "bool-comparison", dirty}, "", 1, "[bool-comparison]", ""},
+		{[]string{"-disable", "bool-comparison, names", dirty}, "", 0, "", ""},
+		{[]string{"-json", dirty}, "", 1, `"file": "` + dirty + `",
+    "line": 2,
+    "column": 11,
+    "severity": "warning",
+    "rule": "bool-comparison"`, ""},
+		{[]string{"-json", clean}, "", 0, "[]", ""},
+		{[]string{}, "puts(lenn([]))", 1, "error: identifier not found: lenn [names]\n --> <stdin>:1:6", ""},
+		{[]string{}, "let = 1", 1, "[syntax]", ""},
+		{[]string{"-rules"}, "", 0, "unreachable-code", ""},
+		{[]string{"-config", badConfig, clean}, "", 2, "", `unknown rule "nope"`},
+		{[]string{"-disable", "nope", clean}, "", 2, "", `unknown rule "nope"`},
+		{[]string{filepath.Join(dir, "missing.mk")}, "", 2, "", "missing.mk"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := runLint(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+		if code != tt.expectedCode {
+			t.Errorf("runLint(%v) - wrong exit code. expected=%d, got=%d (stdout=%q, stderr=%q)", tt.args, tt.expectedCode, code, stdout.String(), stderr.String())
+		}
+		if !strings.Contains(stdout.String(), tt.expectedStdoutIn) {
+			t.Errorf("runLint(%v) - stdout %q doesn't contain %q", tt.args, stdout.String(), tt.expectedStdoutIn)
+		}
+		if !strings.Contains(stderr.String(), tt.expectedStderrIn) {
+			t.Errorf("runLint(%v) - stderr %q doesn't contain %q", tt.args, stderr.String(), tt.expectedStderrIn)
+		}
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	dir := t.TempDir()
+	typed := filepath.Join(dir, "typed.mk")
+	wrong := filepath.Join(dir, "wrong.mk")
+	os.WriteFile(typed, []byte("let double = fn(x: int): int { x * 2 };\nputs(double(2));\n"), 0o644)
+	os.WriteFile(wrong, []byte("let double = fn(x: int): int { x * 2 };\nputs(double(\"2\"));\n"), 0o644)
+
+	tests := []struct {
+		args             []string
+		stdin            string
+		expectedCode     int
+		expectedStdoutIn string
+		expectedStderrIn string
+	}{
+		{[]string{typed}, "", 0, "", ""},
+		{[]string{typed, wrong}, "", 1, "error: cannot use string as int in argument 1 of double\n --> " + wrong + ":2:13", ""},
+		{[]string{}, `1 + "a"`, 1, "error: type mismatch: int + string\n --> <stdin>:1:3", ""},
+		{[]string{}, "let x: int = ;", 1, "error: ", ""},
+		{[]string{}, "puts(undefined_name)", 0, "", ""},
+		{[]string{filepath.Join(dir, "missing.mk")}, "", 2, "", "missing.mk"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := runCheck(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+		if code != tt.expectedCode {
+			t.Errorf("runCheck(%v) - wrong exit code. expected=%d, got=%d (stdout=%q, stderr=%q)", tt.args, tt.expectedCode, code, stdout.String(), stderr.String())
+		}
+		if !strings.Contains(stdout.String(), tt.expectedStdoutIn) {
+			t.Errorf("runCheck(%v) - stdout %q doesn't contain %q", tt.args, stdout.String(), tt.expectedStdoutIn)
+		}
+		if !strings.Contains(stderr.String(), tt.expectedStderrIn) {
+			t.Errorf("runCheck(%v) - stderr %q doesn't contain %q", tt.args, stderr.String(), tt.expectedStderrIn)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nK\n"
+
+	expected := `--- f
++++ f
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,4 +8,4 @@
+ h
+ i
+ j
+-k
++K
+`
+	if got := unifiedDiff("f", before, after); got != expected {
+		t.Errorf("wrong diff.\nexpected=%q\ngot=     %q", expected, got)
+	}
+	if got := unifiedDiff("f", before, before); got != "" {
+		t.Errorf("expected no diff for equal texts, got=%q", got)
+	}
+}
@@ -0,0 +1,75 @@
+package object
+
+import (
+	"bufio"
+	"io"
+	"math/rand/v2"
+	"os"
+	"time"
+)
+
+// INFO: Config - per-interpreter settings, shared by an Environment and every environment enclosed in it
+
+type Config struct {
+	// NOTE: when set, indexing or slicing out of range is an error instead of giving null
+	StrictIndexing bool
+
+	// NOTE: all the script I/O goes through these - puts/print write to Stdout, eprint to Stderr,
+	// read_line/read_all read from Stdin. NewConfig points them to the os ones, tests and embedders can swap them.
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	// NOTE: the file system builtins only work inside FSRoot (a directory picked by the host, "" means no file access at all)
+	// and not at all in Sandbox mode, whatever FSRoot says
+	FSRoot  string
+	Sandbox bool
+
+	// NOTE: where now() and since() get the current time from - tests can pin it to get deterministic results
+	Clock func() time.Time
+
+	// NOTE: the PRNG behind rand_int & co. - one per interpreter, randomly seeded unless Seed is called,
+	// so a run can be reproduced by using the same seed
+	random *rand.Rand
+
+	stdin       *bufio.Reader
+	stdinSource io.Reader
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Stdin:  os.Stdin,
+		Clock:  time.Now,
+	}
+}
+
+// NOTE: read_line has to buffer, so the same bufio.Reader is kept between calls (otherwise whatever got buffered
+// past the line would be lost) - it's only recreated when Stdin gets replaced
+func (c *Config) StdinReader() *bufio.Reader {
+	if c.stdin == nil || c.stdinSource != c.Stdin {
+		c.stdin = bufio.NewReader(c.Stdin)
+		c.stdinSource = c.Stdin
+	}
+	return c.stdin
+}
+
+// NOTE: a Config made without NewConfig has no Clock, that's just the real one
+func (c *Config) Now() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock()
+}
+
+func (c *Config) Seed(seed uint64) {
+	c.random = rand.New(rand.NewPCG(seed, seed))
+}
+
+func (c *Config) Random() *rand.Rand {
+	if c.random == nil {
+		c.Seed(rand.Uint64())
+	}
+	return c.random
+}
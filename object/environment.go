@@ -1,21 +1,35 @@
 package object
 
+import "sort"
+
 type Environment struct {
 	store map[string]Object
-	outer *Environment
+	// NOTE: the values of the names the resolver gave a slot, so they can be found without going through the maps
+	// of the whole chain - they are in store too (for Names and the lookups of unresolved identifiers)
+	slots  []Object
+	outer  *Environment
+	config *Config
 }
 
 func NewEnvironment() *Environment {
+	return NewEnvironmentWithConfig(NewConfig())
+}
+
+func NewEnvironmentWithConfig(config *Config) *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	return &Environment{store: s, outer: nil, config: config}
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
+	env := NewEnvironmentWithConfig(outer.config)
 	env.outer = outer
 	return env
 }
 
+func (e *Environment) Config() *Config {
+	return e.config
+}
+
 func (e *Environment) Get(key string) (Object, bool) {
 	val, ok := e.store[key]
 	if !ok && e.outer != nil {
@@ -28,3 +42,41 @@ func (e *Environment) Set(key string, value Object) Object {
 	e.store[key] = value
 	return value
 }
+
+// NOTE: the fast path of resolved identifiers - nil when the slot wasn't set (yet), e.g. the let is in an if that didn't run
+func (e *Environment) GetSlot(depth, slot int) Object {
+	env := e
+	for ; depth > 0 && env != nil; depth-- {
+		env = env.outer
+	}
+	if env == nil || slot >= len(env.slots) {
+		return nil
+	}
+	return env.slots[slot]
+}
+
+func (e *Environment) SetSlot(slot int, key string, value Object) Object {
+	if slot >= len(e.slots) {
+		e.slots = append(e.slots, make([]Object, slot+1-len(e.slots))...)
+	}
+	e.slots[slot] = value
+	e.store[key] = value
+	return value
+}
+
+// NOTE: every name visible from this environment (the outer ones included), sorted - for "did you mean" hints
+func (e *Environment) Names() []string {
+	seen := make(map[string]bool)
+	for env := e; env != nil; env = env.outer {
+		for name := range env.store {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
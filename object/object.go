@@ -4,8 +4,14 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
 	"mfiorek/waiig/ast"
+	"mfiorek/waiig/token"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type ObjectType string
@@ -31,6 +37,7 @@ type HashPair struct {
 const (
 	NULL_OBJ         = "NULL"
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
 	ERROR_OBJ        = "ERROR"
@@ -39,6 +46,9 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	REGEX_OBJ        = "REGEX"
+	TIME_OBJ         = "TIME"
+	DURATION_OBJ     = "DURATION"
 )
 
 // INFO: Null
@@ -59,6 +69,23 @@ func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 func (i *Integer) HashKey() HashKey { return HashKey{Type: i.Type(), Value: uint64(i.Value)} }
 
+// INFO: Float
+
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) Inspect() string {
+	out := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	// NOTE: keep the dot for whole numbers, so 2.0 doesn't look like an INTEGER when printed
+	if !strings.ContainsAny(out, ".eIN") {
+		out += ".0"
+	}
+	return out
+}
+func (f *Float) HashKey() HashKey { return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)} }
+
 // INFO: Boolean
 
 type Boolean struct {
@@ -93,6 +120,34 @@ func (s *String) HashKey() HashKey {
 	return HashKey{Type: s.Type(), Value: h.Sum64()}
 }
 
+// INFO: Regex
+
+// NOTE: compiled once by the regex builtin, so scripts can reuse it without paying for compilation again
+type Regex struct {
+	Value *regexp.Regexp
+}
+
+func (r *Regex) Type() ObjectType { return REGEX_OBJ }
+func (r *Regex) Inspect() string  { return "/" + r.Value.String() + "/" }
+
+// INFO: Time
+
+type Time struct {
+	Value time.Time
+}
+
+func (t *Time) Type() ObjectType { return TIME_OBJ }
+func (t *Time) Inspect() string  { return t.Value.Format(time.RFC3339Nano) }
+
+// INFO: Duration
+
+type Duration struct {
+	Value time.Duration
+}
+
+func (d *Duration) Type() ObjectType { return DURATION_OBJ }
+func (d *Duration) Inspect() string  { return d.Value.String() }
+
 // INFO: ReturnValue
 
 type ReturnValue struct {
@@ -106,6 +161,10 @@ func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
 type Error struct {
 	Message string
+	// NOTE: only some errors know where they happened (an unknown identifier) and how to fix them - the zero token
+	// and "" otherwise
+	Token token.Token
+	Hint  string
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
@@ -114,7 +173,8 @@ func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
 // INFO: Function
 
 type Function struct {
-	Parameters []*ast.Identifier
+	Parameters []ast.Pattern
+	Rest       *ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -127,6 +187,9 @@ func (f *Function) Inspect() string {
 	for _, p := range f.Parameters {
 		params = append(params, p.String())
 	}
+	if f.Rest != nil {
+		params = append(params, "..."+f.Rest.String())
+	}
 
 	out.WriteString("fn")
 	out.WriteString("(")
@@ -141,7 +204,7 @@ func (f *Function) Inspect() string {
 // INFO: Builtin
 
 type Builtin struct {
-	Fn func(args ...Object) Object
+	Fn func(config *Config, args ...Object) Object
 }
 
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
@@ -154,12 +217,37 @@ type Array struct {
 }
 
 func (a *Array) Type() ObjectType { return ARRAY_OBJ }
-func (a *Array) Inspect() string {
+func (a *Array) Inspect() string  { return inspect(a, map[Object]bool{}) }
+
+// NOTE: index assignment can put an array or a hash inside itself (a[0] = a) - the containers already being printed
+// are written as [...] and {...}, otherwise printing it would never end
+func inspect(obj Object, printing map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *Array:
+		if printing[obj] {
+			return "[...]"
+		}
+		printing[obj] = true
+		defer delete(printing, obj)
+		return obj.inspect(printing)
+	case *Hash:
+		if printing[obj] {
+			return "{...}"
+		}
+		printing[obj] = true
+		defer delete(printing, obj)
+		return obj.inspect(printing)
+	default:
+		return obj.Inspect()
+	}
+}
+
+func (a *Array) inspect(printing map[Object]bool) string {
 	var out bytes.Buffer
 
 	elements := []string{}
 	for _, el := range a.Elements {
-		elements = append(elements, el.Inspect())
+		elements = append(elements, inspect(el, printing))
 	}
 
 	out.WriteString("[")
@@ -176,12 +264,14 @@ type Hash struct {
 }
 
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
-func (h *Hash) Inspect() string {
+func (h *Hash) Inspect() string  { return inspect(h, map[Object]bool{}) }
+
+func (h *Hash) inspect(printing map[Object]bool) string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, pair.Key.Inspect()+":"+pair.Value.Inspect())
+	for _, pair := range h.SortedPairs() {
+		pairs = append(pairs, pair.Key.Inspect()+":"+inspect(pair.Value, printing))
 	}
 
 	out.WriteString("{")
@@ -190,3 +280,34 @@ func (h *Hash) Inspect() string {
 
 	return out.String()
 }
+
+// NOTE: Go maps have no order, so everything that walks over a hash (Inspect, keys, values, ...) uses this
+// to be deterministic - keys are grouped by type, then sorted by value within the type
+func (h *Hash) SortedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		left, right := pairs[i].Key, pairs[j].Key
+		if left.Type() != right.Type() {
+			return left.Type() < right.Type()
+		}
+
+		switch left := left.(type) {
+		case *Integer:
+			return left.Value < right.(*Integer).Value
+		case *Float:
+			return left.Value < right.(*Float).Value
+		case *String:
+			return left.Value < right.(*String).Value
+		case *Boolean:
+			return !left.Value && right.(*Boolean).Value
+		default:
+			return left.Inspect() < right.Inspect()
+		}
+	})
+
+	return pairs
+}
@@ -20,3 +20,67 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestEnvironmentSlots(t *testing.T) {
+	outer := NewEnvironment()
+	inner := NewEnclosedEnvironment(outer)
+
+	outer.SetSlot(1, "a", &Integer{Value: 1})
+	inner.SetSlot(0, "b", &Integer{Value: 2})
+
+	tests := []struct {
+		env         *Environment
+		depth, slot int
+		expected    Object
+	}{
+		{inner, 0, 0, &Integer{Value: 2}},
+		{inner, 1, 1, &Integer{Value: 1}},
+		{inner, 1, 0, nil},
+		{inner, 2, 0, nil},
+		{outer, 0, 5, nil},
+	}
+
+	for _, tt := range tests {
+		got := tt.env.GetSlot(tt.depth, tt.slot)
+		if tt.expected == nil {
+			if got != nil {
+				t.Errorf("GetSlot(%d, %d) expected nil. got=%v", tt.depth, tt.slot, got)
+			}
+			continue
+		}
+		if got == nil || got.Inspect() != tt.expected.Inspect() {
+			t.Errorf("GetSlot(%d, %d) wrong. expected=%v, got=%v", tt.depth, tt.slot, tt.expected, got)
+		}
+	}
+
+	// NOTE: the slots are only a shortcut - the names are still there for the lookups that go by them
+	if value, ok := inner.Get("a"); !ok || value.Inspect() != "1" {
+		t.Errorf("SetSlot didn't set the name. got=%v (%t)", value, ok)
+	}
+}
+
+func TestInspectCycles(t *testing.T) {
+	array := &Array{Elements: []Object{&Integer{Value: 1}}}
+	array.Elements = append(array.Elements, array)
+
+	key := &String{Value: "self"}
+	hash := &Hash{Pairs: map[HashKey]HashPair{}}
+	hash.Pairs[key.HashKey()] = HashPair{Key: key, Value: hash}
+
+	shared := &Array{Elements: []Object{&Integer{Value: 2}}}
+	tests := []struct {
+		input    Object
+		expected string
+	}{
+		{array, "[1, [...]]"},
+		{hash, "{self:{...}}"},
+		{&Array{Elements: []Object{hash, array}}, "[{self:{...}}, [1, [...]]]"},
+		{&Array{Elements: []Object{shared, shared}}, "[[2], [2]]"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.input.Inspect(); got != tt.expected {
+			t.Errorf("wrong Inspect. expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"fmt"
+	"mfiorek/waiig/token"
+)
+
+// INFO: Diagnostic - a problem found in the source, with where it is and (for a missing token) what was expected there
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+type Diagnostic struct {
+	Severity Severity
+	Line     int // where the problem starts - 1-based like token.Token's Line and Column
+	Column   int
+	Message  string
+	// NOTE: the token types that would have been fine there - empty when the problem isn't a missing token
+	Expected []token.TokenType
+	Found    token.Token // the offending token
+	Hint     string      // how it might be fixed - "" when there is no idea
+}
+
+// NOTE: `line:column: severity: message`, the way compilers print them
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Severity, d.Message)
+}
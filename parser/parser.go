@@ -13,10 +13,17 @@ import (
 type Parser struct {
 	l *lexer.Lexer
 
-	errors []string
+	diagnostics []Diagnostic
+	// NOTE: set by the first error of a statement - the errors after it are most likely caused by it, so they aren't
+	// reported until the statement loop skipped to the next statement (see parseStatementRecovering)
+	recovering bool
 
 	curToken  token.Token
 	peekToken token.Token
+	depth     int // how many { are open, curToken included
+
+	// NOTE: the calls written in parentheses - x |> (f(a)) pipes into what f(a) gives, it isn't f(x, a)
+	parenthesized map[*ast.CallExpression]bool
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
@@ -26,14 +33,17 @@ type infixParseFn func(ast.Expression) ast.Expression
 
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:             l,
+		diagnostics:   []Diagnostic{},
+		parenthesized: map[*ast.CallExpression]bool{},
 	}
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.TILDE, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
@@ -42,18 +52,32 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
+	p.registerInfix(token.AMPERSAND, p.parseInfixExpression)
+	p.registerInfix(token.PIPE, p.parseInfixExpression)
+	p.registerInfix(token.CARET, p.parseInfixExpression)
+	p.registerInfix(token.SHIFT_LEFT, p.parseInfixExpression)
+	p.registerInfix(token.SHIFT_RIGHT, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PIPELINE, p.parsePipelineExpression)
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -62,13 +86,31 @@ func New(l *lexer.Lexer) *Parser {
 	return p
 }
 
+// NOTE: the messages of the error diagnostics - kept for the callers which only want to know what went wrong
 func (p *Parser) Errors() []string {
-	return p.errors
+	errors := []string{}
+	for _, diagnostic := range p.diagnostics {
+		if diagnostic.Severity == SeverityError {
+			errors = append(errors, diagnostic.Message)
+		}
+	}
+	return errors
+}
+
+func (p *Parser) Diagnostics() []Diagnostic {
+	return p.diagnostics
 }
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	switch p.curToken.Type {
+	case token.LBRACE:
+		p.depth++
+	case token.RBRACE:
+		p.depth--
+	}
 }
 
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
@@ -81,21 +123,70 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 
 // INFO: Parse Program functionality
 
+// NOTE: the program is given back even when there were errors - with ast.BadStatement / ast.BadExpression where
+// the source didn't parse, so tools can still work with the rest of it
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 
 	for !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			program.Statements = append(program.Statements, stmt)
+		stmt, ok := p.parseStatementRecovering(p.depth)
+		program.Statements = append(program.Statements, stmt)
+		if ok {
+			p.nextToken()
 		}
-		p.nextToken()
 	}
 
 	return program
 }
 
+// INFO: Error recovery
+
+// NOTE: parses the statement at curToken - ok is false when it had an error, the rest of it is skipped then
+// and curToken is already the first token of the next statement (so the caller must not move on to it).
+// A statement that couldn't be built at all becomes an ast.BadStatement.
+func (p *Parser) parseStatementRecovering(depth int) (stmt ast.Statement, ok bool) {
+	start := p.curToken
+
+	stmt = p.parseStatement()
+	if !p.recovering {
+		return stmt, true
+	}
+
+	p.synchronize(start, depth)
+	p.recovering = false
+	if stmt == nil {
+		stmt = &ast.BadStatement{Token: start}
+	}
+	return stmt, false
+}
+
+// WARN: Helper method used only in parseStatementRecovering - skips tokens up to the start of the next statement
+// at the given nesting depth: past a `;`, up to a `let` / `return`, or up to the `}` closing the enclosing block
+// (which is left for the block to consume, the failed statement might have read it already)
+func (p *Parser) synchronize(start token.Token, depth int) {
+	// NOTE: the failed statement has to be left, or the same error would come up again and again
+	if p.curToken == start && !p.curTokenIs(token.EOF) {
+		p.nextToken()
+	}
+
+	for !p.curTokenIs(token.EOF) {
+		if p.depth < depth {
+			return
+		}
+		if p.depth == depth {
+			switch p.curToken.Type {
+			case token.SEMICOLON:
+				p.nextToken()
+				return
+			case token.LET, token.RETURN:
+				return
+			}
+		}
+		p.nextToken()
+	}
+}
+
 // INFO: ==================================== STATEMENTS! ====================================
 
 // INFO: Parse Statement - main switch for parsing every statement
@@ -103,7 +194,11 @@ func (p *Parser) ParseProgram() *ast.Program {
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
-		return p.parseLetStatement()
+		// NOTE: a nil *ast.LetStatement mustn't turn into a non-nil ast.Statement
+		if stmt := p.parseLetStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
 	case token.RETURN:
 		return p.parseReturnStatement()
 	default:
@@ -116,11 +211,24 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
-	}
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		stmt.Pattern = p.parsePattern()
+		if stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+		var ok bool
+		if stmt.Type, ok = p.parseAnnotation(); !ok {
+			return nil
+		}
+	}
 
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
@@ -129,7 +237,8 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
+	// NOTE: after an error the ; is left for synchronize, curToken might already be past the statement
+	if !p.recovering && p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -145,7 +254,7 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
+	if !p.recovering && p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -160,7 +269,7 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 	stmt.Expression = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
+	if !p.recovering && p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -172,16 +281,21 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
+	depth := p.depth
 
 	p.nextToken()
 
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
+		stmt, ok := p.parseStatementRecovering(depth)
+		block.Statements = append(block.Statements, stmt)
+		if ok {
+			p.nextToken()
 		}
-		p.nextToken()
 	}
+	if p.curTokenIs(token.EOF) {
+		p.addError(p.curToken, []token.TokenType{token.RBRACE}, "expected next token to be %s, got %s instead", token.RBRACE, token.EOF)
+	}
+	block.EndToken = p.curToken
 
 	return block
 }
@@ -191,40 +305,75 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // arr[0] = X
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
 	EQUALS      // ==
 	LESSGREATER // > or <
+	PIPELINE    // X |> f (binds tighter than the comparisons, so xs |> len == 3 compares the length)
+	BITWISE_OR  // |
+	BITWISE_XOR // ^
+	BITWISE_AND // &
+	SHIFT       // << or >>
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
+	POWER       // ** (binds tighter than prefix, so -2 ** 2 is -(2 ** 2))
 	CALL        // myFunction(X)
 	INDEX       // array[1]
 )
 
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.ASSIGN:      ASSIGN,
+	token.PIPELINE:    PIPELINE,
+	token.OR:          LOGICAL_OR,
+	token.AND:         LOGICAL_AND,
+	token.EQ:          EQUALS,
+	token.NOT_EQ:      EQUALS,
+	token.LT:          LESSGREATER,
+	token.GT:          LESSGREATER,
+	token.LT_EQ:       LESSGREATER,
+	token.GT_EQ:       LESSGREATER,
+	token.PIPE:        BITWISE_OR,
+	token.CARET:       BITWISE_XOR,
+	token.AMPERSAND:   BITWISE_AND,
+	token.SHIFT_LEFT:  SHIFT,
+	token.SHIFT_RIGHT: SHIFT,
+	token.PLUS:        SUM,
+	token.MINUS:       SUM,
+	token.SLASH:       PRODUCT,
+	token.ASTERISK:    PRODUCT,
+	token.PERCENT:     PRODUCT,
+	token.POWER:       POWER,
+	token.LPAREN:      CALL,
+	token.LBRACKET:    INDEX,
+}
+
+// NOTE: exported for the formatter, which has to know when parentheses are needed - LOWEST for non-operators
+func Precedence(tokenType token.TokenType) int {
+	if precedence, ok := precedences[tokenType]; ok {
+		return precedence
+	}
+	return LOWEST
 }
 
 // INFO: Parse Expression - main function for parsing every expresssion
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	// defer untrace(trace("parseExpression"))
+	// NOTE: what failed to parse becomes an ast.BadExpression, so the expression is never nil
+	tok := p.curToken
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
-		p.noPrefixParseFnError(p.curToken.Type)
-		return nil
+		p.noPrefixParseFnError(p.curToken)
+		return &ast.BadExpression{Token: tok}
 	}
 	leftExp := prefix()
+	if leftExp == nil {
+		return &ast.BadExpression{Token: tok}
+	}
 
-	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
+	for !p.recovering && !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
 			return leftExp
@@ -232,7 +381,10 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 		p.nextToken()
 
-		leftExp = infix(leftExp)
+		tok = p.curToken
+		if leftExp = infix(leftExp); leftExp == nil {
+			return &ast.BadExpression{Token: tok}
+		}
 	}
 
 	return leftExp
@@ -252,8 +404,23 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken, nil, "could not parse %q as integer", p.curToken.Literal)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
+// INFO: Parse FloatLiteral functionality
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.addError(p.curToken, nil, "could not parse %q as float", p.curToken.Literal)
 		return nil
 	}
 
@@ -298,6 +465,10 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Operator: p.curToken.Literal,
 	}
 	currentPrecedence := p.curPrecedence()
+	// NOTE: ** is right-associative (2 ** 3 ** 2 is 2 ** (3 ** 2)), so the right side may swallow another **
+	if p.curTokenIs(token.POWER) {
+		currentPrecedence--
+	}
 	p.nextToken()
 	inf.Right = p.parseExpression(currentPrecedence)
 
@@ -313,6 +484,9 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
+	if call, ok := expression.(*ast.CallExpression); ok {
+		p.parenthesized[call] = true
+	}
 
 	return expression
 }
@@ -361,7 +535,14 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	lit.Parameters, lit.Rest = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+	var ok bool
+	if lit.ReturnType, ok = p.parseAnnotation(); !ok {
+		return nil
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
@@ -372,32 +553,55 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return lit
 }
 
-// WARN: Helper method used only in parseFunctionLiteral
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+// WARN: Helper method used only in parseFunctionLiteral - gives nil parameters when parsing failed
+func (p *Parser) parseFunctionParameters() ([]ast.Pattern, *ast.Identifier) {
+	parameters := []ast.Pattern{}
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
+		return parameters, nil
 	}
 
-	p.nextToken()
+	for {
+		p.nextToken()
 
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil, nil
+			}
+			rest := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			// NOTE: ...rest has to be the last parameter
+			if !p.expectPeek(token.RPAREN) {
+				return nil, nil
+			}
+			return parameters, rest
+		}
 
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
+		param := p.parsePattern()
+		if param == nil {
+			return nil, nil
+		}
+		if ident, ok := param.(*ast.IdentifierPattern); ok {
+			if ident.Type, ok = p.parseAnnotation(); !ok {
+				return nil, nil
+			}
+		}
+		if param = p.parseDefault(param); param == nil {
+			return nil, nil
+		}
+		parameters = append(parameters, param)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
 		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		identifiers = append(identifiers, ident)
 	}
 
 	if !p.expectPeek(token.RPAREN) {
-		return nil
+		return nil, nil
 	}
 
-	return identifiers
+	return parameters, nil
 }
 
 // INFO: Parse CallExpression functionality
@@ -408,6 +612,33 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	return exp
 }
 
+// INFO: Parse pipeline functionality - there is no pipeline node, `x |> f(a)` becomes the call f(x, a) right away
+
+func (p *Parser) parsePipelineExpression(left ast.Expression) ast.Expression {
+	tok := p.curToken
+
+	p.nextToken()
+	right := p.parseExpression(PIPELINE)
+	if right == nil {
+		return nil
+	}
+
+	// NOTE: a call that is itself a pipeline (x |> (y |> f)) or is in parentheses (x |> (f(a))) is treated like
+	// any other function value
+	if call, ok := right.(*ast.CallExpression); ok && !call.Piped && !p.parenthesized[call] {
+		call.Arguments = append([]ast.Expression{left}, call.Arguments...)
+		call.Piped = true
+		return call
+	}
+
+	return &ast.CallExpression{
+		Token:     tok,
+		Function:  right,
+		Arguments: []ast.Expression{left},
+		Piped:     true,
+	}
+}
+
 // INFO: Parse ArrayLiteral functioanlity
 
 func (p *Parser) parseArrayLiteral() ast.Expression {
@@ -426,12 +657,12 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	}
 
 	p.nextToken()
-	list = append(list, p.parseExpression(LOWEST))
+	list = append(list, p.parseListElement())
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		list = append(list, p.parseExpression(LOWEST))
+		list = append(list, p.parseListElement())
 	}
 
 	if !p.expectPeek(end) {
@@ -441,14 +672,36 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	return list
 }
 
+// WARN: Helper method used only in parseExpressionList - an element can be spread (...xs)
+func (p *Parser) parseListElement() ast.Expression {
+	if !p.curTokenIs(token.ELLIPSIS) {
+		return p.parseExpression(LOWEST)
+	}
+
+	spread := &ast.SpreadExpression{Token: p.curToken}
+	p.nextToken()
+	spread.Value = p.parseExpression(LOWEST)
+
+	return spread
+}
+
 // INFO: Parse IndexExpression functionality
 
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
 
 	p.nextToken()
+	if p.curTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp.Token, left, nil)
+	}
+
 	exp.Index = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		return p.parseSliceExpression(exp.Token, left, exp.Index)
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
@@ -456,6 +709,47 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+// WARN: Helper method used only in parseIndexExpression - curToken is the first ':' and the start is already parsed
+func (p *Parser) parseSliceExpression(tok token.Token, left, start ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{Token: tok, Left: left, Start: start}
+
+	if !p.peekTokenIs(token.COLON) && !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.End = p.parseExpression(LOWEST)
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		if !p.peekTokenIs(token.RBRACKET) {
+			p.nextToken()
+			exp.Step = p.parseExpression(LOWEST)
+		}
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return exp
+}
+
+// INFO: Parse AssignExpression functionality
+
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	exp := &ast.AssignExpression{Token: p.curToken, Target: target}
+
+	if _, ok := target.(*ast.IndexExpression); !ok {
+		p.addError(exp.Token, nil, "invalid assignment target: %s", target.String())
+		return nil
+	}
+
+	// NOTE: assignment is right-associative, so a[0] = b[0] = 1 assigns 1 to both
+	p.nextToken()
+	exp.Value = p.parseExpression(ASSIGN - 1)
+
+	return exp
+}
+
 // INFO: Parse HashLiteral
 
 func (p *Parser) parseHashLiteral() ast.Expression {
@@ -485,6 +779,295 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 	return hash
 }
 
+// INFO: Parse MatchExpression functionality
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Arms = []*ast.MatchArm{}
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		expression.Arms = append(expression.Arms, arm)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	expression.EndToken = p.curToken
+
+	return expression
+}
+
+// WARN: Helper method used only in parseMatchExpression - parses `pattern [if guard] => body`
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{}
+
+	arm.Pattern = p.parsePattern()
+	if arm.Pattern == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		arm.Guard = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+
+	p.nextToken()
+	arm.Body = p.parseExpression(LOWEST)
+
+	return arm
+}
+
+// INFO: ==================================== PATTERNS! ====================================
+
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		return &ast.IdentifierPattern{
+			Token: p.curToken,
+			Name:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		}
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE:
+		return p.parseLiteralPattern()
+	case token.MINUS:
+		if !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.FLOAT) {
+			p.patternError()
+			return nil
+		}
+		return p.parseLiteralPattern()
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	default:
+		p.patternError()
+		return nil
+	}
+}
+
+// NOTE: used where a value can be missing (array/hash pattern elements, function parameters) - `pattern = default`.
+// Not done by parsePattern itself, as `let [a] = ...` would take the value for a default
+func (p *Parser) parsePatternWithDefault() ast.Pattern {
+	pattern := p.parsePattern()
+	if pattern == nil {
+		return nil
+	}
+	return p.parseDefault(pattern)
+}
+
+// NOTE: the `= default` after an already parsed pattern - the pattern itself when there is none
+func (p *Parser) parseDefault(pattern ast.Pattern) ast.Pattern {
+	if !p.peekTokenIs(token.ASSIGN) {
+		return pattern
+	}
+	p.nextToken()
+
+	defaultPattern := &ast.DefaultPattern{Token: p.curToken, Pattern: pattern}
+	p.nextToken()
+	// NOTE: parsing with ASSIGN precedence, so the default doesn't turn into an assignment itself
+	defaultPattern.Default = p.parseExpression(ASSIGN)
+
+	return defaultPattern
+}
+
+// WARN: Helper method used only in parsePattern
+func (p *Parser) parseLiteralPattern() ast.Pattern {
+	pattern := &ast.LiteralPattern{Token: p.curToken}
+
+	prefix := p.prefixParseFns[p.curToken.Type]
+	pattern.Value = prefix()
+	if pattern.Value == nil {
+		return nil
+	}
+
+	return pattern
+}
+
+// WARN: Helper method used only in parsePattern
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Pattern{}}
+
+	for !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			pattern.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			// NOTE: ...rest has to be the last element
+			break
+		}
+
+		element := p.parsePatternWithDefault()
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.peekTokenIs(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+// WARN: Helper method used only in parsePattern
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken, Pairs: []*ast.HashPatternPair{}}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			pattern.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			// NOTE: ...rest has to be the last pair
+			break
+		}
+
+		switch p.curToken.Type {
+		case token.STRING, token.INT, token.TRUE, token.FALSE:
+		default:
+			p.addError(p.curToken, nil, "hash pattern keys must be literals, got %s", p.curToken.Type)
+			return nil
+		}
+		key := p.prefixParseFns[p.curToken.Type]()
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parsePatternWithDefault()
+		if value == nil {
+			return nil
+		}
+		pattern.Pairs = append(pattern.Pairs, &ast.HashPatternPair{Key: key, Value: value})
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
+
+// INFO: ==================================== TYPES! ====================================
+
+// NOTE: the `: type` after a let name, a parameter or the parameters of a function - a nil type when there is
+// no colon, false when the type after it didn't parse
+func (p *Parser) parseAnnotation() (ast.Type, bool) {
+	if !p.peekTokenIs(token.COLON) {
+		return nil, true
+	}
+	p.nextToken()
+	p.nextToken()
+
+	typ := p.parseType()
+	return typ, typ != nil
+}
+
+func (p *Parser) parseType() ast.Type {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return &ast.NamedType{Token: p.curToken, Name: p.curToken.Literal}
+	case token.LBRACKET:
+		typ := &ast.ArrayType{Token: p.curToken}
+		p.nextToken()
+		if typ.Element = p.parseType(); typ.Element == nil || !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return typ
+	case token.LBRACE:
+		typ := &ast.HashType{Token: p.curToken}
+		p.nextToken()
+		if typ.Key = p.parseType(); typ.Key == nil || !p.expectPeek(token.COLON) {
+			return nil
+		}
+		p.nextToken()
+		if typ.Value = p.parseType(); typ.Value == nil || !p.expectPeek(token.RBRACE) {
+			return nil
+		}
+		return typ
+	case token.FUNCTION:
+		return p.parseFunctionType()
+	default:
+		p.addError(p.curToken, nil, "expected a type, got %s", p.curToken.Type)
+		return nil
+	}
+}
+
+// WARN: Helper method used only in parseType - fn(int, string): bool, the return type can be left out
+func (p *Parser) parseFunctionType() ast.Type {
+	typ := &ast.FunctionType{Token: p.curToken, Parameters: []ast.Type{}}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		param := p.parseType()
+		if param == nil {
+			return nil
+		}
+		typ.Parameters = append(typ.Parameters, param)
+
+		if !p.peekTokenIs(token.RPAREN) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.nextToken()
+
+	var ok bool
+	if typ.Return, ok = p.parseAnnotation(); !ok {
+		return nil
+	}
+	return typ
+}
+
 // INFO: ==================================== Helper methods ====================================
 
 func (p *Parser) curTokenIs(t token.TokenType) bool {
@@ -505,15 +1088,33 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	}
 }
 
+// NOTE: only the first error of a statement is reported, see recovering
+func (p *Parser) addError(found token.Token, expected []token.TokenType, format string, a ...any) {
+	if p.recovering {
+		return
+	}
+	p.recovering = true
+
+	p.diagnostics = append(p.diagnostics, Diagnostic{
+		Severity: SeverityError,
+		Line:     found.Line,
+		Column:   found.Column,
+		Message:  fmt.Sprintf(format, a...),
+		Expected: expected,
+		Found:    found,
+	})
+}
+
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
-		t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addError(p.peekToken, []token.TokenType{t}, "expected next token to be %s, got %s instead", t, p.peekToken.Type)
+}
+
+func (p *Parser) patternError() {
+	p.addError(p.curToken, nil, "unexpected %s in pattern", p.curToken.Type)
 }
 
-func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+func (p *Parser) noPrefixParseFnError(tok token.Token) {
+	p.addError(tok, nil, "no prefix parse function for %s found", tok.Type)
 }
 
 func (p *Parser) peekPrecedence() int {
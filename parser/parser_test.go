@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"mfiorek/waiig/ast"
 	"mfiorek/waiig/lexer"
+	"mfiorek/waiig/token"
+	"slices"
 	"testing"
 )
 
@@ -196,6 +198,17 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"true == true", true, "==", true},
 		{"true != false", true, "!=", false},
 		{"false == false", false, "==", false},
+		{"true && false", true, "&&", false},
+		{"true || false", true, "||", false},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 ** 5;", 5, "**", 5},
+		{"5 & 5;", 5, "&", 5},
+		{"5 | 5;", 5, "|", 5},
+		{"5 ^ 5;", 5, "^", 5},
+		{"5 << 5;", 5, "<<", 5},
+		{"5 >> 5;", 5, ">>", 5},
 	}
 
 	for _, tt := range infixTests {
@@ -331,6 +344,58 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a == b && c < d || !e",
+			"(((a == b) && (c < d)) || (!e))",
+		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"-2 ** 2",
+			"(-(2 ** 2))",
+		},
+		{
+			"a * b ** c",
+			"(a * (b ** c))",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b << c + d",
+			"(a & (b << (c + d)))",
+		},
+		{
+			"a | b == c",
+			"((a | b) == c)",
+		},
+		{
+			"~a & b",
+			"((~a) & b)",
+		},
+		{
+			"1.5 * 2",
+			"(1.5 * 2)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -346,6 +411,37 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	input := "3.25;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. got=%d",
+			len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 3.25 {
+		t.Errorf("literal.Value not %f. got=%f", 3.25, literal.Value)
+	}
+	if literal.TokenLiteral() != "3.25" {
+		t.Errorf("literal.TokenLiteral not %s. got=%s", "3.25",
+			literal.TokenLiteral())
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input           string
@@ -521,8 +617,8 @@ func TestFunctionLiteralParsing(t *testing.T) {
 			len(function.Parameters))
 	}
 
-	testLiteralExpression(t, function.Parameters[0], "x")
-	testLiteralExpression(t, function.Parameters[1], "y")
+	testIdentifierPattern(t, function.Parameters[0], "x")
+	testIdentifierPattern(t, function.Parameters[1], "y")
 
 	if len(function.Body.Statements) != 1 {
 		t.Fatalf("function.Body.Statements has not 1 statements. got=%d\n",
@@ -563,7 +659,153 @@ func TestFunctionParameterParsing(t *testing.T) {
 		}
 
 		for i, ident := range tt.expectedParams {
-			testLiteralExpression(t, function.Parameters[i], ident)
+			testIdentifierPattern(t, function.Parameters[i], ident)
+		}
+	}
+}
+
+func TestDestructuringParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = x;", "let [a, b] = x;"},
+		{"let [a, [b, c], ...rest] = x;", "let [a, [b, c], ...rest] = x;"},
+		{"let [a, b = 2] = x;", "let [a, b = 2] = x;"},
+		{`let {"x": x, "y": y = 0} = p;`, "let {x:x, y:y = 0} = p;"},
+		{`let {"a": [a, _], ...others} = h;`, "let {a:[a, _], ...others} = h;"},
+		{"fn([a, b], {\"c\": c}) { a }", "fn([a, b], {c:c}) a"},
+		{"fn(x, [y, z] = [1, 2]) { x }", "fn(x, [y, z] = [1, 2]) x"},
+		{"fn(x, y = 10) { x }", "fn(x, y = 10) x"},
+		{"fn(first, ...rest) { rest }", "fn(first, ...rest) rest"},
+		{"fn(...args) { args }", "fn(...args) args"},
+		{"f(...xs, 1, ...[2, 3])", "f(...xs, 1, ...[2, 3])"},
+		{"[0, ...xs]", "[0, ...xs]"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestTypeAnnotationParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"let xs: [string] = [];", "let xs: [string] = [];"},
+		{"let h: {string: [int]} = {};", "let h: {string: [int]} = {};"},
+		{"let f: fn(int, string): bool = g;", "let f: fn(int, string): bool = g;"},
+		{"let f: fn() = g;", "let f: fn() = g;"},
+		{"fn(a: int, b: float = 1.5, c, ...rest): string { a }", "fn(a: int, b: float = 1.5, c, ...rest): string a"},
+		{"fn(f: fn(int): fn(): any): {int: bool} { f }", "fn(f: fn(int): fn(): any): {int: bool} f"},
+		{"let [a, b] = x;", "let [a, b] = x;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestRestParameterMustBeLast(t *testing.T) {
+	l := lexer.New("fn(...rest, x) { x }")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors")
+	}
+	if errors[0] != "expected next token to be ), got , instead" {
+		t.Errorf("wrong error message. got=%q", errors[0])
+	}
+}
+
+func TestPipelinePrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3] |> len == 3", "(([1, 2, 3] |> len) == 3)"},
+		{"a == b |> f", "(a == (b |> f))"},
+		{"x |> f != y |> g(1)", "((x |> f) != (y |> g(1)))"},
+		{"x |> f < 3", "((x |> f) < 3)"},
+		{"x |> f && y |> g", "((x |> f) && (y |> g))"},
+		{"x |> f || y", "((x |> f) || y)"},
+		{"!x |> f || y == z", "(((!x) |> f) || (y == z))"},
+		{"a + b |> f * 2", "((a + b) |> (f * 2))"},
+		{"a[0] = x |> f", "(a[0]) = (x |> f)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestParsingPipelines(t *testing.T) {
+	tests := []struct {
+		input            string
+		expected         string
+		expectedFunction string
+		expectedArgs     int
+	}{
+		{"x |> f", "(x |> f)", "f", 1},
+		{"x |> f()", "(x |> f)", "f", 1},
+		{"x |> f(a, b)", "(x |> f(a, b))", "f", 3},
+		{"x |> f(a) |> g", "((x |> f(a)) |> g)", "g", 1},
+		{"a + b |> f(c * d)", "((a + b) |> f((c * d)))", "f", 2},
+		{"x |> fn(y) { y }", "(x |> fn(y) y)", "fn(y) y", 1},
+		{"x |> f(a)(b)", "(x |> f(a)(b))", "f(a)", 2},
+		{"x |> (y |> f)", "(x |> (y |> f))", "(y |> f)", 1},
+		{"x |> (f(a))", "(x |> f(a)())", "f(a)", 1},
+		{"x |> (f(a))(b)", "(x |> f(a)(b))", "f(a)", 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		call, ok := stmt.Expression.(*ast.CallExpression)
+		if !ok {
+			t.Fatalf("exp not *ast.CallExpression. got=%T", stmt.Expression)
+		}
+		if !call.Piped {
+			t.Errorf("call.Piped is not true for %q", tt.input)
+		}
+		if call.Function.String() != tt.expectedFunction {
+			t.Errorf("wrong function. expected=%q, got=%q", tt.expectedFunction, call.Function.String())
+		}
+		if len(call.Arguments) != tt.expectedArgs {
+			t.Errorf("wrong number of arguments. expected=%d, got=%d", tt.expectedArgs, len(call.Arguments))
+		}
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
 		}
 	}
 }
@@ -671,6 +913,119 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"myArray[1:2]", "(myArray[1:2])"},
+		{"myArray[:2]", "(myArray[:2])"},
+		{"myArray[1:]", "(myArray[1:])"},
+		{"myArray[:]", "(myArray[:])"},
+		{"myArray[::2]", "(myArray[::2])"},
+		{"myArray[1:-1:2]", "(myArray[1:(-1):2])"},
+		{"myArray[::-1]", "(myArray[::(-1)])"},
+		{"myArray[a + 1:len(a)]", "(myArray[(a + 1):len(a)])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.SliceExpression); !ok {
+			t.Fatalf("exp not *ast.SliceExpression. got=%T", stmt.Expression)
+		}
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestParsingAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"arr[0] = 1", "(arr[0]) = 1"},
+		{`h["a" + "b"] = 1 + 2`, "(h[(a + b)]) = (1 + 2)"},
+		{"a[0] = b[0] = 1", "(a[0]) = (b[0]) = 1"},
+		{"a[0] = x || y", "(a[0]) = (x || y)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.AssignExpression); !ok {
+			t.Fatalf("exp not *ast.AssignExpression. got=%T", stmt.Expression)
+		}
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestParsingInvalidAssignTarget(t *testing.T) {
+	l := lexer.New("x = 5")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("parser has wrong number of errors. got=%d (%v)", len(errors), errors)
+	}
+	if errors[0] != "invalid assignment target: x" {
+		t.Errorf("wrong error message. got=%q", errors[0])
+	}
+}
+
+func TestParsingMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { 1 => a, _ => b }", "match (x) { 1 => a, _ => b }"},
+		{"match (x) { -1 => a, 2.5 => b, }", "match (x) { (-1) => a, 2.5 => b }"},
+		{`match (x) { "a" => 1, true => 2, false => 3 }`, "match (x) { a => 1, true => 2, false => 3 }"},
+		{"match (x) { n if n > 0 => n * 2, n => n }", "match (x) { n if (n > 0) => (n * 2), n => n }"},
+		{"match (x) { [] => 0, [a] => a, [a, _, ...rest] => rest }", "match (x) { [] => 0, [a] => a, [a, _, ...rest] => rest }"},
+		{`match (x) { {"kind": "user", "name": [first, ...]} => first }`, ""},
+		{`match (x) { {"kind": "user", "tags": [t, ...ts]} => t }`, "match (x) { {kind:user, tags:[t, ...ts]} => t }"},
+		{`match (f(x) + 1) { {} => 0 }`, "match ((f(x) + 1)) { {} => 0 }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		if tt.expected == "" {
+			if len(p.Errors()) == 0 {
+				t.Errorf("expected parser errors for %q", tt.input)
+			}
+			continue
+		}
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.MatchExpression); !ok {
+			t.Fatalf("exp not *ast.MatchExpression. got=%T", stmt.Expression)
+		}
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
 func TestParsingHashLiteralsStringKeys(t *testing.T) {
 	input := `{"one": 1, "two": 2, "three": 3}`
 
@@ -773,6 +1128,167 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 	}
 }
 
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedLine     int
+		expectedColumn   int
+		expectedMessage  string
+		expectedExpected []token.TokenType
+		expectedFound    string
+	}{
+		{"let = 5;", 1, 5, "expected next token to be IDENT, got = instead", []token.TokenType{token.IDENT}, "="},
+		{"let x = 1;\nlet y = 2 +;", 2, 12, "no prefix parse function for ; found", nil, ";"},
+		{"puts(1, 2", 1, 10, "expected next token to be ), got EOF instead", []token.TokenType{token.RPAREN}, ""},
+		{"if (x) {\n  1", 2, 4, "expected next token to be }, got EOF instead", []token.TokenType{token.RBRACE}, ""},
+		{"x = 5", 1, 3, "invalid assignment target: x", nil, "="},
+		{"99999999999999999999", 1, 1, `could not parse "99999999999999999999" as integer`, nil, "99999999999999999999"},
+		{"match (x) { + => 1 }", 1, 13, "unexpected + in pattern", nil, "+"},
+		{"let x: = 1;", 1, 8, "expected a type, got =", nil, "="},
+		{"fn(a: [int) { a }", 1, 11, "expected next token to be ], got ) instead", []token.TokenType{token.RBRACKET}, ")"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		diagnostics := p.Diagnostics()
+		if len(diagnostics) != 1 {
+			t.Errorf("wrong number of diagnostics for %q. expected=1, got=%d (%v)", tt.input, len(diagnostics), diagnostics)
+			continue
+		}
+		d := diagnostics[0]
+
+		if d.Severity != SeverityError {
+			t.Errorf("wrong severity for %q. got=%s", tt.input, d.Severity)
+		}
+		if d.Line != tt.expectedLine || d.Column != tt.expectedColumn {
+			t.Errorf("wrong position for %q. expected=%d:%d, got=%d:%d", tt.input, tt.expectedLine, tt.expectedColumn, d.Line, d.Column)
+		}
+		if d.Message != tt.expectedMessage {
+			t.Errorf("wrong message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, d.Message)
+		}
+		if !slices.Equal(d.Expected, tt.expectedExpected) {
+			t.Errorf("wrong expected tokens for %q. expected=%v, got=%v", tt.input, tt.expectedExpected, d.Expected)
+		}
+		if d.Found.Literal != tt.expectedFound {
+			t.Errorf("wrong found token for %q. expected=%q, got=%q", tt.input, tt.expectedFound, d.Found.Literal)
+		}
+	}
+
+	d := Diagnostic{Severity: SeverityError, Line: 3, Column: 7, Message: "boom"}
+	if d.String() != "3:7: error: boom" {
+		t.Errorf("wrong Diagnostic.String(). got=%q", d.String())
+	}
+}
+
+// NOTE: after an error the parser skips to the next statement and goes on - reporting only the first error of
+// a statement, with placeholders for what didn't parse
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input          string
+		expected       string
+		expectedErrors []string
+	}{
+		{
+			"let = 5; let y = 2; y",
+			"<bad statement>let y = 2;y",
+			[]string{"expected next token to be IDENT, got = instead"},
+		},
+		{
+			"let x = 1 +; let y = ;\nlet z = 3",
+			"let x = (1 + <bad expression>);let y = <bad expression>;let z = 3;",
+			[]string{"no prefix parse function for ; found", "no prefix parse function for ; found"},
+		},
+		{
+			"let x = (1 + 2;\nlet y = [1, 2;\nputs(x)",
+			"let x = <bad expression>;let y = [];puts(x)",
+			[]string{"expected next token to be ), got ; instead", "expected next token to be ], got ; instead"},
+		},
+		{
+			"fn(x) { let = 1; x }; let ok = 1",
+			"fn(x) <bad statement>xlet ok = 1;",
+			[]string{"expected next token to be IDENT, got = instead"},
+		},
+		{
+			"if (x) { 1 + }; let a = 2",
+			"ifx (1 + <bad expression>)let a = 2;",
+			[]string{"no prefix parse function for } found"},
+		},
+		{
+			"let f = fn(x) { x +\n}\nlet g = 2",
+			"let f = fn(x) (x + <bad expression>);let g = 2;",
+			[]string{"no prefix parse function for } found"},
+		},
+		{
+			`let h = {"a": 1 "b": fn() { 2 }}; let z = 1;`,
+			"let h = <bad expression>;let z = 1;",
+			[]string{"expected next token to be ,, got STRING instead"},
+		},
+		{
+			"match (x) { 1 => 2, => 3 }; 5",
+			"<bad expression>5",
+			[]string{"unexpected => in pattern"},
+		},
+		{
+			"}; let a = 1",
+			"<bad expression>let a = 1;",
+			[]string{"no prefix parse function for } found"},
+		},
+		{
+			"let a = fn() { let b = fn() { 1 + ; }; }; let c = 1;",
+			"let a = fn() let b = fn() (1 + <bad expression>);;let c = 1;",
+			[]string{"no prefix parse function for ; found"},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("wrong program for %q. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+		if !slices.Equal(p.Errors(), tt.expectedErrors) {
+			t.Errorf("wrong errors for %q.\nexpected=%q\ngot=     %q", tt.input, tt.expectedErrors, p.Errors())
+		}
+	}
+}
+
+func TestBadNodes(t *testing.T) {
+	l := lexer.New("let = 1; let y = 2 * ;")
+	p := New(l)
+	program := p.ParseProgram()
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements has wrong length. got=%d", len(program.Statements))
+	}
+
+	bad, ok := program.Statements[0].(*ast.BadStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.BadStatement. got=%T", program.Statements[0])
+	}
+	if bad.Token.Type != token.LET || bad.Token.Column != 1 {
+		t.Errorf("bad.Token wrong. got=%+v", bad.Token)
+	}
+
+	// NOTE: the statement itself is fine, only its value is partial
+	stmt, ok := program.Statements[1].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[1] is not *ast.LetStatement. got=%T", program.Statements[1])
+	}
+	infix := stmt.Value.(*ast.InfixExpression)
+	badExpr, ok := infix.Right.(*ast.BadExpression)
+	if !ok {
+		t.Fatalf("infix.Right is not *ast.BadExpression. got=%T", infix.Right)
+	}
+	if badExpr.Token.Type != token.SEMICOLON || badExpr.Token.Column != 22 {
+		t.Errorf("badExpr.Token wrong. got=%+v", badExpr.Token)
+	}
+}
+
 // INFO: ==================================== Helper methods ====================================
 
 func checkParserErrors(t *testing.T, p *Parser) {
@@ -856,6 +1372,16 @@ func testIdentifier(t *testing.T, exp ast.Expression, value string) bool {
 	return true
 }
 
+func testIdentifierPattern(t *testing.T, pattern ast.Pattern, value string) bool {
+	identPattern, ok := pattern.(*ast.IdentifierPattern)
+	if !ok {
+		t.Errorf("pattern not *ast.IdentifierPattern. got=%T", pattern)
+		return false
+	}
+
+	return testIdentifier(t, identPattern.Name, value)
+}
+
 func testLiteralExpression(t *testing.T, exp ast.Expression, expected interface{}) bool {
 	switch v := expected.(type) {
 	case int:
@@ -4,11 +4,14 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"mfiorek/waiig/diagnostic"
 	"mfiorek/waiig/evaluator"
 	"mfiorek/waiig/lexer"
 	"mfiorek/waiig/object"
 	"mfiorek/waiig/parser"
+	"mfiorek/waiig/resolver"
 	"mfiorek/waiig/token"
+	"strings"
 )
 
 const PROMPT = ">> "
@@ -48,7 +51,7 @@ func StartRPPL(in io.Reader, out io.Writer) {
 
 		program := p.ParseProgram()
 		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+			printDiagnostics(out, line, p.Diagnostics())
 			continue
 		}
 
@@ -57,31 +60,38 @@ func StartRPPL(in io.Reader, out io.Writer) {
 	}
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-	io.WriteString(out, MONKEY_FACE)
-	io.WriteString(out, "Woops! We ran into some monkey business here!\n")
-	io.WriteString(out, " parser errors:\n")
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg+"\n")
-	}
+// NOTE: rustc-style, with the line the errors are in (the parser's or the resolver's) - colored when out is a terminal
+func printDiagnostics(out io.Writer, line string, diagnostics []parser.Diagnostic) {
+	renderer := &diagnostic.Renderer{Filename: "<repl>", Source: line, Color: diagnostic.ColorEnabled(out)}
+	renderer.Render(out, diagnostics)
 }
 
-const MONKEY_FACE = `            __,__
-   .--.  .-"     "-.  .--.
-  / .. \/  .-. .-.  \/ .. \
- | |  '|  /   Y   \  |'  | |
- | \   \  \ 0 | 0 /  /   / |
-  \ '- ,\.-"""""""-./, -' /
-   ''-' /_   ^ ^   _\ '-''
-       |  \._   _./  |
-       \   \ '~' /   /
-        '._ '-=-' _.'
-           '-----'
-`
+// NOTE: errors knowing where they happened are rendered like parser errors - but an error inside a function defined
+// on an earlier line points into that line, so the source is only shown when the token really is there in this one
+func printEvalError(out io.Writer, line string, err *object.Error) {
+	d, ok := diagnostic.FromError(err)
+	if !ok {
+		io.WriteString(out, err.Inspect())
+		io.WriteString(out, "\n")
+		return
+	}
+
+	renderer := &diagnostic.Renderer{Filename: "<repl>", Color: diagnostic.ColorEnabled(out)}
+	if d.Line == 1 && strings.HasPrefix(line[min(d.Column-1, len(line)):], d.Found.Literal) {
+		renderer.Source = line
+	}
+	renderer.Render(out, []parser.Diagnostic{d})
+}
 
 func StartREPL(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+
+	// NOTE: script output goes where the REPL output goes - stdin stays the os one, as the scanner buffers `in`
+	config := object.NewConfig()
+	config.Stdout = out
+	env := object.NewEnvironmentWithConfig(config)
+	// NOTE: one resolver for the whole session - its global scope is the one of env, the slots have to match
+	names := resolver.New(evaluator.BuiltinNames())
 
 	for {
 		fmt.Fprintf(out, PROMPT)
@@ -96,14 +106,48 @@ func StartREPL(in io.Reader, out io.Writer) {
 
 		program := p.ParseProgram()
 		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+			printDiagnostics(out, line, p.Diagnostics())
+			continue
+		}
+		errors, warnings := resolveErrors(names.Resolve(program))
+		if len(errors) != 0 {
+			printDiagnostics(out, line, errors)
 			continue
 		}
+		if len(warnings) != 0 {
+			printDiagnostics(out, line, warnings)
+		}
 
 		evaluated := evaluator.Eval(program, env)
+		if err, ok := evaluated.(*object.Error); ok {
+			printEvalError(out, line, err)
+			continue
+		}
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
 		}
 	}
 }
+
+// NOTE: only the errors - a global that's not used yet may well be by the next line, so the warnings would be noise.
+// An undefined name inside a function body isn't one either, a later line can define it before the function is
+// called - it's given back as a warning, and the line still runs.
+func resolveErrors(result *resolver.Result) (errors, warnings []parser.Diagnostic) {
+	deferred := map[token.Token]bool{}
+	for _, ident := range result.Deferred {
+		deferred[ident.Token] = true
+	}
+
+	for _, d := range result.Diagnostics {
+		switch {
+		case d.Severity != parser.SeverityError:
+		case deferred[d.Found]:
+			d.Severity = parser.SeverityWarning
+			warnings = append(warnings, d)
+		default:
+			errors = append(errors, d)
+		}
+	}
+	return errors, warnings
+}
@@ -0,0 +1,32 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestREPL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1 + 2; x", "3\n"},
+		// NOTE: the function is called after b is defined - the line defining it only gets a warning
+		{"let f = fn() { b };\nlet b = 2;\nf()", "warning: identifier not found: b\n --> <repl>:1:16\n  |\n1 | let f = fn() { b };\n  |                ^\n2\n"},
+		// NOTE: code that runs right away can't use it
+		{"let y = z;\ny", "error: identifier not found: z\n --> <repl>:1:9\n  |\n1 | let y = z;\n  |         ^\nerror: identifier not found: y\n --> <repl>:1:1\n  |\n1 | y\n  | ^\n"},
+		// NOTE: called on the same line, the warning is followed by the runtime error
+		{"let g = fn() { c }; g()", "warning: identifier not found: c\n --> <repl>:1:16\n  |\n1 | let g = fn() { c }; g()\n  |                ^\nerror: identifier not found: c\n --> <repl>:1:16\n  |\n1 | let g = fn() { c }; g()\n  |                ^\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		StartREPL(strings.NewReader(tt.input), &out)
+
+		got := strings.ReplaceAll(out.String(), PROMPT, "")
+		if got != tt.expected {
+			t.Errorf("wrong output for %q.\nexpected=%q\ngot=     %q", tt.input, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package resolver
+
+import (
+	"cmp"
+	"fmt"
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/parser"
+	"mfiorek/waiig/suggest"
+	"slices"
+	"strings"
+)
+
+// INFO: Static resolution of names - finds what every identifier refers to before the program runs, reports the names
+// that are never defined (an error), the ones that shadow an outer one and the lets and parameters that are never used
+// (warnings), and gives every identifier it resolved its (depth, slot), so the evaluator finds its value without
+// going through the maps of the environments.
+//
+// The scopes are the environments of the evaluator, so the depths match at runtime: the program, every function call
+// and every match arm get one, the blocks of an if don't. A function body is resolved once the scope it is defined in
+// is complete - that's what a closure sees when it is called, so functions can call the ones defined after them.
+
+type Kind int
+
+const (
+	LetBinding       Kind = iota // let x = ... (destructured names too)
+	ParameterBinding             // the parameters of a function (destructured ones and the rest parameter too)
+	MatchBinding                 // the names bound by the pattern of a match arm
+)
+
+type Binding struct {
+	Name *ast.Identifier // where the name is declared
+	Kind Kind
+	// NOTE: the value of a `let name = value` - nil for destructured names, parameters and match bindings
+	Value ast.Expression
+	Scope *Scope
+	Slot  int
+	Uses  []*ast.Identifier // in source order
+}
+
+type Scope struct {
+	Parent *Scope
+	// NOTE: the nodes the scope covers - the *ast.Program, the *ast.FunctionLiteral, or the pattern and the body of a match arm
+	Nodes []ast.Node
+
+	names map[string]*Binding
+	slots int
+}
+
+// NOTE: the binding the name has in this scope or an outer one - nil when it's not declared (yet)
+func (s *Scope) Lookup(name string) *Binding {
+	for current := s; current != nil; current = current.Parent {
+		if binding, ok := current.names[name]; ok {
+			return binding
+		}
+	}
+	return nil
+}
+
+// NOTE: the names declared in the scope itself, sorted
+func (s *Scope) Names() []string {
+	names := make([]string, 0, len(s.names))
+	for name := range s.names {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+type Result struct {
+	Diagnostics []parser.Diagnostic // in source order
+	Scopes      []*Scope            // the global one first
+	Bindings    []*Binding
+	// NOTE: the binding of every identifier that has one - the declarations and the uses
+	Identifiers map[*ast.Identifier]*Binding
+	// NOTE: the uses of names that aren't declared in the program - the predeclared ones and the undefined ones
+	Unresolved []*ast.Identifier
+	// NOTE: the undefined ones used inside a function body - they are only looked up when the function is called, so a
+	// REPL line defining them later still makes the call work
+	Deferred []*ast.Identifier
+}
+
+// NOTE: keeps the global scope between the programs it resolves, so lines of a REPL can use what the earlier ones defined
+type Resolver struct {
+	global      *Scope
+	predeclared map[string]bool
+
+	result  *Result
+	scope   *Scope
+	pending []pendingFunction
+}
+
+type pendingFunction struct {
+	fn    *ast.FunctionLiteral
+	scope *Scope
+}
+
+// NOTE: the predeclared names are the ones that are always there (the builtins and the constants) - they are never undefined
+func New(predeclared []string) *Resolver {
+	r := &Resolver{global: &Scope{names: map[string]*Binding{}}, predeclared: map[string]bool{}}
+	for _, name := range predeclared {
+		r.predeclared[name] = true
+	}
+	return r
+}
+
+// NOTE: annotates the identifiers of the program - the result is only about this program, the global scope included
+func (r *Resolver) Resolve(program *ast.Program) *Result {
+	r.result = &Result{Identifiers: map[*ast.Identifier]*Binding{}}
+	r.global.Nodes = []ast.Node{program}
+	r.result.Scopes = append(r.result.Scopes, r.global)
+	r.scope = r.global
+
+	for _, stmt := range program.Statements {
+		ast.Walk(r, stmt)
+	}
+	// NOTE: function bodies can queue more functions, hence not a range loop
+	for len(r.pending) > 0 {
+		next := r.pending[0]
+		r.pending = r.pending[1:]
+		r.resolveFunction(next.fn, next.scope)
+	}
+
+	r.reportUnused()
+	for _, binding := range r.result.Bindings {
+		slices.SortFunc(binding.Uses, compareIdentifiers)
+	}
+	slices.SortStableFunc(r.result.Diagnostics, func(a, b parser.Diagnostic) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+
+	result := r.result
+	r.result, r.scope = nil, nil
+	return result
+}
+
+func (r *Resolver) Visit(node ast.Node) ast.Visitor {
+	switch node := node.(type) {
+	case *ast.LetStatement:
+		// NOTE: the value is evaluated before the name is bound - let x = x + 1 uses the outer x
+		walkOptional(r, node.Value)
+		if node.Name != nil {
+			r.declare(node.Name, LetBinding, node.Value)
+		} else if node.Pattern != nil {
+			r.declarePattern(node.Pattern, LetBinding)
+		}
+		return nil
+	case *ast.FunctionLiteral:
+		r.pending = append(r.pending, pendingFunction{fn: node, scope: r.scope})
+		return nil
+	case *ast.MatchExpression:
+		ast.Walk(r, node.Subject)
+		for _, arm := range node.Arms {
+			r.openScope(arm.Pattern, arm.Body)
+			r.declarePattern(arm.Pattern, MatchBinding)
+			walkOptional(r, arm.Guard)
+			ast.Walk(r, arm.Body)
+			r.scope = r.scope.Parent
+		}
+		return nil
+	case *ast.Identifier:
+		r.use(node)
+		return nil
+	case nil:
+		return nil
+	}
+	return r
+}
+
+// WARN: Helper method used only in Resolve - the parameters get the scope of the call, the body is walked in it
+func (r *Resolver) resolveFunction(fn *ast.FunctionLiteral, defined *Scope) {
+	r.scope = defined
+	r.openScope(fn)
+	for _, param := range fn.Parameters {
+		r.declarePattern(param, ParameterBinding)
+	}
+	if fn.Rest != nil {
+		r.declare(fn.Rest, ParameterBinding, nil)
+	}
+	if fn.Body != nil {
+		ast.Walk(r, fn.Body)
+	}
+	r.scope = defined
+}
+
+func (r *Resolver) openScope(nodes ...ast.Node) {
+	r.scope = &Scope{Parent: r.scope, Nodes: nodes, names: map[string]*Binding{}}
+	r.result.Scopes = append(r.result.Scopes, r.scope)
+}
+
+// NOTE: declaring a name again in the same scope (let x = 1; let x = 2;) reuses its slot, as the evaluator overwrites it
+func (r *Resolver) declare(name *ast.Identifier, kind Kind, value ast.Expression) {
+	slot := r.scope.slots
+	if previous, ok := r.scope.names[name.Value]; ok {
+		slot = previous.Slot
+	} else {
+		r.scope.slots++
+		if outer := r.scope.Parent.Lookup(name.Value); outer != nil {
+			r.warn(name, "%s shadows the %s declared at %d:%d", name.Value, outer.Kind, outer.Name.Token.Line, outer.Name.Token.Column)
+		}
+	}
+
+	binding := &Binding{Name: name, Kind: kind, Value: value, Scope: r.scope, Slot: slot}
+	r.scope.names[name.Value] = binding
+	r.result.Bindings = append(r.result.Bindings, binding)
+	r.result.Identifiers[name] = binding
+	name.Resolved, name.Depth, name.Slot = true, 0, slot
+}
+
+// NOTE: the defaults are evaluated before their pattern binds anything, so they are walked first
+func (r *Resolver) declarePattern(pattern ast.Pattern, kind Kind) {
+	switch pattern := pattern.(type) {
+	case *ast.IdentifierPattern:
+		r.declare(pattern.Name, kind, nil)
+	case *ast.DefaultPattern:
+		ast.Walk(r, pattern.Default)
+		r.declarePattern(pattern.Pattern, kind)
+	case *ast.ArrayPattern:
+		for _, el := range pattern.Elements {
+			r.declarePattern(el, kind)
+		}
+		if pattern.Rest != nil {
+			r.declare(pattern.Rest, kind, nil)
+		}
+	case *ast.HashPattern:
+		for _, pair := range pattern.Pairs {
+			ast.Walk(r, pair.Key)
+			r.declarePattern(pair.Value, kind)
+		}
+		if pattern.Rest != nil {
+			r.declare(pattern.Rest, kind, nil)
+		}
+	case *ast.LiteralPattern:
+		ast.Walk(r, pattern.Value)
+	}
+}
+
+func (r *Resolver) use(ident *ast.Identifier) {
+	depth := 0
+	for current := r.scope; current != nil; current = current.Parent {
+		if binding, ok := current.names[ident.Value]; ok {
+			binding.Uses = append(binding.Uses, ident)
+			r.result.Identifiers[ident] = binding
+			ident.Resolved, ident.Depth, ident.Slot = true, depth, binding.Slot
+			return
+		}
+		depth++
+	}
+
+	ident.Resolved = false
+	r.result.Unresolved = append(r.result.Unresolved, ident)
+	if r.predeclared[ident.Value] {
+		return
+	}
+
+	d := parser.Diagnostic{
+		Severity: parser.SeverityError,
+		Line:     ident.Token.Line,
+		Column:   ident.Token.Column,
+		Message:  "identifier not found: " + ident.Value,
+		Found:    ident.Token,
+	}
+	if suggestion := suggest.Closest(ident.Value, r.visibleNames()); suggestion != "" {
+		d.Hint = fmt.Sprintf("did you mean `%s`?", suggestion)
+	}
+	r.result.Diagnostics = append(r.result.Diagnostics, d)
+	if r.inFunction() {
+		r.result.Deferred = append(r.result.Deferred, ident)
+	}
+}
+
+// WARN: Helper method used only in use - the parameter defaults are evaluated at the call too, they are in the function's scope
+func (r *Resolver) inFunction() bool {
+	for current := r.scope; current != nil; current = current.Parent {
+		if _, ok := current.Nodes[0].(*ast.FunctionLiteral); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WARN: Helper method used only in use - the candidates of a "did you mean" hint
+func (r *Resolver) visibleNames() []string {
+	names := []string{}
+	for current := r.scope; current != nil; current = current.Parent {
+		for name := range current.names {
+			names = append(names, name)
+		}
+	}
+	for name := range r.predeclared {
+		names = append(names, name)
+	}
+	return names
+}
+
+// NOTE: names starting with _ are meant to be unused (fn(_event) { ... }). Only the bindings of this program are looked at,
+// so a global a later line of a REPL uses is reported too - the REPL shows only the errors.
+func (r *Resolver) reportUnused() {
+	for _, binding := range r.result.Bindings {
+		if len(binding.Uses) > 0 || strings.HasPrefix(binding.Name.Value, "_") {
+			continue
+		}
+		r.warn(binding.Name, "%s %s is never used", binding.Kind, binding.Name.Value)
+	}
+}
+
+func (r *Resolver) warn(ident *ast.Identifier, format string, args ...any) {
+	r.result.Diagnostics = append(r.result.Diagnostics, parser.Diagnostic{
+		Severity: parser.SeverityWarning,
+		Line:     ident.Token.Line,
+		Column:   ident.Token.Column,
+		Message:  fmt.Sprintf(format, args...),
+		Found:    ident.Token,
+	})
+}
+
+func (k Kind) String() string {
+	switch k {
+	case LetBinding:
+		return "variable"
+	case ParameterBinding:
+		return "parameter"
+	case MatchBinding:
+		return "match binding"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// WARN: Helper method used only in Visit - the value of a let and the guard of an arm can be missing
+func walkOptional(v ast.Visitor, node ast.Node) {
+	if node != nil {
+		ast.Walk(v, node)
+	}
+}
+
+func compareIdentifiers(a, b *ast.Identifier) int {
+	return cmp.Or(cmp.Compare(a.Token.Line, b.Token.Line), cmp.Compare(a.Token.Column, b.Token.Column))
+}
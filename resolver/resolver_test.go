@@ -0,0 +1,214 @@
+package resolver
+
+import (
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/lexer"
+	"mfiorek/waiig/parser"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []parser.Diagnostic
+	}{
+		{"let a = 1; puts(a);", nil},
+		{"let counter = 1;\nputs(countr);", []parser.Diagnostic{
+			{Severity: parser.SeverityWarning, Line: 1, Column: 5, Message: "variable counter is never used"},
+			{Severity: parser.SeverityError, Line: 2, Column: 6, Message: "identifier not found: countr", Hint: "did you mean `counter`?"},
+		}},
+		{"puts(lenn([]));", []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Column: 6, Message: "identifier not found: lenn", Hint: "did you mean `len`?"},
+		}},
+		{"if (false) { completely_unknown }", []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Column: 14, Message: "identifier not found: completely_unknown"},
+		}},
+		{"let x = 1; let f = fn(x) { x }; f(x);", []parser.Diagnostic{
+			{Severity: parser.SeverityWarning, Line: 1, Column: 23, Message: "x shadows the variable declared at 1:5"},
+		}},
+		{"let f = fn(a, b) { a }; f(1, 2);", []parser.Diagnostic{
+			{Severity: parser.SeverityWarning, Line: 1, Column: 15, Message: "parameter b is never used"},
+		}},
+		{"let f = fn(_a, _b) { 1 }; f(1, 2);", nil},
+		{"let v = 5; match (v) { n => 1 }", []parser.Diagnostic{
+			{Severity: parser.SeverityWarning, Line: 1, Column: 24, Message: "match binding n is never used"},
+		}},
+		{"let f = fn() { g() }; let g = fn() { 1 }; f();", nil},
+		{"let x = x + 1;", []parser.Diagnostic{
+			{Severity: parser.SeverityWarning, Line: 1, Column: 5, Message: "variable x is never used"},
+			{Severity: parser.SeverityError, Line: 1, Column: 9, Message: "identifier not found: x"},
+		}},
+		{"let [a, ...rest] = [1, 2]; let {\"k\": k = a} = {}; puts(rest, k);", nil},
+	}
+
+	for _, tt := range tests {
+		result := testResolve(t, tt.input)
+		testDiagnostics(t, tt.input, result.Diagnostics, tt.expected)
+	}
+}
+
+func TestIdentifierAnnotations(t *testing.T) {
+	input := `let a = 1;
+let b = 2;
+let a = 3;
+let f = fn(x, y) {
+  let z = fn() { x + a };
+  z() + y
+};
+match (b) { n => n + a }`
+
+	result := testResolve(t, input)
+
+	tests := []struct {
+		line, column int
+		name         string
+		depth, slot  int
+	}{
+		{3, 5, "a", 0, 0}, // NOTE: redeclared in the same scope, so the slot of the first a
+		{4, 12, "x", 0, 0},
+		{4, 15, "y", 0, 1},
+		{5, 7, "z", 0, 2},
+		{5, 18, "x", 1, 0},
+		{5, 22, "a", 2, 0},
+		{6, 3, "z", 0, 2},
+		{6, 9, "y", 0, 1},
+		{8, 8, "b", 0, 1},
+		{8, 13, "n", 0, 0},
+		{8, 18, "n", 0, 0},
+		{8, 22, "a", 1, 0},
+	}
+
+	for _, tt := range tests {
+		ident := findIdentifier(result, tt.line, tt.column)
+		if ident == nil {
+			t.Errorf("no identifier at %d:%d", tt.line, tt.column)
+			continue
+		}
+		if ident.Value != tt.name {
+			t.Errorf("wrong identifier at %d:%d. expected=%s, got=%s", tt.line, tt.column, tt.name, ident.Value)
+		}
+		if !ident.Resolved || ident.Depth != tt.depth || ident.Slot != tt.slot {
+			t.Errorf("wrong annotation of %s at %d:%d. expected=(%d, %d), got=(%d, %d) resolved=%t",
+				tt.name, tt.line, tt.column, tt.depth, tt.slot, ident.Depth, ident.Slot, ident.Resolved)
+		}
+	}
+
+	if len(result.Scopes) != 4 {
+		t.Errorf("wrong number of scopes. expected=4, got=%d", len(result.Scopes))
+	}
+	if len(result.Unresolved) != 0 {
+		t.Errorf("expected no unresolved identifiers. got=%d", len(result.Unresolved))
+	}
+}
+
+func TestBindings(t *testing.T) {
+	input := `let add = fn(a, b) { a + b };
+add(1, 2) + add(3, 4) + len([])`
+
+	result := testResolve(t, input)
+
+	add := result.Scopes[0].Lookup("add")
+	if add == nil {
+		t.Fatalf("add not declared in the global scope")
+	}
+	if add.Kind != LetBinding || add.Name.Token.Line != 1 || add.Name.Token.Column != 5 {
+		t.Errorf("wrong binding of add. got=%s at %d:%d", add.Kind, add.Name.Token.Line, add.Name.Token.Column)
+	}
+	if _, ok := add.Value.(*ast.FunctionLiteral); !ok {
+		t.Errorf("wrong value of add. got=%T", add.Value)
+	}
+	if len(add.Uses) != 2 || add.Uses[0].Token.Column != 1 || add.Uses[1].Token.Column != 13 {
+		t.Errorf("wrong uses of add. got=%d", len(add.Uses))
+	}
+
+	fn := result.Scopes[1]
+	if fn.Parent != result.Scopes[0] {
+		t.Errorf("wrong parent of the function scope")
+	}
+	if names := fn.Names(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("wrong names of the function scope. got=%v", names)
+	}
+	if fn.Lookup("add") != add {
+		t.Errorf("add not visible in the function scope")
+	}
+
+	if len(result.Unresolved) != 1 || result.Unresolved[0].Value != "len" {
+		t.Errorf("expected len to be unresolved. got=%v", result.Unresolved)
+	}
+}
+
+// NOTE: the way the REPL uses it - each line sees the globals of the lines before
+func TestGlobalScopeAcrossPrograms(t *testing.T) {
+	r := New([]string{"puts"})
+
+	first := r.Resolve(parse(t, "let a = 1; let b = 2;"))
+	if len(first.Diagnostics) != 2 {
+		t.Errorf("expected both globals to be unused in the first line. got=%v", first.Diagnostics)
+	}
+
+	second := r.Resolve(parse(t, "let c = a; puts(b, c);"))
+	testDiagnostics(t, "second line", second.Diagnostics, nil)
+
+	ident := findIdentifier(second, 1, 17)
+	if ident == nil || ident.Value != "b" || ident.Slot != 1 {
+		t.Errorf("wrong annotation of b. got=%+v", ident)
+	}
+	c := second.Scopes[0].Lookup("c")
+	if c == nil || c.Slot != 2 {
+		t.Errorf("wrong slot of c. got=%+v", c)
+	}
+}
+
+// NOTE: only the undefined names inside function bodies (the defaults of parameters too) are deferred
+func TestDeferred(t *testing.T) {
+	result := testResolve(t, "let f = fn(x = y) { a + x }; b; match (1) { _ => c }")
+
+	got := []string{}
+	for _, ident := range result.Deferred {
+		got = append(got, ident.Value)
+	}
+	if strings.Join(got, ",") != "y,a" {
+		t.Errorf("wrong deferred identifiers. expected=%q, got=%q", "y,a", got)
+	}
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
+
+func testResolve(t *testing.T, input string) *Result {
+	t.Helper()
+	return New([]string{"puts", "len"}).Resolve(parse(t, input))
+}
+
+func testDiagnostics(t *testing.T, input string, got, expected []parser.Diagnostic) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Errorf("wrong number of diagnostics for %q. expected=%d, got=%d (%+v)", input, len(expected), len(got), got)
+		return
+	}
+	for i, d := range got {
+		e := expected[i]
+		if d.Severity != e.Severity || d.Line != e.Line || d.Column != e.Column || d.Message != e.Message || d.Hint != e.Hint {
+			t.Errorf("wrong diagnostic %d for %q.\nexpected=%+v\ngot=     %+v", i, input, e, d)
+		}
+	}
+}
+
+func findIdentifier(result *Result, line, column int) *ast.Identifier {
+	for ident := range result.Identifiers {
+		if ident.Token.Line == line && ident.Token.Column == column {
+			return ident
+		}
+	}
+	return nil
+}
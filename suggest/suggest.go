@@ -0,0 +1,76 @@
+package suggest
+
+import "slices"
+
+// INFO: "did you mean" suggestions - the closest candidate by edit distance, when it is close enough to be a typo.
+// It imports nothing of the interpreter, so the evaluator, the resolver and the checker can all suggest names
+// without depending on one another (or on how the diagnostics are rendered).
+
+// NOTE: gives "" when no candidate is close enough - the distance allowed grows with the name (a third of it, at least 1),
+// but never covers the whole name, so `lenn` suggests `len` but `x` doesn't suggest `y`.
+// Ties go to a candidate made of the same letters (`pust` is `puts` rather than `push`), then to the alphabetically first.
+func Closest(name string, candidates []string) string {
+	length := len([]rune(name))
+	maxDistance := min(max(1, length/3), length-1)
+
+	best, bestDistance := "", maxDistance+1
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		distance := editDistance(name, candidate)
+		if distance > maxDistance {
+			continue
+		}
+		if distance < bestDistance || distance == bestDistance && betterTie(name, candidate, best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best
+}
+
+// WARN: Helper method used only in Closest
+func betterTie(name, candidate, best string) bool {
+	if sameLetters(name, candidate) != sameLetters(name, best) {
+		return sameLetters(name, candidate)
+	}
+	return candidate < best
+}
+
+func sameLetters(a, b string) bool {
+	s, t := []rune(a), []rune(b)
+	slices.Sort(s)
+	slices.Sort(t)
+	return slices.Equal(s, t)
+}
+
+// NOTE: the optimal string alignment distance - Levenshtein, but swapping two neighbours (`pust` / `puts`) counts as one edit
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+
+	// NOTE: three rows are enough - the transposition looks two rows back
+	prevPrev := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = min(cur[j], prevPrev[j-2]+1)
+			}
+		}
+		prevPrev, prev, cur = prev, cur, prevPrev
+	}
+
+	return prev[len(t)]
+}
@@ -0,0 +1,52 @@
+package suggest
+
+import "testing"
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"len", "puts", "push", "first", "rest", "map", "x", "filter", "reduce"}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"lenn", "len"},
+		{"ln", "len"},
+		{"pust", "puts"},
+		{"pusj", "push"},
+		{"frist", "first"},
+		{"filtre", "filter"},
+		{"reduc", "reduce"},
+		{"y", ""},
+		{"x", ""},
+		{"completely_different", ""},
+		{"mpa", "map"},
+	}
+
+	for _, tt := range tests {
+		if got := Closest(tt.name, candidates); got != tt.expected {
+			t.Errorf("Closest(%q) wrong. expected=%q, got=%q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"puts", "pust", 1},
+		{"ab", "ba", 1},
+		{"ca", "abc", 3},
+		{"zażółć", "zazolc", 4},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("editDistance(%q, %q) wrong. expected=%d, got=%d", tt.a, tt.b, tt.expected, got)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package token
 
+import "slices"
+
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	// NOTE: where the token starts in the source, both 1-based (the column counts bytes) - 0 when unknown
+	Line   int
+	Column int
 }
 
 // INFO:
@@ -22,6 +27,7 @@ const (
 	// Identifiers + literals
 	IDENT = "IDENT" // add, foobar, x, y, ...
 	INT   = "INT"   // 1343456
+	FLOAT = "FLOAT" // 3.14
 
 	// Operators
 	ASSIGN   = "="
@@ -30,16 +36,34 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
+	POWER    = "**"
+
+	AMPERSAND   = "&"
+	PIPE        = "|"
+	CARET       = "^"
+	TILDE       = "~"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
 
 	LT     = "<"
 	GT     = ">"
+	LT_EQ  = "<="
+	GT_EQ  = ">="
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	AND = "&&"
+	OR  = "||"
+
+	PIPELINE = "|>"
+
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	ARROW     = "=>"
+	ELLIPSIS  = "..."
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -50,6 +74,9 @@ const (
 
 	STRING = "STRING"
 
+	// NOTE: comments never reach the parser, the lexer keeps them aside (see Lexer.Comments)
+	COMMENT = "COMMENT" // // to the end of the line
+
 	// Keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
@@ -58,6 +85,7 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	MATCH    = "MATCH"
 )
 
 var keywords = map[string]TokenType{
@@ -68,6 +96,7 @@ var keywords = map[string]TokenType{
 	"if":     IF,
 	"else":   ELSE,
 	"return": RETURN,
+	"match":  MATCH,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -76,3 +105,13 @@ func LookupIdent(ident string) TokenType {
 	}
 	return IDENT
 }
+
+// NOTE: all the keywords, sorted - for tools (like the completion of the language server)
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,135 @@
+package types
+
+// INFO: The types of the builtins and the constants - a, b are generic (every use gets its own), the builtins taking
+// values of more than one type (len of an ARRAY or a STRING, the math of INTEGERs and FLOATs, the TIMEs and
+// DURATIONs) take `any`. The builtins missing here are `any` as a whole, so their calls aren't checked at all.
+// NOTE: the builtins adding to an array give [any] - the array can hold values of any type, so push([1], "a") is fine
+// NOTE: every name here must be a builtin or a constant, TestBuiltinTypes makes sure none is misspelled
+
+var builtinTypes = map[string]*scheme{}
+
+func init() {
+	a, b := &Variable{}, &Variable{}
+	generic := func(t Type) *scheme { return &scheme{generic: []*Variable{a, b}, typ: t} }
+	plain := func(t Type) *scheme { return &scheme{typ: t} }
+	predicate := fn(a).returns(Any)
+
+	for name, s := range map[string]*scheme{
+		// NOTE: builtins.go
+		"len":     plain(fn(Any).returns(Int)),
+		"first":   generic(fn(&Array{a}).returns(a)),
+		"last":    generic(fn(&Array{a}).returns(a)),
+		"rest":    generic(fn(&Array{a}).returns(&Array{a})),
+		"push":    generic(fn(&Array{a}, Any).returns(&Array{Any})),
+		"append":  generic(fn(&Array{a}).rest(Any).returns(&Array{Any})),
+		"pop":     generic(fn(&Array{a}).returns(a)),
+		"shift":   generic(fn(&Array{a}).returns(a)),
+		"unshift": generic(fn(&Array{a}, Any).returns(&Array{Any})),
+		"insert":  generic(fn(&Array{a}, Int, Any).returns(&Array{Any})),
+		"puts":    plain(fn().rest(Any).returns(Null)),
+
+		// NOTE: builtins_collections.go
+		"map":    generic(fn(&Array{a}, fn(a).returns(b)).returns(&Array{b})),
+		"filter": generic(fn(&Array{a}, predicate).returns(&Array{a})),
+		"reduce": generic(fn(&Array{a}, fn(b, a).returns(b), b).optional(1).returns(b)),
+		"each":   generic(fn(&Array{a}, predicate).returns(Null)),
+		"find":   generic(fn(&Array{a}, predicate).returns(a)),
+		"any":    generic(fn(&Array{a}, predicate).returns(Bool)),
+		"all":    generic(fn(&Array{a}, predicate).returns(Bool)),
+		"sort":   generic(fn(&Array{a}, fn(a, a).returns(Int)).optional(1).returns(&Array{a})),
+		"range":  plain(fn(Int, Int, Int).optional(2).returns(&Array{Int})),
+		"keys":   generic(fn(&Hash{a, b}).returns(&Array{a})),
+		"values": generic(fn(&Hash{a, b}).returns(&Array{b})),
+
+		// NOTE: builtins_fs.go
+		"read_file": plain(fn(String).returns(String)),
+		"list_dir":  plain(fn(String).optional(1).returns(&Array{String})),
+		"exists":    plain(fn(String).returns(Bool)),
+
+		// NOTE: builtins_io.go
+		"print":    plain(fn().rest(Any).returns(Null)),
+		"eprint":   plain(fn().rest(Any).returns(Null)),
+		"read_all": plain(fn().returns(String)),
+
+		// NOTE: builtins_json.go
+		"json_parse":     plain(fn(String).returns(Any)),
+		"json_stringify": plain(fn(Any, Any).optional(1).returns(String)),
+
+		// NOTE: builtins_math.go
+		"sqrt":    plain(fn(Any).returns(Float)),
+		"exp":     plain(fn(Any).returns(Float)),
+		"log10":   plain(fn(Any).returns(Float)),
+		"log2":    plain(fn(Any).returns(Float)),
+		"sin":     plain(fn(Any).returns(Float)),
+		"cos":     plain(fn(Any).returns(Float)),
+		"tan":     plain(fn(Any).returns(Float)),
+		"asin":    plain(fn(Any).returns(Float)),
+		"acos":    plain(fn(Any).returns(Float)),
+		"atan":    plain(fn(Any).returns(Float)),
+		"atan2":   plain(fn(Any, Any).returns(Float)),
+		"log":     plain(fn(Any, Any).optional(1).returns(Float)),
+		"floor":   plain(fn(Any).returns(Int)),
+		"ceil":    plain(fn(Any).returns(Int)),
+		"choice":  generic(fn(&Array{a}).returns(a)),
+		"shuffle": generic(fn(&Array{a}).returns(&Array{a})),
+
+		// NOTE: builtins_strings.go
+		"split":       plain(fn(String, String).optional(1).returns(&Array{String})),
+		"join":        plain(fn(&Array{Any}, String).optional(1).returns(String)),
+		"trim":        plain(fn(String, String).optional(1).returns(String)),
+		"trim_left":   plain(fn(String, String).optional(1).returns(String)),
+		"trim_right":  plain(fn(String, String).optional(1).returns(String)),
+		"upper":       plain(fn(String).returns(String)),
+		"lower":       plain(fn(String).returns(String)),
+		"contains":    plain(fn(String, String).returns(Bool)),
+		"starts_with": plain(fn(String, String).returns(Bool)),
+		"ends_with":   plain(fn(String, String).returns(Bool)),
+		"index_of":    plain(fn(String, String).returns(Int)),
+		"replace":     plain(fn(String, String, String, Int).optional(1).returns(String)),
+		"repeat":      plain(fn(String, Int).returns(String)),
+		"pad_left":    plain(fn(String, Int, String).optional(1).returns(String)),
+		"pad_right":   plain(fn(String, Int, String).optional(1).returns(String)),
+		"chars":       plain(fn(String).returns(&Array{String})),
+		"format":      plain(fn(String).rest(Any).returns(String)),
+		"sprintf":     plain(fn(String).rest(Any).returns(String)),
+		"parse_int":   plain(fn(String, Int).optional(1).returns(Int)),
+		"parse_float": plain(fn(String).returns(Float)),
+		"to_string":   plain(fn(Any).returns(String)),
+
+		// NOTE: builtins_time.go
+		"time_format":      plain(fn(Any, String).optional(1).returns(String)),
+		"duration_seconds": plain(fn(Any).returns(Float)),
+
+		// NOTE: constants
+		"PI":      plain(Float),
+		"E":       plain(Float),
+		"INF":     plain(Float),
+		"MAX_INT": plain(Int),
+		"MIN_INT": plain(Int),
+	} {
+		builtinTypes[name] = s
+	}
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+// NOTE: fn(int, string).optional(1).returns(bool) is fn(int, string?): bool
+func fn(params ...Type) *Function {
+	return &Function{Params: params, Required: len(params), Return: Null}
+}
+
+func (f *Function) returns(t Type) *Function {
+	f.Return = t
+	return f
+}
+
+// NOTE: the last n parameters can be left out
+func (f *Function) optional(n int) *Function {
+	f.Required = len(f.Params) - n
+	return f
+}
+
+func (f *Function) rest(t Type) *Function {
+	f.Rest = t
+	return f
+}
@@ -0,0 +1,871 @@
+package types
+
+import (
+	"cmp"
+	"fmt"
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/parser"
+	"mfiorek/waiig/suggest"
+	"mfiorek/waiig/token"
+	"slices"
+)
+
+// INFO: Static type checking - the annotations (let x: int = 5, fn(a: string): int { ... }) are checked, and the types
+// of everything not annotated are inferred Hindley-Milner style: an unannotated parameter gets a type variable, which
+// the way it's used pins down (a + "!" makes it a string), and a function bound by a let is generic in what is left
+// (let id = fn(x) { x } works on any x). The errors are the ones the evaluator would only find at runtime - the
+// operators on the wrong types, calls of non-functions and with the wrong number of arguments - and the values not
+// matching their annotations.
+//
+// The checker is optional: the evaluator ignores the annotations, and the program runs whatever the checker says.
+// The scopes are the ones of the evaluator (a function call and a match arm get one, the blocks of an if don't), but
+// a function only sees the names defined before it - the ones defined after it are `any`.
+
+// NOTE: a type with the variables that are generic in it - every use of the name gets fresh ones in their place
+type scheme struct {
+	generic []*Variable
+	typ     Type
+	// NOTE: the type is the one of an annotation - only then the elements assigned to have to be of its element type,
+	// an inferred [int] can just as well get a string later (let xs = [1]; xs[0] = "a")
+	annotated bool
+}
+
+type scope struct {
+	parent *scope
+	names  map[string]*scheme
+}
+
+func (s *scope) lookup(name string) *scheme {
+	for current := s; current != nil; current = current.parent {
+		if found, ok := current.names[name]; ok {
+			return found
+		}
+	}
+	return nil
+}
+
+// NOTE: the return type of the function literal being checked - inferred from the values it returns, unless annotated
+type function struct {
+	ret       Type // nil until the first return without an annotation
+	annotated bool
+}
+
+type checker struct {
+	global *scope
+
+	scope       *scope
+	level       int
+	functions   []*function // innermost last
+	diagnostics []parser.Diagnostic
+	nextID      int
+}
+
+// NOTE: the type errors of the program, in source order
+func Check(program *ast.Program) []parser.Diagnostic {
+	return check(program).diagnostics
+}
+
+// WARN: Helper method used only in Check (and the tests, which look up the inferred types of the globals)
+func check(program *ast.Program) *checker {
+	c := &checker{global: &scope{names: map[string]*scheme{}}}
+	c.scope = c.global
+	for _, stmt := range program.Statements {
+		c.statement(stmt)
+	}
+
+	slices.SortStableFunc(c.diagnostics, func(a, b parser.Diagnostic) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return c
+}
+
+// NOTE: the type of a global the program defined - nil when there is none
+func (c *checker) lookup(name string) Type {
+	if found, ok := c.global.names[name]; ok {
+		return found.typ
+	}
+	return nil
+}
+
+// INFO: ==================================== Statements ====================================
+
+// NOTE: the value of the statement - nil when it always returns (a return, or an if whose branches both do)
+func (c *checker) statement(stmt ast.Statement) Type {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		c.let(stmt)
+		return Null
+	case *ast.ReturnStatement:
+		if stmt.ReturnValue == nil {
+			c.returned(Null, stmt)
+		} else {
+			c.returned(c.value(stmt.ReturnValue), stmt.ReturnValue)
+		}
+		return nil
+	case *ast.ExpressionStatement:
+		if stmt.Expression == nil {
+			return Null
+		}
+		return c.expression(stmt.Expression)
+	case *ast.BlockStatement:
+		return c.block(stmt)
+	}
+	return Any
+}
+
+// NOTE: the value of the last statement - the statements after one that always returns are still checked
+func (c *checker) block(block *ast.BlockStatement) Type {
+	if block == nil {
+		return Null
+	}
+
+	var value Type = Null
+	returns := false
+	for _, stmt := range block.Statements {
+		value = c.statement(stmt)
+		returns = returns || value == nil
+	}
+	if returns {
+		return nil
+	}
+	return value
+}
+
+func (c *checker) let(stmt *ast.LetStatement) {
+	if stmt.Name == nil {
+		if stmt.Pattern != nil {
+			c.bindPattern(stmt.Pattern, c.value(stmt.Value))
+		}
+		return
+	}
+
+	var annotation Type
+	if stmt.Type != nil {
+		annotation = c.annotation(stmt.Type)
+	}
+
+	c.level++
+	var value Type
+	fn, isFunction := stmt.Value.(*ast.FunctionLiteral)
+	if isFunction {
+		// NOTE: the name is bound before the body is checked, so the function can call itself (it isn't generic in its own body)
+		self := c.newVariable()
+		if annotation != nil {
+			unify(self, annotation)
+		}
+		c.scope.names[stmt.Name.Value] = &scheme{typ: self}
+		value = c.function(fn)
+		unify(self, value)
+	} else {
+		value = c.value(stmt.Value)
+	}
+	c.level--
+
+	if annotation != nil {
+		c.expect(annotation, value, stmt.Value, "cannot use %s as %s in the declaration of %s", stmt.Name.Value)
+		value = annotation
+	}
+	// NOTE: only functions are generalized - let xs = [] is a single array, it can't hold ints and strings at the same time
+	if isFunction {
+		c.scope.names[stmt.Name.Value] = c.generalize(value)
+	} else {
+		c.scope.names[stmt.Name.Value] = &scheme{typ: value, annotated: annotation != nil}
+	}
+}
+
+// NOTE: binds the names of a destructuring pattern or a match arm to what they get from a value of type t - the parts
+// of a value that isn't known to be an array or a hash are `any`, the pattern might not even match
+func (c *checker) bindPattern(pattern ast.Pattern, t Type) {
+	switch pattern := pattern.(type) {
+	case *ast.IdentifierPattern:
+		c.declare(pattern.Name, t)
+	case *ast.DefaultPattern:
+		c.bindPattern(pattern.Pattern, join(t, c.value(pattern.Default)))
+	case *ast.LiteralPattern:
+		c.value(pattern.Value)
+	case *ast.ArrayPattern:
+		var element Type = Any
+		if array, ok := prune(t).(*Array); ok {
+			element = array.Element
+		}
+		for _, el := range pattern.Elements {
+			c.bindPattern(el, element)
+		}
+		if pattern.Rest != nil {
+			c.declare(pattern.Rest, &Array{Element: element})
+		}
+	case *ast.HashPattern:
+		hash, ok := prune(t).(*Hash)
+		if !ok {
+			hash = &Hash{Key: Any, Value: Any}
+		}
+		for _, pair := range pattern.Pairs {
+			c.value(pair.Key)
+			c.bindPattern(pair.Value, hash.Value)
+		}
+		if pattern.Rest != nil {
+			c.declare(pattern.Rest, hash)
+		}
+	}
+}
+
+func (c *checker) declare(name *ast.Identifier, t Type) {
+	c.scope.names[name.Value] = &scheme{typ: t}
+}
+
+// INFO: ==================================== Expressions ====================================
+
+// NOTE: the type of an expression whose value is used - an if whose branches both return has none
+func (c *checker) value(exp ast.Expression) Type {
+	if exp == nil {
+		return Any
+	}
+	if t := c.expression(exp); t != nil {
+		return t
+	}
+	return Any
+}
+
+func (c *checker) expression(exp ast.Expression) Type {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+	case *ast.FloatLiteral:
+		return Float
+	case *ast.StringLiteral:
+		return String
+	case *ast.Boolean:
+		return Bool
+	case *ast.Identifier:
+		return c.identifier(exp)
+	case *ast.PrefixExpression:
+		return c.prefix(exp)
+	case *ast.InfixExpression:
+		return c.infix(exp)
+	case *ast.IfExpression:
+		c.value(exp.Condition)
+		return join(c.block(exp.Consequence), c.block(exp.Alternative))
+	case *ast.FunctionLiteral:
+		return c.function(exp)
+	case *ast.CallExpression:
+		return c.call(exp)
+	case *ast.ArrayLiteral:
+		return c.array(exp)
+	case *ast.SpreadExpression:
+		c.value(exp.Value)
+		return Any
+	case *ast.IndexExpression:
+		return c.index(exp.Left, exp.Index, exp.Token)
+	case *ast.SliceExpression:
+		return c.slice(exp)
+	case *ast.AssignExpression:
+		return c.assign(exp)
+	case *ast.HashLiteral:
+		return c.hash(exp)
+	case *ast.MatchExpression:
+		return c.match(exp)
+	}
+	return Any
+}
+
+// NOTE: the names neither the program nor the builtins define are `any` - the resolver is the one reporting them
+func (c *checker) identifier(ident *ast.Identifier) Type {
+	if found := c.scope.lookup(ident.Value); found != nil {
+		return c.instantiate(found)
+	}
+	if found, ok := builtinTypes[ident.Value]; ok {
+		return c.instantiate(found)
+	}
+	return Any
+}
+
+func (c *checker) prefix(node *ast.PrefixExpression) Type {
+	right := c.value(node.Right)
+	switch node.Operator {
+	case "-":
+		switch t := prune(right); t {
+		case Int, Float, Any:
+			return t
+		default:
+			if _, ok := t.(*Variable); ok {
+				return t
+			}
+		}
+	case "~":
+		if unify(Int, right) {
+			return prune(right)
+		}
+	default:
+		return Bool
+	}
+
+	c.errorf(node.Token, "", "unknown operator: %s%s", node.Operator, right)
+	return Any
+}
+
+// NOTE: mirrors evalInfixExpression - ints give an int, an int and a float a float, == and != take anything.
+// An operand not known yet (a type variable) becomes the type of the other one - unless that's a float.
+func (c *checker) infix(node *ast.InfixExpression) Type {
+	left, right := c.value(node.Left), c.value(node.Right)
+	switch node.Operator {
+	case "==", "!=":
+		return Bool
+	case "&&", "||":
+		return join(left, right)
+	}
+
+	comparison := slices.Contains([]string{"<", ">", "<=", ">="}, node.Operator)
+	result := func(t Type) Type {
+		if comparison {
+			return Bool
+		}
+		return t
+	}
+
+	l, r := prune(left), prune(right)
+	_, lVar := l.(*Variable)
+	_, rVar := r.(*Variable)
+	switch {
+	case l == Any || r == Any:
+		return result(Any)
+	case lVar && rVar:
+		// NOTE: a + b adds ints, floats or strings - or an int and a float, so neither pins down the other
+		return result(Any)
+	case l == Float || r == Float:
+		// NOTE: an int works with a float just as well, so the variable is left as it is
+		if (lVar || rVar) && operand(node.Operator, Float) {
+			return result(Float)
+		}
+	case (lVar || rVar) && (l == Int || r == Int) && operand(node.Operator, Float):
+		// NOTE: x + 1 works for a float x too (it gives a float), so x isn't pinned down to an int
+		return result(Any)
+	case lVar && operand(node.Operator, r):
+		unify(l, r)
+		l, lVar = r, false
+	case rVar && operand(node.Operator, l):
+		unify(r, l)
+		r, rVar = l, false
+	}
+	if lVar || rVar {
+		return result(Any)
+	}
+
+	numbers := isNumber(l) && isNumber(r)
+	switch {
+	case l == Int && r == Int && operand(node.Operator, Int):
+		// NOTE: a negative exponent gives a float
+		if node.Operator == "**" {
+			return Any
+		}
+		return result(Int)
+	case numbers && operand(node.Operator, Float):
+		return result(Float)
+	case l == String && r == String && operand(node.Operator, String):
+		return result(String)
+	case !numbers && kind(l) != kind(r):
+		c.errorf(node.Token, "", "type mismatch: %s %s %s", l, node.Operator, r)
+	default:
+		c.errorf(node.Token, "", "unknown operator: %s %s %s", l, node.Operator, r)
+	}
+	return Any
+}
+
+// WARN: Helper method used only in infix - whether the operator works on values of the (basic) type
+func operand(operator string, t Type) bool {
+	switch operator {
+	case "+", "<", ">", "<=", ">=":
+		return t == Int || t == Float || t == String
+	case "-", "*", "/", "%", "**":
+		return t == Int || t == Float
+	case "&", "|", "^", "<<", ">>":
+		return t == Int
+	}
+	return false
+}
+
+func isNumber(t Type) bool {
+	return t == Int || t == Float
+}
+
+// NOTE: the type without what's in it - [int] and [string] are both arrays, a mix of them is no type mismatch for the evaluator
+func kind(t Type) string {
+	switch t := prune(t).(type) {
+	case *Basic:
+		return t.Name
+	case *Array:
+		return "array"
+	case *Hash:
+		return "hash"
+	case *Function:
+		return "function"
+	}
+	return "variable"
+}
+
+func (c *checker) function(fn *ast.FunctionLiteral) *Function {
+	outer := c.scope
+	c.scope = &scope{parent: outer, names: map[string]*scheme{}}
+	defer func() { c.scope = outer }()
+
+	t := &Function{}
+	for _, param := range fn.Parameters {
+		t.Params = append(t.Params, c.parameter(param))
+		if _, ok := param.(*ast.DefaultPattern); !ok {
+			t.Required++
+		}
+	}
+	// NOTE: a rest parameter can't be annotated, and its arguments can be of any types - f(1, "a", true) is just fine
+	if fn.Rest != nil {
+		t.Rest = Any
+		c.declare(fn.Rest, &Array{Element: t.Rest})
+	}
+
+	current := &function{}
+	if fn.ReturnType != nil {
+		current.ret, current.annotated = c.annotation(fn.ReturnType), true
+	}
+	c.functions = append(c.functions, current)
+	if fn.Body != nil {
+		if value := c.block(fn.Body); value != nil {
+			c.returned(value, implicitReturn(fn))
+		}
+	}
+	c.functions = c.functions[:len(c.functions)-1]
+
+	t.Return = current.ret
+	if t.Return == nil {
+		// NOTE: a function that never returns (it always calls itself) - its value can be anything
+		t.Return = c.newVariable()
+	}
+	return t
+}
+
+// WARN: Helper method used only in function - where the error about the value of the last statement goes
+func implicitReturn(fn *ast.FunctionLiteral) ast.Node {
+	if len(fn.Body.Statements) == 0 {
+		return fn
+	}
+	last := fn.Body.Statements[len(fn.Body.Statements)-1]
+	if stmt, ok := last.(*ast.ExpressionStatement); ok && stmt.Expression != nil {
+		return stmt.Expression
+	}
+	return last
+}
+
+// NOTE: an unannotated parameter gets a type variable, a default pins it down to the type of the default
+func (c *checker) parameter(param ast.Pattern) Type {
+	switch param := param.(type) {
+	case *ast.IdentifierPattern:
+		var t Type = c.newVariable()
+		if param.Type != nil {
+			t = c.annotation(param.Type)
+		}
+		c.scope.names[param.Name.Value] = &scheme{typ: t, annotated: param.Type != nil}
+		return t
+	case *ast.DefaultPattern:
+		value := c.value(param.Default)
+		t := c.parameter(param.Pattern)
+		c.expect(t, value, param.Default, "cannot use %s as %s as a default value")
+		return t
+	}
+
+	t := c.newVariable()
+	c.bindPattern(param, t)
+	return t
+}
+
+// NOTE: the return type of the function it's in - the joined values without an annotation. A return outside of a
+// function ends the program, there is nothing to check.
+func (c *checker) returned(value Type, node ast.Node) {
+	if len(c.functions) == 0 {
+		return
+	}
+	current := c.functions[len(c.functions)-1]
+	if current.annotated {
+		c.expect(current.ret, value, node, "cannot return %s from a function returning %s")
+		return
+	}
+	current.ret = join(current.ret, value)
+}
+
+// NOTE: the arguments after a spread (f(...xs)) can't be told apart, so they are only checked on their own
+func (c *checker) call(node *ast.CallExpression) Type {
+	callee := c.value(node.Function)
+
+	args, nodes := []Type{}, []ast.Expression{}
+	spread := false
+	for _, arg := range node.Arguments {
+		t := c.value(arg)
+		if _, ok := arg.(*ast.SpreadExpression); ok {
+			spread = true
+		}
+		if !spread {
+			args, nodes = append(args, t), append(nodes, arg)
+		}
+	}
+
+	switch f := prune(callee).(type) {
+	case *Function:
+		if !spread {
+			if err := checkArity(f, len(args)); err != "" {
+				c.errorf(node.Token, "", "%s", err)
+				return f.Return
+			}
+		}
+		for idx, arg := range args {
+			param := f.Rest
+			if idx < len(f.Params) {
+				param = f.Params[idx]
+			}
+			if param != nil {
+				c.expect(param, arg, nodes[idx], "cannot use %s as %s in argument %d of %s", idx+1, calleeName(node.Function))
+			}
+		}
+		return f.Return
+	case *Variable:
+		// NOTE: a parameter that is called is a function taking what it's called with
+		ret := c.newVariable()
+		if !spread {
+			unify(f, &Function{Params: args, Required: len(args), Return: ret})
+		}
+		return ret
+	case *Basic:
+		if f == Any {
+			return Any
+		}
+	}
+
+	c.errorf(node.Token, "", "not a function: %s", callee)
+	return Any
+}
+
+// WARN: Helper method used only in call - a function literal is too long for a message
+func calleeName(callee ast.Expression) string {
+	if ident, ok := callee.(*ast.Identifier); ok {
+		return ident.Value
+	}
+	return "the function"
+}
+
+// WARN: Helper method used only in call - the messages of the evaluator's checkArity, "" when the count is fine
+func checkArity(f *Function, got int) string {
+	switch {
+	case f.Rest != nil && got < f.Required:
+		return fmt.Sprintf("wrong number of arguments. got=%d, want>=%d", got, f.Required)
+	case f.Rest != nil:
+		return ""
+	case f.Required == len(f.Params) && got != f.Required:
+		return fmt.Sprintf("wrong number of arguments. got=%d, want=%d", got, f.Required)
+	case got < f.Required || got > len(f.Params):
+		return fmt.Sprintf("wrong number of arguments. got=%d, want=%d..%d", got, f.Required, len(f.Params))
+	}
+	return ""
+}
+
+// NOTE: the elements of different types make an array of `any`, an empty one can still become anything
+func (c *checker) array(node *ast.ArrayLiteral) Type {
+	var element Type
+	for _, el := range node.Elements {
+		spread, ok := el.(*ast.SpreadExpression)
+		if !ok {
+			element = join(element, c.value(el))
+			continue
+		}
+		var t Type = Any
+		if array, ok := prune(c.value(spread.Value)).(*Array); ok {
+			t = array.Element
+		}
+		element = join(element, t)
+	}
+	if element == nil {
+		element = c.newVariable()
+	}
+	return &Array{Element: element}
+}
+
+func (c *checker) hash(node *ast.HashLiteral) Type {
+	pairs := make([]ast.Expression, 0, len(node.Pairs))
+	for key := range node.Pairs {
+		pairs = append(pairs, key)
+	}
+	// NOTE: the pairs are a map - checked in source order, so the errors (and the joins) don't depend on its order
+	slices.SortFunc(pairs, func(a, b ast.Expression) int {
+		ta, _ := ast.TokenOf(a)
+		tb, _ := ast.TokenOf(b)
+		return cmp.Or(cmp.Compare(ta.Line, tb.Line), cmp.Compare(ta.Column, tb.Column))
+	})
+
+	var key, value Type
+	for _, k := range pairs {
+		t := c.value(k)
+		c.hashable(t, k)
+		key = join(key, t)
+		value = join(value, c.value(node.Pairs[k]))
+	}
+	if key == nil {
+		key, value = c.newVariable(), c.newVariable()
+	}
+	return &Hash{Key: key, Value: value}
+}
+
+// NOTE: only ints, strings and bools can be hash keys
+func (c *checker) hashable(t Type, node ast.Node) {
+	switch t := prune(t).(type) {
+	case *Basic:
+		if t == Int || t == String || t == Bool || t == Any {
+			return
+		}
+	case *Variable:
+		return
+	}
+	tok, _ := ast.TokenOf(node)
+	c.errorf(tok, "", "unusable as hash key: %s", t)
+}
+
+func (c *checker) index(left, index ast.Expression, tok token.Token) Type {
+	container, key := c.value(left), c.value(index)
+	switch t := prune(container).(type) {
+	case *Array:
+		if c.expect(Int, key, index, "cannot index %[3]s with %[1]s", t) {
+			return t.Element
+		}
+		return Any
+	case *Hash:
+		c.hashable(key, index)
+		if c.expect(t.Key, key, index, "cannot index %[3]s with %[1]s", t) {
+			return t.Value
+		}
+		return Any
+	case *Basic:
+		switch t {
+		case String:
+			if c.expect(Int, key, index, "cannot index string with %[1]s") {
+				return String
+			}
+			return Any
+		case Any:
+			return Any
+		}
+	case *Variable:
+		return Any
+	}
+
+	c.errorf(tok, "", "index operator not supported: %s", container)
+	return Any
+}
+
+func (c *checker) slice(node *ast.SliceExpression) Type {
+	container := c.value(node.Left)
+	for _, bound := range []ast.Expression{node.Start, node.End, node.Step} {
+		if bound != nil {
+			c.expect(Int, c.value(bound), bound, "cannot use %s as %s as a slice bound")
+		}
+	}
+
+	switch t := prune(container).(type) {
+	case *Array:
+		return t
+	case *Basic:
+		if t == String || t == Any {
+			return t
+		}
+	case *Variable:
+		return Any
+	}
+	c.errorf(node.Token, "", "slice operator not supported: %s", container)
+	return Any
+}
+
+// NOTE: only index expressions can be assigned to - the value has to fit what's in the array or the hash
+func (c *checker) assign(node *ast.AssignExpression) Type {
+	value := c.value(node.Value)
+	target, ok := node.Target.(*ast.IndexExpression)
+	if !ok {
+		return value
+	}
+
+	element := c.index(target.Left, target.Index, target.Token)
+	if c.annotated(target.Left) {
+		c.expect(element, value, node.Value, "cannot assign %s to an element of type %s")
+	}
+	return value
+}
+
+// WARN: Helper method used only in assign - xs[0][1] = ... is checked when xs is annotated
+func (c *checker) annotated(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.IndexExpression:
+		return c.annotated(exp.Left)
+	case *ast.Identifier:
+		found := c.scope.lookup(exp.Value)
+		return found != nil && found.annotated
+	}
+	return false
+}
+
+func (c *checker) match(node *ast.MatchExpression) Type {
+	subject := c.value(node.Subject)
+
+	var result Type
+	for _, arm := range node.Arms {
+		outer := c.scope
+		c.scope = &scope{parent: outer, names: map[string]*scheme{}}
+		c.bindPattern(arm.Pattern, subject)
+		if arm.Guard != nil {
+			c.value(arm.Guard)
+		}
+		result = join(result, c.value(arm.Body))
+		c.scope = outer
+	}
+	// NOTE: without an arm that matches, the match gives null
+	if result == nil {
+		return Null
+	}
+	return result
+}
+
+// INFO: ==================================== Annotations ====================================
+
+func (c *checker) annotation(typ ast.Type) Type {
+	switch typ := typ.(type) {
+	case *ast.NamedType:
+		if t, ok := basics[typ.Name]; ok {
+			return t
+		}
+		names := make([]string, 0, len(basics))
+		for name := range basics {
+			names = append(names, name)
+		}
+		hint := ""
+		if suggestion := suggest.Closest(typ.Name, names); suggestion != "" {
+			hint = fmt.Sprintf("did you mean `%s`?", suggestion)
+		}
+		c.errorf(typ.Token, hint, "unknown type: %s", typ.Name)
+		return Any
+	case *ast.ArrayType:
+		return &Array{Element: c.annotation(typ.Element)}
+	case *ast.HashType:
+		key := c.annotation(typ.Key)
+		c.hashable(key, typ.Key)
+		return &Hash{Key: key, Value: c.annotation(typ.Value)}
+	case *ast.FunctionType:
+		t := &Function{Return: Any}
+		for _, param := range typ.Parameters {
+			t.Params = append(t.Params, c.annotation(param))
+		}
+		t.Required = len(t.Params)
+		if typ.Return != nil {
+			t.Return = c.annotation(typ.Return)
+		}
+		return t
+	}
+	return Any
+}
+
+// INFO: ==================================== Type variables ====================================
+
+func (c *checker) newVariable() *Variable {
+	c.nextID++
+	return &Variable{id: c.nextID, level: c.level}
+}
+
+// NOTE: the variables created deeper than the current let (and not tied to anything outside of it since) become generic
+func (c *checker) generalize(t Type) *scheme {
+	s := &scheme{typ: t}
+	seen := map[*Variable]bool{}
+
+	var collect func(t Type)
+	collect = func(t Type) {
+		switch t := prune(t).(type) {
+		case *Variable:
+			if t.level > c.level && !seen[t] {
+				seen[t] = true
+				s.generic = append(s.generic, t)
+			}
+		case *Array:
+			collect(t.Element)
+		case *Hash:
+			collect(t.Key)
+			collect(t.Value)
+		case *Function:
+			for _, param := range t.Params {
+				collect(param)
+			}
+			if t.Rest != nil {
+				collect(t.Rest)
+			}
+			collect(t.Return)
+		}
+	}
+
+	collect(t)
+	return s
+}
+
+// NOTE: the type of the scheme with fresh variables in place of the generic ones
+func (c *checker) instantiate(s *scheme) Type {
+	if len(s.generic) == 0 {
+		return s.typ
+	}
+	fresh := map[*Variable]Type{}
+	for _, v := range s.generic {
+		fresh[v] = c.newVariable()
+	}
+
+	var substitute func(t Type) Type
+	substitute = func(t Type) Type {
+		switch t := prune(t).(type) {
+		case *Variable:
+			if replacement, ok := fresh[t]; ok {
+				return replacement
+			}
+			return t
+		case *Array:
+			return &Array{Element: substitute(t.Element)}
+		case *Hash:
+			return &Hash{Key: substitute(t.Key), Value: substitute(t.Value)}
+		case *Function:
+			f := &Function{Required: t.Required, Return: substitute(t.Return)}
+			for _, param := range t.Params {
+				f.Params = append(f.Params, substitute(param))
+			}
+			if t.Rest != nil {
+				f.Rest = substitute(t.Rest)
+			}
+			return f
+		default:
+			return t
+		}
+	}
+	return substitute(s.typ)
+}
+
+// INFO: ==================================== Errors ====================================
+
+// NOTE: reports when a value of type got can't be used where want is expected - the format gets got and want first,
+// then the args. The types are printed before unifying, as a failed unification can leave some variables bound.
+func (c *checker) expect(want, got Type, node ast.Node, format string, args ...any) bool {
+	// NOTE: an int is fine where a float is expected, the evaluator mixes them anyway
+	if prune(want) == Float && prune(got) == Int {
+		return true
+	}
+	wantString, gotString := want.String(), got.String()
+	if unify(want, got) {
+		return true
+	}
+
+	tok, _ := ast.TokenOf(node)
+	c.errorf(tok, "", format, append([]any{gotString, wantString}, args...)...)
+	return false
+}
+
+func (c *checker) errorf(tok token.Token, hint string, format string, args ...any) {
+	c.diagnostics = append(c.diagnostics, parser.Diagnostic{
+		Severity: parser.SeverityError,
+		Line:     tok.Line,
+		Column:   tok.Column,
+		Message:  fmt.Sprintf(format, args...),
+		Found:    tok,
+		Hint:     hint,
+	})
+}
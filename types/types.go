@@ -0,0 +1,259 @@
+package types
+
+import (
+	"strings"
+)
+
+// INFO: The types of the checker - the basic ones, arrays, hashes, functions and the type variables of the inference.
+//
+// `any` is what makes the typing gradual: it is the type of everything the checker can't tell (an unannotated
+// value of mixed types, most builtins, names it doesn't know) and it goes with every other type both ways, so
+// untyped code only gets errors where the types clash for sure.
+
+type Type interface {
+	String() string
+}
+
+type Basic struct {
+	Name string
+}
+
+var (
+	Int    = &Basic{Name: "int"}
+	Float  = &Basic{Name: "float"}
+	String = &Basic{Name: "string"}
+	Bool   = &Basic{Name: "bool"}
+	Null   = &Basic{Name: "null"}
+	Any    = &Basic{Name: "any"}
+)
+
+// NOTE: the names usable in annotations
+var basics = map[string]*Basic{"int": Int, "float": Float, "string": String, "bool": Bool, "null": Null, "any": Any}
+
+type Array struct {
+	Element Type
+}
+
+type Hash struct {
+	Key   Type
+	Value Type
+}
+
+type Function struct {
+	Params []Type
+	// NOTE: the parameters without a default - the ones after them can be left out
+	Required int
+	Rest     Type // the type of the elements of a ...rest parameter, nil without one
+	Return   Type
+}
+
+// NOTE: a type not known yet - unification binds it to the type it has to be (instance). The level is the let nesting
+// it was created at, the variables created deeper than the let being generalized are the ones that become generic.
+type Variable struct {
+	id       int
+	level    int
+	instance Type
+}
+
+func (b *Basic) String() string    { return b.Name }
+func (a *Array) String() string    { return typeString(a) }
+func (h *Hash) String() string     { return typeString(h) }
+func (f *Function) String() string { return typeString(f) }
+func (v *Variable) String() string { return typeString(v) }
+
+// NOTE: the type the variables stand for - a variable not bound yet stays itself
+func prune(t Type) Type {
+	for {
+		v, ok := t.(*Variable)
+		if !ok || v.instance == nil {
+			return t
+		}
+		t = v.instance
+	}
+}
+
+// INFO: ==================================== Printing ====================================
+
+// NOTE: the type as it would be annotated, with the unbound variables named a, b, c, ... in the order they come in.
+// Optional parameters get a ?, a rest parameter is ...T
+func typeString(t Type) string {
+	names := map[*Variable]string{}
+	var out strings.Builder
+
+	var write func(t Type)
+	write = func(t Type) {
+		switch t := prune(t).(type) {
+		case *Basic:
+			out.WriteString(t.Name)
+		case *Array:
+			out.WriteString("[")
+			write(t.Element)
+			out.WriteString("]")
+		case *Hash:
+			out.WriteString("{")
+			write(t.Key)
+			out.WriteString(": ")
+			write(t.Value)
+			out.WriteString("}")
+		case *Function:
+			out.WriteString("fn(")
+			for idx, param := range t.Params {
+				if idx > 0 {
+					out.WriteString(", ")
+				}
+				write(param)
+				if idx >= t.Required {
+					out.WriteString("?")
+				}
+			}
+			if t.Rest != nil {
+				if len(t.Params) > 0 {
+					out.WriteString(", ")
+				}
+				out.WriteString("...")
+				write(t.Rest)
+			}
+			out.WriteString("): ")
+			write(t.Return)
+		case *Variable:
+			name, ok := names[t]
+			if !ok {
+				name = variableName(len(names))
+				names[t] = name
+			}
+			out.WriteString(name)
+		}
+	}
+
+	write(t)
+	return out.String()
+}
+
+// WARN: Helper method used only in typeString - a, b, ..., z, a1, b1, ...
+func variableName(idx int) string {
+	name := string(rune('a' + idx%26))
+	if idx >= 26 {
+		name += strings.Repeat("'", idx/26)
+	}
+	return name
+}
+
+// INFO: ==================================== Unification ====================================
+
+// NOTE: makes the two types the same by binding the variables in them - false when they can't be. `any` unifies with
+// everything (without binding anything), the parameters of two functions only as far as both have them, so a function
+// taking fewer arguments fits where more are passed (like the callbacks of map and filter).
+// NOTE: a failed unification can leave some of the variables bound - the checker reports it and goes on with `any`
+func unify(a, b Type) bool {
+	a, b = prune(a), prune(b)
+	if a == b || a == Any || b == Any {
+		return true
+	}
+
+	if v, ok := a.(*Variable); ok {
+		return bind(v, b)
+	}
+	if v, ok := b.(*Variable); ok {
+		return bind(v, a)
+	}
+
+	switch a := a.(type) {
+	case *Array:
+		b, ok := b.(*Array)
+		return ok && unify(a.Element, b.Element)
+	case *Hash:
+		b, ok := b.(*Hash)
+		return ok && unify(a.Key, b.Key) && unify(a.Value, b.Value)
+	case *Function:
+		b, ok := b.(*Function)
+		if !ok {
+			return false
+		}
+		for idx := range min(len(a.Params), len(b.Params)) {
+			if !unify(a.Params[idx], b.Params[idx]) {
+				return false
+			}
+		}
+		return unify(a.Return, b.Return)
+	}
+	return false
+}
+
+// WARN: Helper method used only in unify - a variable can't be bound to a type containing itself (a = [a])
+func bind(v *Variable, t Type) bool {
+	if occurs(v, t) {
+		return false
+	}
+	v.instance = t
+	return true
+}
+
+// NOTE: also lowers the level of the variables in t to the one of v - they are now known where v is, so they
+// can't be generalized any deeper than v could
+func occurs(v *Variable, t Type) bool {
+	switch t := prune(t).(type) {
+	case *Variable:
+		if t == v {
+			return true
+		}
+		t.level = min(t.level, v.level)
+		return false
+	case *Array:
+		return occurs(v, t.Element)
+	case *Hash:
+		return occurs(v, t.Key) || occurs(v, t.Value)
+	case *Function:
+		for _, param := range t.Params {
+			if occurs(v, param) {
+				return true
+			}
+		}
+		return (t.Rest != nil && occurs(v, t.Rest)) || occurs(v, t.Return)
+	}
+	return false
+}
+
+// NOTE: the type of a value that is one of the two (the branches of an if, the elements of an array) - `any` when they
+// don't go together, as mixing the types is fine as long as nothing needs one of them. nil stands for no value at all
+// (a branch that always returns).
+func join(a, b Type) Type {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	a, b = prune(a), prune(b)
+	switch {
+	case a == b:
+		return a
+	case a == Any || b == Any:
+		return Any
+	case (a == Int && b == Float) || (a == Float && b == Int):
+		return Float
+	}
+
+	// NOTE: a variable isn't unified with the other type - [a, b] of two parameters can hold an int and a string
+	_, aVar := a.(*Variable)
+	_, bVar := b.(*Variable)
+	if aVar || bVar {
+		return Any
+	}
+
+	switch a := a.(type) {
+	case *Array:
+		if b, ok := b.(*Array); ok {
+			return &Array{Element: join(a.Element, b.Element)}
+		}
+	case *Hash:
+		if b, ok := b.(*Hash); ok {
+			return &Hash{Key: join(a.Key, b.Key), Value: join(a.Value, b.Value)}
+		}
+	case *Function:
+		if b, ok := b.(*Function); ok && len(a.Params) == len(b.Params) && unify(a, b) {
+			return a
+		}
+	}
+	return Any
+}
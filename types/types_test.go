@@ -0,0 +1,189 @@
+package types
+
+import (
+	"fmt"
+	"mfiorek/waiig/ast"
+	"mfiorek/waiig/evaluator"
+	"mfiorek/waiig/lexer"
+	"mfiorek/waiig/parser"
+	"strings"
+	"testing"
+)
+
+func TestInference(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		expected string
+	}{
+		{"let x = 5;", "x", "int"},
+		{"let x: float = 5;", "x", "float"},
+		{"let x = 1 + 2.5;", "x", "float"},
+		{`let x = "a" + "b";`, "x", "string"},
+		{"let x = 1 < 2;", "x", "bool"},
+		{"let x = [1, 2, 3];", "x", "[int]"},
+		{"let x = [1, 2.5];", "x", "[float]"},
+		{`let x = [1, "a"];`, "x", "[any]"},
+		{"let x = [];", "x", "[a]"},
+		{`let x = {"a": 1, "b": 2};`, "x", "{string: int}"},
+		{"let x = if (true) { 1 } else { 2 };", "x", "int"},
+		{"let x = if (true) { 1 };", "x", "any"},
+		{"let id = fn(x) { x };", "id", "fn(a): a"},
+		{"let inc = fn(x) { x + 1 };", "inc", "fn(a): any"},
+		{"let add = fn(a, b) { a + b };", "add", "fn(a, b): any"},
+		{`let shout = fn(s) { s + "!" };`, "shout", "fn(string): string"},
+		{"let mask = fn(x) { x & 1 };", "mask", "fn(int): int"},
+		{"let pair = fn(a, b) { [a, b] };", "pair", "fn(a, b): [any]"},
+		{"let x = push([1], 2);", "x", "[any]"},
+		{"let greet = fn(name: string, greeting = \"hi\") { greeting + name };", "greet", "fn(string, string?): string"},
+		{"let f = fn(a, ...rest) { rest };", "f", "fn(a, ...any): [any]"},
+		{"let apply = fn(f, x) { f(x) };", "apply", "fn(fn(a): b, a): b"},
+		{"let fact = fn(n) { if (n < 2) { return 1; } n * fact(n - 1) };", "fact", "fn(a): any"},
+		{"let f = fn(): int { return 1; };", "f", "fn(): int"},
+		{"let x = len([1]);", "x", "int"},
+		{"let x = map([1, 2], fn(n) { n * 2.5 });", "x", "[float]"},
+		{"let half = fn(n) { n / 2.0 };", "half", "fn(a): float"},
+		{"let x = first([\"a\"]);", "x", "string"},
+		{"let id = fn(x) { x }; let a = id(1); let b = id(\"s\");", "b", "string"},
+		{"let x = [1, 2][0];", "x", "int"},
+		{"let x = \"abc\"[1:];", "x", "string"},
+		{`let x = {"a": [1]}["a"];`, "x", "[int]"},
+		{"let x = match (1) { 1 => \"one\", _ => \"many\" };", "x", "string"},
+		{"let [a, b] = [1, 2]; let x = a;", "x", "int"},
+		{"let x = PI * 2;", "x", "float"},
+		{"let x = now();", "x", "any"},
+	}
+
+	for _, tt := range tests {
+		checker := check(parse(t, tt.input))
+		testNoErrors(t, tt.input, checker.diagnostics)
+		typ := checker.lookup(tt.name)
+		if typ == nil {
+			t.Errorf("no type for %s in %q", tt.name, tt.input)
+			continue
+		}
+		if typ.String() != tt.expected {
+			t.Errorf("wrong type of %s in %q. expected=%q, got=%q", tt.name, tt.input, tt.expected, typ.String())
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`1 + "a"`, []string{`1:3 type mismatch: int + string`}},
+		{`"a" - "b"`, []string{`1:5 unknown operator: string - string`}},
+		{`true + true`, []string{`1:6 unknown operator: bool + bool`}},
+		{`-"a"`, []string{`1:1 unknown operator: -string`}},
+		{`~1.5`, []string{`1:1 unknown operator: ~float`}},
+		{`let x: int = "five";`, []string{`1:14 cannot use string as int in the declaration of x`}},
+		{`let x: [int] = ["a"];`, []string{`1:16 cannot use [string] as [int] in the declaration of x`}},
+		{`let x: [int] = [1, "a"];`, nil},
+		{`let f = fn(a: int) { a }; f("a");`, []string{`1:29 cannot use string as int in argument 1 of f`}},
+		{`let f = fn(a: int): string { a };`, []string{`1:30 cannot return int from a function returning string`}},
+		{`let f = fn(a): bool { return 1; };`, []string{`1:30 cannot return int from a function returning bool`}},
+		{`let f = fn(a, b = 1) { a }; f(); f(1, 2, 3);`, []string{
+			`1:30 wrong number of arguments. got=0, want=1..2`,
+			`1:35 wrong number of arguments. got=3, want=1..2`,
+		}},
+		{`let f = fn(a, ...rest) { a }; f();`, []string{`1:32 wrong number of arguments. got=0, want>=1`}},
+		{`len([1], [2])`, []string{`1:4 wrong number of arguments. got=2, want=1`}},
+		{`let x = 5; x(1)`, []string{`1:13 not a function: int`}},
+		{`let shout = fn(s) { s + "!" }; shout(1)`, []string{`1:38 cannot use int as string in argument 1 of shout`}},
+		{`[1, 2]["a"]`, []string{`1:8 cannot index [int] with string`}},
+		{`5[0]`, []string{`1:2 index operator not supported: int`}},
+		{`{[1]: 2}`, []string{`1:2 unusable as hash key: [int]`}},
+		{`let xs: [int] = [1]; xs[0] = "a";`, []string{`1:30 cannot assign string to an element of type int`}},
+		{`let f = fn(xs: [[int]]) { xs[0][0] = "a" };`, []string{`1:38 cannot assign string to an element of type int`}},
+		{`let x: integer = 1;`, []string{`1:8 unknown type: integer`}},
+		{`let f = fn(g: fn(int): int) { g(1) }; f(fn(s) { s + "a" });`, []string{
+			`1:41 cannot use fn(string): string as fn(int): int in argument 1 of f`,
+		}},
+		{`upper(1)`, []string{`1:7 cannot use int as string in argument 1 of upper`}},
+		{`let f = fn(x: float) { x }; f(1);`, nil},
+		{`let f = fn(x) { x }; f(...[1, 2]);`, nil},
+		{`let x: any = 1; x + "a"; x(1); x[0];`, nil},
+		{`let f = fn(x) { 1 }; f(undefined_name + 1)`, nil},
+	}
+
+	for _, tt := range tests {
+		testErrors(t, tt.input, Check(parse(t, tt.input)), tt.expected)
+	}
+}
+
+func TestHints(t *testing.T) {
+	diagnostics := Check(parse(t, "let x: strng = 1;"))
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic. got=%d (%v)", len(diagnostics), diagnostics)
+	}
+	if diagnostics[0].Hint != "did you mean `string`?" {
+		t.Errorf("wrong hint. got=%q", diagnostics[0].Hint)
+	}
+}
+
+// NOTE: the untyped programs that run fine must check fine too - the typing is gradual
+func TestUntypedPrograms(t *testing.T) {
+	tests := []string{
+		`let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; puts(fib(10));`,
+		`let people = [{"name": "Ann", "age": 30}, {"name": "Bob", "age": 25}];
+let names = map(people, fn(p) { p["name"] });
+puts(join(names, ", "), len(people));`,
+		`let counter = fn() { let count = [0]; fn() { count[0] = count[0] + 1; count[0] } };
+let next = counter(); next(); puts(next());`,
+		`let describe = fn(x) { match (x) { 0 => "zero", [a, ...rest] => "array", {"k": v} => v, _ => "other" } };
+puts(describe(0), describe([1]), describe({"k": "v"}));`,
+		`let mixed = [1, "two", 3.0, true, fn() { 1 }, [1], {}]; puts(mixed[1], len(mixed));`,
+		`let sum = reduce([1, 2, 3], fn(acc, x) { acc + x }, 0); puts(sum / 2, sum * 1.5);`,
+		`let f = fn(a, b = 2, ...rest) { a + b + len(rest) }; puts(f(1), f(1, 2, 3, 4));`,
+		`let x = if (len([]) > 0) { "some" } else { 0 }; puts(x);`,
+		`let t = now(); let d = since(t); puts(duration_seconds(d) + 1);`,
+		`let f = fn(x) { x + 1 }; f(1.5)`,
+		`let add = fn(a, b) { a + b }; add(1, 2.5)`,
+		`let pair = fn(a, b) { [a, b] }; pair(1, "a")`,
+		`let xs = [1]; xs[0] = "a"`,
+		`let ys = push([], 1); push(ys, "a")`,
+		`let f = fn(...args) { len(args) }; f(1, "a", true)`,
+	}
+
+	for _, input := range tests {
+		testNoErrors(t, input, Check(parse(t, input)))
+	}
+}
+
+func TestBuiltinTypes(t *testing.T) {
+	for name := range builtinTypes {
+		if evaluator.BuiltinDoc(name) == "" {
+			t.Errorf("%s has a type but is no builtin", name)
+		}
+	}
+}
+
+// INFO: ==================================== Helper methods ====================================
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
+
+func testErrors(t *testing.T, input string, diagnostics []parser.Diagnostic, expected []string) {
+	t.Helper()
+	got := []string{}
+	for _, d := range diagnostics {
+		got = append(got, fmt.Sprintf("%d:%d %s", d.Line, d.Column, d.Message))
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong errors for %q.\nexpected=%q\ngot=     %q", input, expected, got)
+	}
+}
+
+func testNoErrors(t *testing.T, input string, diagnostics []parser.Diagnostic) {
+	t.Helper()
+	testErrors(t, input, diagnostics, nil)
+}